@@ -13,7 +13,9 @@ import (
 	"strings"
 
 	"github.com/postfinance/topf/internal/cmd/apply"
+	"github.com/postfinance/topf/internal/configdiff"
 	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/progress"
 	"github.com/postfinance/topf/internal/topf"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
 	"github.com/urfave/cli/v3"
@@ -24,7 +26,7 @@ func newApplyCmd() *cli.Command {
 		Name:        "apply",
 		Usage:       "apply configuration changes to a running cluster",
 		Description: `This command applies configuration changes to nodes in a running Talos cluster.`,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "dry-run",
 				Usage:   "only show what changes would be applied without actually applying them",
@@ -61,13 +63,24 @@ func newApplyCmd() *cli.Command {
 				Usage:   "apply mode: " + strings.Join(validApplyModes(), ", "),
 				Sources: cli.EnvVars("TOPF_MODE"),
 			},
+			&cli.StringFlag{
+				Name:    "diff-format",
+				Value:   string(configdiff.FormatUnified),
+				Usage:   "format of the printed configuration diff: unified, json",
+				Sources: cli.EnvVars("TOPF_DIFF_FORMAT"),
+			},
 			&cli.StringFlag{
 				Name:    "max-parallel",
 				Value:   "1",
 				Usage:   "number of worker nodes to apply to concurrently, as an integer (e.g. \"5\") or a percentage of the total node count (e.g. \"25%\"); control-plane nodes are always applied to one at a time",
 				Sources: cli.EnvVars("TOPF_MAX_PARALLEL"),
 			},
-		},
+			newAllowQuorumLossFlag(),
+			newSkipHealthGatesFlag(),
+			newSkipHooksFlag(),
+			newReportFlag(),
+			newUIFlag(),
+		}, slices.Concat(newEtcdBackupFlags(), newRolloutFlags(), newTelemetryFlags())...),
 		Before: noPositionalArgs,
 		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
@@ -77,20 +90,53 @@ func newApplyCmd() *cli.Command {
 				return err
 			}
 
+			diffFormat, err := configdiff.ParseFormat(c.String("diff-format"))
+			if err != nil {
+				return err
+			}
+
 			maxParallel, err := nodepool.ParseMaxParallel(c.String("max-parallel"))
 			if err != nil {
 				return err
 			}
 
-			err = apply.Execute(ctx, t, apply.Options{
-				DryRun:               c.Bool("dry-run"),
-				AutoBootstrap:        c.Bool("auto-bootstrap"),
-				SkipProblematicNodes: c.Bool("skip-problematic-nodes"),
-				SkipPostApplyChecks:  c.Bool("skip-post-apply-checks"),
-				AllowNotReady:        c.Bool("allow-not-ready"),
-				Mode:                 mode,
-				MaxParallel:          maxParallel,
+			waves, err := newWaves(t, c)
+			if err != nil {
+				return err
+			}
+
+			rep, err := newReport(c, c.Bool("dry-run"))
+			if err != nil {
+				return err
+			}
+
+			controller, stopControl, err := newController(ctx, t, c)
+			if err != nil {
+				return err
+			}
+			defer stopControl()
+
+			err = withObservers(ctx, c, t, c.Bool("dry-run"), func(ctx context.Context, tracker *progress.Tracker) error {
+				return apply.Execute(ctx, t, apply.Options{
+					DryRun:               c.Bool("dry-run"),
+					AutoBootstrap:        c.Bool("auto-bootstrap"),
+					SkipProblematicNodes: c.Bool("skip-problematic-nodes"),
+					SkipPostApplyChecks:  c.Bool("skip-post-apply-checks"),
+					AllowNotReady:        c.Bool("allow-not-ready"),
+					Mode:                 mode,
+					DiffFormat:           diffFormat,
+					AllowQuorumLoss:      c.Bool(allowQuorumLossFlag),
+					Waves:                waves,
+					Control:              controller,
+					MaxParallel:          maxParallel,
+					EtcdBackupDir:        etcdBackupDir(c),
+					HealthGates:          newHealthGates(t, c),
+					Hooks:                newHooks(t, c, c.Bool("dry-run")),
+					Report:               rep,
+					Progress:             tracker,
+				})
 			})
+			err = writeReport(t, c, rep, err)
 			if errors.Is(err, topf.ErrDryRunChangesDetected) {
 				return cli.Exit(err.Error(), 2)
 			}
@@ -6,10 +6,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/postfinance/topf/internal/cmd/clusterinfo"
+	pkgclusterinfo "github.com/postfinance/topf/pkg/clusterinfo"
 	"github.com/urfave/cli/v3"
-	"go.yaml.in/yaml/v4"
 )
 
 func newClusterInfoCmd() *cli.Command {
@@ -17,7 +19,10 @@ func newClusterInfoCmd() *cli.Command {
 		Name:   "clusterinfo",
 		Usage:  "output non-sensitive cluster information",
 		Before: noPositionalArgs,
-		Action: func(ctx context.Context, _ *cli.Command) error {
+		Flags: []cli.Flag{
+			newOutputFlag(outputYAML, true),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
 			clusterInfo, err := clusterinfo.Get(t)
@@ -25,14 +30,45 @@ func newClusterInfoCmd() *cli.Command {
 				return err
 			}
 
-			yamlBytes, err := yaml.Marshal(clusterInfo)
-			if err != nil {
-				return err
-			}
+			return writeOutput(c, output{
+				data: clusterInfo,
+				table: func(w io.Writer, wide bool) error {
+					renderClusterInfoTable(w, clusterInfo, wide)
+					return nil
+				},
+			})
+		},
+	}
+}
 
-			fmt.Println(string(yamlBytes))
+// renderClusterInfoTable leaves out the CA certificates, which are only
+// useful in machine-readable formats
+func renderClusterInfoTable(w io.Writer, info *pkgclusterinfo.ClusterInfo, wide bool) {
+	fmt.Fprintf(w, "Cluster: %s\nEndpoint: %s\nKubernetes: %s\n\n", info.ClusterName, info.ClusterEndpoint, info.KubernetesVersion)
 
-			return nil
-		},
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+
+	header := table.Row{"Host", "IP", "Role"}
+	if wide {
+		header = append(header, "Talos", "Schematic", "Platform")
 	}
+
+	tw.AppendHeader(header)
+
+	for _, node := range info.Nodes {
+		var ip string
+		if node.IP != nil {
+			ip = node.IP.String()
+		}
+
+		row := table.Row{node.Host, ip, node.Role}
+		if wide {
+			row = append(row, node.TalosVersion, node.SchematicID, node.Platform)
+		}
+
+		tw.AppendRow(row)
+	}
+
+	tw.Render()
 }
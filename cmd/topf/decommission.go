@@ -0,0 +1,69 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/postfinance/topf/internal/cmd/decommission"
+	"github.com/siderolabs/talos/cmd/talosctl/pkg/talos/nodedrain"
+	"github.com/urfave/cli/v3"
+)
+
+func newDecommissionCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "decommission",
+		Usage: "remove node(s) from the cluster for good",
+		Description: `Decommissions the nodes selected by --nodes-filter one at a time: the Kubernetes node is cordoned and drained, ` +
+			`the etcd member of control-plane nodes is removed (refused if etcd would lose quorum), the machine is wiped and the Kubernetes Node object is deleted. ` +
+			`Remove the nodes from topf.yaml or the nodes provider afterwards.`,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "drain-timeout",
+				Usage:   "maximum time to wait for pod evictions (and, with --delete-if-eviction-fails, deletions) to complete during drain",
+				Value:   nodedrain.DefaultDrainTimeout,
+				Sources: cli.EnvVars("TOPF_DRAIN_TIMEOUT"),
+			},
+			&cli.BoolFlag{
+				Name:    "delete-if-eviction-fails",
+				Usage:   "if graceful drain fails (e.g. a PodDisruptionBudget blocks eviction), retry by deleting pods directly (DELETE instead of EVICT, bypassing PDBs)",
+				Value:   false,
+				Sources: cli.EnvVars("TOPF_DELETE_IF_EVICTION_FAILS"),
+			},
+			&cli.BoolFlag{
+				Name:    "shutdown",
+				Value:   false,
+				Usage:   "if true, shut down machine after reset. otherwise, machine reboots into maintenance mode.",
+				Sources: cli.EnvVars("TOPF_SHUTDOWN"),
+			},
+			newAllowQuorumLossFlag(),
+			newReportFlag(),
+		},
+		Before: noPositionalArgs,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			// without a filter, every node of the cluster would be decommissioned
+			if c.String("nodes-filter") == "" {
+				return errors.New("select the node(s) to decommission with --nodes-filter")
+			}
+
+			t := MustGetRuntime(ctx)
+
+			rep, err := newReport(c, false)
+			if err != nil {
+				return err
+			}
+
+			opts := decommission.Options{
+				DrainTimeout:          c.Duration("drain-timeout"),
+				DeleteIfEvictionFails: c.Bool("delete-if-eviction-fails"),
+				Shutdown:              c.Bool("shutdown"),
+				AllowQuorumLoss:       c.Bool(allowQuorumLossFlag),
+				Report:                rep,
+			}
+
+			return writeReport(t, c, rep, decommission.Execute(ctx, t, opts))
+		},
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/postfinance/topf/internal/drift"
+	"github.com/urfave/cli/v3"
+)
+
+// Exit codes of drift. Unchecked nodes take precedence, as the drift of the
+// cluster is unknown then.
+const (
+	exitDriftFound = 2
+	exitUnchecked  = 3
+)
+
+func newDriftCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "drift",
+		Usage: "detect drift between the nodes and topf.yaml",
+		Description: `Compares, per node, the rendered config (dry-run apply), the Talos version, the schematic and the kubelet version with topf.yaml. ` +
+			`Exits with code 2 if drift was found and with code 3 if any node could not be checked.`,
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			newOutputFlag(outputTable, true),
+			&cli.StringFlag{
+				Name:    "junit",
+				Usage:   "write a JUnit XML report with one test case per node to the given file",
+				Sources: cli.EnvVars("TOPF_JUNIT"),
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			rep, err := drift.Check(ctx, t)
+			if err != nil {
+				return err
+			}
+
+			if path := c.String("junit"); path != "" {
+				if err := writeJUnit(path, rep); err != nil {
+					return err
+				}
+			}
+
+			err = writeOutput(c, output{
+				data: rep,
+				table: func(w io.Writer, wide bool) error {
+					renderDriftTable(w, rep, wide)
+					return nil
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case rep.Unchecked():
+				return cli.Exit("could not check all nodes for drift", exitUnchecked)
+			case rep.Drifted():
+				return cli.Exit("drift found", exitDriftFound)
+			}
+
+			return nil
+		},
+	}
+}
+
+func writeJUnit(path string, rep *drift.Report) error {
+	f, err := os.Create(path) //nolint:gosec // writing to a user-provided path is by design
+	if err != nil {
+		return fmt.Errorf("failed to create junit report: %w", err)
+	}
+	defer f.Close()
+
+	if err := rep.WriteJUnit(f); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func renderDriftTable(w io.Writer, rep *drift.Report, wide bool) {
+	state := func(d drift.Drift) string {
+		switch {
+		case d.Drifted():
+			return d.Running + " → " + d.Desired
+		case d.Unknown():
+			return "unknown"
+		}
+
+		return d.Running
+	}
+
+	elipsis := func(s string) string {
+		if len(s) > 8 && !wide {
+			return s[:8] + "..."
+		}
+
+		return s
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	tw.AppendHeader(table.Row{"Host", "Role", "Result", "Config", "Talos", "Schematic", "Kubelet", "Errors"})
+
+	if !wide {
+		tw.SetColumnConfigs([]table.ColumnConfig{{Name: "Errors", WidthMax: 40}})
+	}
+
+	for _, n := range rep.Nodes {
+		result := "in sync"
+
+		switch {
+		case n.Drifted():
+			result = "drifted"
+		case n.Unchecked():
+			result = "unknown"
+		}
+
+		var configState string
+
+		switch {
+		case n.Config == nil, n.Config.Error != "":
+		case n.Config.Drifted:
+			configState = n.Config.Changes()
+		default:
+			configState = "in sync"
+		}
+
+		schematic := elipsis(n.Schematic.Running)
+
+		switch {
+		case n.Schematic.Drifted():
+			schematic += " → " + elipsis(n.Schematic.Desired)
+		case n.Schematic.Unknown():
+			schematic = "unknown"
+		}
+
+		tw.AppendRow(table.Row{n.Host, n.Role, result, configState, state(n.Talos), schematic, state(n.Kubernetes), strings.Join(slices.Concat(n.Unknown, n.Errors), "\n")})
+	}
+
+	tw.Render()
+}
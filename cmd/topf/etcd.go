@@ -0,0 +1,242 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/urfave/cli/v3"
+)
+
+func newEtcdCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "etcd",
+		Usage: "manage the etcd cluster of the control-plane nodes",
+		Commands: []*cli.Command{
+			newEtcdSnapshotCmd(),
+			newEtcdRecoverCmd(),
+			newEtcdMembersCmd(),
+			newEtcdRemoveMemberCmd(),
+			newEtcdLeaveCmd(),
+			newEtcdDefragCmd(),
+		},
+	}
+}
+
+func newEtcdSnapshotCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "snapshot",
+		Usage:       "save an etcd snapshot to a local file",
+		Description: `Streams an etcd snapshot from a control-plane node to a timestamped file (<clusterName>-etcd-<timestamp>.snapshot) and writes its SHA-256 checksum next to it (<file>.sha256).`,
+		Before:      noPositionalArgs,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "directory to write the snapshot to",
+				Value:   ".",
+				Sources: cli.EnvVars("TOPF_ETCD_SNAPSHOT_OUTPUT"),
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			result, err := etcd.Snapshot(ctx, t, c.String("output"), t.Logger().With("command", "etcd snapshot"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(t.Writer(), "Wrote etcd snapshot to %s (sha256 %s)\n", result.Path, result.SHA256)
+
+			return nil
+		},
+	}
+}
+
+func newEtcdRecoverCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "recover",
+		Usage: "recover etcd from a snapshot after losing quorum",
+		Description: `Uploads an etcd snapshot to the first selected control-plane node and bootstraps etcd from it. ` +
+			`etcd must not be running on that node: reset its EPHEMERAL partition first. ` +
+			`If a checksum file (<snapshot>.sha256) exists next to the snapshot, the snapshot is verified before the upload.`,
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "snapshot",
+				Usage:    "path to the etcd snapshot to recover from",
+				Required: true,
+				Sources:  cli.EnvVars("TOPF_SNAPSHOT"),
+			},
+			&cli.BoolFlag{
+				Name:    "skip-hash-check",
+				Usage:   "skip the integrity check of the snapshot by etcd; required for a database file copied from an etcd data directory instead of taken with \"etcd snapshot\"",
+				Value:   false,
+				Sources: cli.EnvVars("TOPF_SKIP_HASH_CHECK"),
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			return etcd.Recover(ctx, t, etcd.RecoverOptions{
+				SnapshotPath:  c.String("snapshot"),
+				SkipHashCheck: c.Bool("skip-hash-check"),
+			})
+		},
+	}
+}
+
+func newEtcdMembersCmd() *cli.Command {
+	return &cli.Command{
+		Name:   "members",
+		Usage:  "list the etcd members and the topf hosts they run on",
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			newOutputFlag(outputTable, true),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			members, err := etcd.Members(ctx, t)
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(c, output{
+				data: members,
+				table: func(w io.Writer, _ bool) error {
+					renderMembersTable(w, members)
+					return nil
+				},
+			})
+		},
+	}
+}
+
+func renderMembersTable(w io.Writer, members []etcd.Member) {
+	check := func(b bool) string {
+		if b {
+			return "✓"
+		}
+
+		return ""
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	tw.AppendHeader(table.Row{"ID", "Name", "Host", "Peer URLs", "Client URLs", "Learner", "Leader"})
+
+	for _, m := range members {
+		host := m.Host
+		if host == "" {
+			host = "-"
+		}
+
+		tw.AppendRow(table.Row{m.ID, m.Name, host, strings.Join(m.PeerURLs, "\n"), strings.Join(m.ClientURLs, "\n"), check(m.Learner), check(m.Leader)})
+	}
+
+	tw.Render()
+}
+
+func newEtcdRemoveMemberCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "remove-member",
+		Usage:     "remove a stale etcd member, e.g. of a replaced control-plane node",
+		ArgsUsage: "<host|member name|member id>",
+		Description: `Removes an etcd member through another reachable control-plane node. ` +
+			`The member is identified by the topf host of its node, its member name or its hexadecimal member ID, as listed by "etcd members". ` +
+			`Use it for members whose node is gone; a node that still runs should leave the cluster with "etcd leave" instead.`,
+		Before: exactlyOneArg,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			return etcd.RemoveMember(ctx, MustGetRuntime(ctx), c.Args().First())
+		},
+	}
+}
+
+func newEtcdLeaveCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "leave",
+		Usage:       "make the etcd member of a control-plane node leave the cluster",
+		ArgsUsage:   "<host>",
+		Description: `Makes the etcd member of a running control-plane node leave the etcd cluster gracefully. etcd stays stopped on the node until it is reset.`,
+		Before:      exactlyOneArg,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			return etcd.Leave(ctx, MustGetRuntime(ctx), c.Args().First())
+		},
+	}
+}
+
+func newEtcdDefragCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "defrag",
+		Usage: "defragment the etcd members of the selected control-plane nodes",
+		Description: `Defragments etcd to reclaim the space freed by compaction. A member blocks reads and writes while it is defragmented, ` +
+			`so by default one member is defragmented at a time, waiting for it to report a healthy status before the next one.`,
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "rolling",
+				Usage:   "defragment one member at a time; if false, all members are defragmented at once",
+				Value:   true,
+				Sources: cli.EnvVars("TOPF_ROLLING"),
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			return etcd.Defrag(ctx, MustGetRuntime(ctx), etcd.DefragOptions{Rolling: c.Bool("rolling")})
+		},
+	}
+}
+
+// exactlyOneArg is a Before hook for commands taking a single positional
+// argument
+func exactlyOneArg(ctx context.Context, c *cli.Command) (context.Context, error) {
+	if c.Args().Len() != 1 {
+		return ctx, fmt.Errorf("expected exactly one argument %s, got %d", c.ArgsUsage, c.Args().Len())
+	}
+
+	return ctx, nil
+}
+
+func newEtcdBackupFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "etcd-backup",
+			Usage:   "take an etcd snapshot before the first control-plane node is touched, and abort if it fails",
+			Value:   false,
+			Sources: cli.EnvVars("TOPF_ETCD_BACKUP"),
+		},
+		&cli.StringFlag{
+			Name:    "etcd-backup-dir",
+			Usage:   "directory to write the --etcd-backup snapshot to",
+			Value:   ".",
+			Sources: cli.EnvVars("TOPF_ETCD_BACKUP_DIR"),
+		},
+	}
+}
+
+const allowQuorumLossFlag = "i-know-quorum-will-be-lost"
+
+func newAllowQuorumLossFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:    allowQuorumLossFlag,
+		Usage:   "proceed with control-plane operations even if etcd loses quorum, e.g. when tearing down the whole cluster",
+		Value:   false,
+		Sources: cli.EnvVars("TOPF_I_KNOW_QUORUM_WILL_BE_LOST"),
+	}
+}
+
+// etcdBackupDir returns the directory of the --etcd-backup snapshot, or an
+// empty string when no backup is requested.
+func etcdBackupDir(c *cli.Command) string {
+	if !c.Bool("etcd-backup") {
+		return ""
+	}
+
+	return c.String("etcd-backup-dir")
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/postfinance/topf/internal/healthgate"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+func newSkipHealthGatesFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:    "skip-health-gates",
+		Usage:   "don't wait for the health gates configured in topf.yaml after each node",
+		Value:   false,
+		Sources: cli.EnvVars("TOPF_SKIP_HEALTH_GATES"),
+	}
+}
+
+// newHealthGates returns the health gates configured in topf.yaml, or nil
+// when they are skipped with --skip-health-gates.
+func newHealthGates(t topf.Topf, c *cli.Command) *healthgate.Runner {
+	if c.Bool("skip-health-gates") {
+		return nil
+	}
+
+	return healthgate.New(t)
+}
@@ -0,0 +1,31 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/postfinance/topf/internal/hooks"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+func newSkipHooksFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:    "skip-hooks",
+		Usage:   "don't run the hooks configured in topf.yaml",
+		Value:   false,
+		Sources: cli.EnvVars("TOPF_SKIP_HOOKS"),
+	}
+}
+
+// newHooks returns the hooks configured in topf.yaml for the command, or nil
+// when they are skipped with --skip-hooks or nothing is changed (dry-run).
+func newHooks(t topf.Topf, c *cli.Command, dryRun bool) *hooks.Runner {
+	if dryRun || c.Bool("skip-hooks") {
+		return nil
+	}
+
+	cfg := t.Config()
+
+	return hooks.New(cfg.Hooks, cfg.ClusterName, c.Name)
+}
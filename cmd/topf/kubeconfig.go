@@ -24,6 +24,7 @@ func newKubeconfigCmd() *cli.Command {
 				Usage: "validity duration of the client certificate",
 				Value: 12 * time.Hour,
 			},
+			newOutputFlag(outputYAML, false),
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			t := MustGetRuntime(ctx)
@@ -38,9 +39,7 @@ func newKubeconfigCmd() *cli.Command {
 				return fmt.Errorf("failed to marshal kubeconfig: %w", err)
 			}
 
-			fmt.Println(string(kubeconfigBytes))
-
-			return nil
+			return writeOutput(cmd, output{data: rawYAML(kubeconfigBytes)})
 		},
 	}
 }
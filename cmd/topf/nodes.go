@@ -4,53 +4,186 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/postfinance/topf/internal/topf"
 	"github.com/urfave/cli/v3"
-	"go.yaml.in/yaml/v4"
 )
 
+// nodeColumn is a column of the nodes table. wide columns are only shown by
+// the wide format, which doesn't truncate values either.
+type nodeColumn struct {
+	name     string
+	header   string
+	wide     bool
+	truncate bool
+	value    func(node *topf.Node) string
+}
+
+func nodeColumns() []nodeColumn {
+	check := func(b bool) string {
+		if b {
+			return "✓"
+		}
+
+		return "✗"
+	}
+
+	return []nodeColumn{
+		{name: "host", header: "Host", value: func(node *topf.Node) string { return node.Node.Host }},
+		{name: "ip", header: "IP", value: func(node *topf.Node) string {
+			if node.Node.IP == nil {
+				return ""
+			}
+
+			return node.Node.IP.String()
+		}},
+		{name: "role", header: "Role", value: func(node *topf.Node) string { return string(node.Node.Role) }},
+		{name: "stage", header: "Stage", value: func(node *topf.Node) string { return node.MachineStatus.Stage.String() }},
+		{name: "ready", header: "Ready", value: func(node *topf.Node) string { return check(node.MachineStatus.Status.Ready) }},
+		{name: "conditions", header: "Unmet Conditions", value: func(node *topf.Node) string {
+			conditions := make([]string, len(node.MachineStatus.Status.UnmetConditions))
+			for i, cond := range node.MachineStatus.Status.UnmetConditions {
+				conditions[i] = cond.Name + ": " + cond.Reason
+			}
+
+			return strings.Join(conditions, "\n")
+		}},
+		{name: "schematic", header: "Schematic", truncate: true, value: func(node *topf.Node) string { return node.RunningSchematic() }},
+		{name: "desired-schematic", header: "Desired Schematic", wide: true, truncate: true, value: func(node *topf.Node) string { return node.DesiredSchematic() }},
+		{name: "talos", header: "Talos", value: func(node *topf.Node) string { return node.RunningVersion() }},
+		{name: "desired-talos", header: "Desired Talos", wide: true, value: func(node *topf.Node) string { return node.DesiredVersion() }},
+		{name: "local-diff", header: "Local Diff", value: func(node *topf.Node) string {
+			if node.Error != nil {
+				return ""
+			}
+
+			changed, err := node.LocalConfigDiff()
+			if err != nil {
+				return "?"
+			}
+
+			if changed {
+				return "changed"
+			}
+
+			return "none"
+		}},
+		{name: "error", header: "Error", value: func(node *topf.Node) string {
+			if node.Error == nil {
+				return ""
+			}
+
+			return node.Error.Error()
+		}},
+	}
+}
+
+// selectNodeColumns returns the columns named in the comma-separated list, in
+// its order. Without a list, the columns of the table or wide format are
+// returned.
+func selectNodeColumns(list string, wide bool) ([]nodeColumn, error) {
+	columns := nodeColumns()
+
+	if list == "" {
+		if wide {
+			return columns, nil
+		}
+
+		return slices.DeleteFunc(columns, func(c nodeColumn) bool { return c.wide }), nil
+	}
+
+	var selected []nodeColumn
+
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+
+		idx := slices.IndexFunc(columns, func(c nodeColumn) bool { return c.name == name })
+		if idx < 0 {
+			names := make([]string, len(columns))
+			for i, c := range columns {
+				names[i] = c.name
+			}
+
+			return nil, fmt.Errorf("unknown column: %s (supported: %s)", name, strings.Join(names, ", "))
+		}
+
+		selected = append(selected, columns[idx])
+	}
+
+	return selected, nil
+}
+
 func newNodesCmd() *cli.Command {
 	return &cli.Command{
 		Name:   "nodes",
 		Usage:  "list all nodes and their current state",
 		Before: noPositionalArgs,
 		Flags: []cli.Flag{
+			newOutputFlag(outputTable, true),
 			&cli.StringFlag{
-				Name:    "output",
-				Aliases: []string{"o"},
-				Usage:   "output format (table, yaml)",
-				Value:   "table",
+				Name:  "columns",
+				Usage: "comma-separated list of the table columns to show, e.g. host,talos,desired-talos,config",
+			},
+			&cli.BoolFlag{
+				Name:    "watch",
+				Aliases: []string{"w"},
+				Usage:   "keep watching the nodes and redraw the table on every change, until interrupted",
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
+			// validate the columns before reaching out to the nodes
+			if _, err := selectNodeColumns(cmd.String("columns"), false); err != nil {
+				return err
+			}
+
+			outputFormat := cmd.String("output")
+			if cmd.Bool("watch") && outputFormat != outputTable && outputFormat != outputWide {
+				return fmt.Errorf("--watch only supports the %s and %s output formats", outputTable, outputWide)
+			}
+
 			nodes, err := t.FilteredNodes(ctx)
 			if err != nil {
 				return err
 			}
 
-			outputFormat := cmd.String("output")
+			out := output{
+				data: nodes,
+				table: func(w io.Writer, wide bool) error {
+					columns, err := selectNodeColumns(cmd.String("columns"), wide)
+					if err != nil {
+						return err
+					}
 
-			switch outputFormat {
-			case "table":
-				return renderNodesTable(nodes)
-			case "yaml":
-				return renderNodesYAML(nodes)
-			default:
-				return fmt.Errorf("unsupported output format: %s (supported: table, yaml)", outputFormat)
+					renderNodesTable(w, nodes, columns, wide)
+
+					return nil
+				},
+			}
+
+			if cmd.Bool("watch") {
+				return watchNodes(ctx, nodes, func(w io.Writer) error { return out.write(w, outputFormat) })
 			}
+
+			return writeOutput(cmd, out)
 		},
 	}
 }
 
-func renderNodesTable(nodes []*topf.Node) error {
+func renderNodesTable(w io.Writer, nodes []*topf.Node, columns []nodeColumn, wide bool) {
 	elipsis := func(s string) string {
 		if len(s) > 8 {
 			return s[:8] + "..."
@@ -60,62 +193,84 @@ func renderNodesTable(nodes []*topf.Node) error {
 	}
 
 	tw := table.NewWriter()
-	tw.SetOutputMirror(os.Stdout)
-	tw.AppendHeader(table.Row{"Host", "IP", "Role", "Stage", "Ready", "Unmet Conditions", "Schematic", "Talos", "Error"})
-	tw.SetColumnConfigs([]table.ColumnConfig{
-		{Name: "Unmet Conditions", WidthMax: 30},
-		{Name: "Error", WidthMax: 30},
-	})
-
-	for _, node := range nodes {
-		host := node.Node.Host
-		ip := node.Node.IP
-		role := string(node.Node.Role)
-
-		var ready string
+	tw.SetOutputMirror(w)
 
-		unmetConditions := ""
+	header := make(table.Row, len(columns))
+	for i, c := range columns {
+		header[i] = c.header
+	}
 
-		schematic := elipsis(node.RunningSchematic())
+	tw.AppendHeader(header)
 
-		talosVersion := node.RunningVersion()
-		errorMsg := ""
+	if !wide {
+		tw.SetColumnConfigs([]table.ColumnConfig{
+			{Name: "Unmet Conditions", WidthMax: 30},
+			{Name: "Error", WidthMax: 30},
+		})
+	}
 
-		stage := node.MachineStatus.Stage.String()
-		if node.MachineStatus.Status.Ready {
-			ready = "✓"
-		} else {
-			ready = "✗"
-		}
+	for _, node := range nodes {
+		row := make(table.Row, len(columns))
 
-		if len(node.MachineStatus.Status.UnmetConditions) > 0 {
-			conditions := make([]string, len(node.MachineStatus.Status.UnmetConditions))
-			for i, cond := range node.MachineStatus.Status.UnmetConditions {
-				conditions[i] = cond.Name + ": " + cond.Reason
+		for i, c := range columns {
+			value := c.value(node)
+			if c.truncate && !wide {
+				value = elipsis(value)
 			}
 
-			unmetConditions = strings.Join(conditions, "\n")
+			row[i] = value
 		}
 
-		if node.Error != nil {
-			errorMsg = node.Error.Error()
-		}
-
-		tw.AppendRow(table.Row{host, ip, role, stage, ready, unmetConditions, schematic, talosVersion, errorMsg})
+		tw.AppendRow(row)
 	}
 
 	tw.Render()
-
-	return nil
 }
 
-func renderNodesYAML(nodes []*topf.Node) error {
-	yamlBytes, err := yaml.Marshal(nodes)
-	if err != nil {
-		return fmt.Errorf("failed to marshal nodes to YAML: %w", err)
+// watchNodes watches the nodes and redraws the table on every change until
+// interrupted. Updates arriving together are drawn at once.
+func watchNodes(ctx context.Context, nodes []*topf.Node, render func(w io.Writer) error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+
+	updates := make(chan topf.Update)
+
+	var wg sync.WaitGroup
+
+	// the watches stop before returning
+	defer wg.Wait()
+	defer stop()
+
+	for _, node := range nodes {
+		wg.Go(func() { node.Watch(ctx, updates) })
 	}
 
-	fmt.Println(string(yamlBytes))
+	for {
+		var buf bytes.Buffer
 
-	return nil
+		fmt.Fprintf(&buf, "Watching %d nodes, updated at %s (Ctrl-C to stop)\n\n", len(nodes), time.Now().Format(time.TimeOnly))
+
+		if err := render(&buf); err != nil {
+			return err
+		}
+
+		// move to the top left corner and clear the screen before drawing
+		fmt.Print("\033[H\033[2J" + buf.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case update := <-updates:
+			update.Apply()
+		}
+
+	drain:
+		for {
+			select {
+			case update := <-updates:
+				update.Apply()
+			default:
+				break drain
+			}
+		}
+	}
 }
@@ -0,0 +1,32 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"github.com/postfinance/topf/internal/notify"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+// newNotifier returns a notifier posting the events of the command to the
+// webhooks configured in topf.yaml, or nil if there are none or nothing is
+// changed (dry-run)
+func newNotifier(c *cli.Command, t topf.Topf, dryRun bool) (*notify.Notifier, error) {
+	cfg := t.Config()
+	if dryRun || len(cfg.Notifications) == 0 {
+		return nil, nil //nolint:nilnil // a nil notifier disables notifications
+	}
+
+	// webhook URLs and headers usually carry credentials
+	for _, n := range cfg.Notifications {
+		secrets := []string{n.URL}
+		for _, value := range n.Headers {
+			secrets = append(secrets, value)
+		}
+
+		t.AddSecretsToMask(secrets)
+	}
+
+	return notify.New(cfg.Notifications, cfg.ClusterName, c.Name, t.Logger())
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+// withObservers runs fn like withProgress, with the progress of the nodes
+// also posted to the webhooks configured in topf.yaml and recorded as
+// traces and metrics. fn gets a context carrying the telemetry recorder.
+func withObservers(ctx context.Context, c *cli.Command, t topf.Topf, dryRun bool, fn func(ctx context.Context, tracker *progress.Tracker) error) error {
+	notifier, err := newNotifier(c, t, dryRun)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := newRecorder(ctx, c, t)
+	if err != nil {
+		return err
+	}
+
+	if notifier == nil && recorder == nil {
+		return withProgress(c, t, func(tracker *progress.Tracker) error { return fn(ctx, tracker) })
+	}
+
+	ctx = recorder.Start(ctx)
+	notifier.Start()
+
+	// the progress is tracked even without a dashboard then
+	var tracker *progress.Tracker
+
+	err = withProgress(c, t, func(dashboard *progress.Tracker) error {
+		tracker = dashboard
+		if tracker == nil {
+			tracker = progress.New()
+		}
+
+		tracker.Observe(notifier.Node)
+		tracker.ObservePhases(func(n progress.Node) { recorder.Phase(nodePhase(n)) })
+
+		return fn(ctx, tracker)
+	})
+
+	nodes := tracker.Nodes()
+	phases := make([]telemetry.NodePhase, len(nodes))
+
+	for i, n := range nodes {
+		phases[i] = nodePhase(n)
+	}
+
+	recorder.Finish(err, phases)
+	notifier.Finish(err, nodes)
+
+	return err
+}
+
+func nodePhase(n progress.Node) telemetry.NodePhase {
+	return telemetry.NodePhase{
+		Host:  n.Host,
+		Role:  n.Role,
+		Phase: string(n.Phase),
+		Final: n.Phase.Final(),
+		Error: n.Error,
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli/v3"
+	"go.yaml.in/yaml/v4"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Output formats of the read-only commands. jsonpath and go-template take
+// their expression after "=", e.g. jsonpath={.clusterName}.
+const (
+	outputTable      = "table"
+	outputWide       = "wide"
+	outputYAML       = "yaml"
+	outputJSON       = "json"
+	outputJSONPath   = "jsonpath"
+	outputGoTemplate = "go-template"
+)
+
+// output is what a read-only command renders
+type output struct {
+	// data is rendered by the yaml, json, jsonpath and go-template formats.
+	// All of them use the YAML field names of data.
+	data any
+
+	// table renders the table and wide formats, which are only supported if
+	// set. wide adds columns and doesn't truncate values.
+	table func(w io.Writer, wide bool) error
+}
+
+// rawYAML is data that is already encoded as YAML, and is printed as is by
+// the yaml format
+type rawYAML []byte
+
+// outputFormats returns the formats supported by commands with a table
+// rendering or without
+func outputFormats(table bool) []string {
+	formats := []string{outputYAML, outputJSON, outputJSONPath, outputGoTemplate}
+	if table {
+		formats = append([]string{outputTable, outputWide}, formats...)
+	}
+
+	return formats
+}
+
+// newOutputFlag returns the --output flag of a read-only command
+func newOutputFlag(defaultFormat string, table bool) *cli.StringFlag {
+	formats := outputFormats(table)
+
+	usage := slices.Clone(formats)
+	usage[len(usage)-2] = outputJSONPath + "=<expr>"
+	usage[len(usage)-1] = outputGoTemplate + "=<template>"
+
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "output format (" + strings.Join(usage, ", ") + ")",
+		Value:   defaultFormat,
+		Validator: func(value string) error {
+			_, _, err := parseOutputFormat(value, formats)
+			return err
+		},
+	}
+}
+
+// parseOutputFormat splits an output format into its name and expression
+func parseOutputFormat(value string, formats []string) (string, string, error) {
+	format, expr, hasExpr := strings.Cut(value, "=")
+
+	if !slices.Contains(formats, format) {
+		return "", "", fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(formats, ", "))
+	}
+
+	takesExpr := format == outputJSONPath || format == outputGoTemplate
+
+	switch {
+	case takesExpr && expr == "":
+		return "", "", fmt.Errorf("output format %s requires an expression, e.g. %s=<expr>", format, format)
+	case !takesExpr && hasExpr:
+		return "", "", fmt.Errorf("output format %s takes no expression", format)
+	}
+
+	return format, expr, nil
+}
+
+// writeOutput renders out to stdout in the format of the --output flag
+func writeOutput(c *cli.Command, out output) error {
+	return out.write(os.Stdout, c.String("output"))
+}
+
+func (o output) write(w io.Writer, value string) error {
+	format, expr, err := parseOutputFormat(value, outputFormats(o.table != nil))
+	if err != nil {
+		return err
+	}
+
+	if format == outputTable || format == outputWide {
+		return o.table(w, format == outputWide)
+	}
+
+	if format == outputYAML {
+		yamlBytes, err := o.yaml()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(yamlBytes))
+
+		return err
+	}
+
+	data, err := o.generic()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		jsonBytes, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output to JSON: %w", err)
+		}
+
+		_, err = fmt.Fprintln(w, string(jsonBytes))
+
+		return err
+	case outputJSONPath:
+		// like kubectl, accept expressions without the surrounding braces
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + expr + "}"
+		}
+
+		jp := jsonpath.New("output")
+		if err := jp.Parse(expr); err != nil {
+			return fmt.Errorf("invalid jsonpath expression: %w", err)
+		}
+
+		return jp.Execute(w, data)
+	default:
+		tpl, err := template.New("output").Parse(expr)
+		if err != nil {
+			return fmt.Errorf("invalid go-template: %w", err)
+		}
+
+		return tpl.Execute(w, data)
+	}
+}
+
+func (o output) yaml() ([]byte, error) {
+	if raw, ok := o.data.(rawYAML); ok {
+		return raw, nil
+	}
+
+	yamlBytes, err := yaml.Marshal(o.data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output to YAML: %w", err)
+	}
+
+	return yamlBytes, nil
+}
+
+// generic converts the data to the maps, slices and scalars JSON decodes to,
+// keyed by the YAML field names of the data
+func (o output) generic() (any, error) {
+	yamlBytes, err := o.yaml()
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if err := yaml.Unmarshal(yamlBytes, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output to JSON: %w", err)
+	}
+
+	var generic any
+	if err := json.Unmarshal(jsonBytes, &generic); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+
+	return generic, nil
+}
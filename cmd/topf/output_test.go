@@ -0,0 +1,103 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type outputItem struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+}
+
+func TestOutput(t *testing.T) {
+	out := output{
+		data: []outputItem{{Name: "cp1", Version: "1.13.0"}, {Name: "w1"}},
+		table: func(w io.Writer, wide bool) error {
+			if wide {
+				_, err := io.WriteString(w, "wide\n")
+				return err
+			}
+
+			_, err := io.WriteString(w, "table\n")
+
+			return err
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"table", "table\n"},
+		{"wide", "wide\n"},
+		{"yaml", "- name: cp1\n  version: 1.13.0\n- name: w1\n\n"},
+		{"json", "[\n  {\n    \"name\": \"cp1\",\n    \"version\": \"1.13.0\"\n  },\n  {\n    \"name\": \"w1\"\n  }\n]\n"},
+		{"jsonpath={[*].name}", "cp1 w1"},
+		{"jsonpath=[0].version", "1.13.0"},
+		{`go-template={{range .}}{{.name}};{{end}}`, "cp1;w1;"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := out.write(&buf, tt.format); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.format, err)
+			continue
+		}
+
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.format, tt.want, got)
+		}
+	}
+}
+
+func TestOutputRawYAML(t *testing.T) {
+	out := output{data: rawYAML("b: 1\na: 2\n")}
+
+	var buf bytes.Buffer
+	if err := out.write(&buf, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "b: 1\na: 2\n\n" {
+		t.Errorf("expected raw YAML to be printed as is, got %q", got)
+	}
+
+	buf.Reset()
+
+	if err := out.write(&buf, "jsonpath={.b}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "1" {
+		t.Errorf("expected 1, got %q", got)
+	}
+
+	if err := out.write(&buf, "table"); err == nil {
+		t.Error("expected table to be unsupported without a table rendering")
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	formats := outputFormats(true)
+
+	for _, value := range []string{"table", "wide", "yaml", "json", "jsonpath={.a}", "go-template={{.a}}", "jsonpath=a=b"} {
+		if _, _, err := parseOutputFormat(value, formats); err != nil {
+			t.Errorf("%s: unexpected error: %v", value, err)
+		}
+	}
+
+	for _, value := range []string{"xml", "jsonpath", "jsonpath=", "go-template", "yaml=x", ""} {
+		if _, _, err := parseOutputFormat(value, formats); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+
+	if _, _, err := parseOutputFormat("wide", outputFormats(false)); err == nil {
+		t.Error("expected wide to be unsupported without a table rendering")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/postfinance/topf/internal/report"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+func newReportFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "report",
+		Usage:   "write a machine-readable execution report to the given file; the format (JSON or YAML) is derived from the file extension",
+		Sources: cli.EnvVars("TOPF_REPORT"),
+	}
+}
+
+// newReport starts a report for the command when the --report flag is set.
+// It returns nil otherwise, which commands treat as "don't record".
+func newReport(c *cli.Command, dryRun bool) (*report.Report, error) {
+	path := c.String("report")
+	if path == "" {
+		return nil, nil //nolint:nilnil // a nil report disables reporting
+	}
+
+	if _, err := report.FormatFromPath(path); err != nil {
+		return nil, err
+	}
+
+	return report.New(c.Name, dryRun), nil
+}
+
+// writeReport finishes the report with the error returned by the command,
+// writes it (redacted) to the --report file and returns the command error.
+func writeReport(t topf.Topf, c *cli.Command, rep *report.Report, err error) error {
+	if rep == nil {
+		return err
+	}
+
+	rep.Finish(err)
+
+	path := c.String("report")
+
+	format, ferr := report.FormatFromPath(path)
+	if ferr != nil {
+		return errors.Join(err, ferr)
+	}
+
+	f, ferr := os.Create(path) //nolint:gosec // writing to a user-provided path is by design
+	if ferr != nil {
+		return errors.Join(err, fmt.Errorf("failed to create report file: %w", ferr))
+	}
+	defer f.Close()
+
+	w := t.MaskWriter(f)
+
+	if ferr := rep.Write(w, format); ferr != nil {
+		return errors.Join(err, fmt.Errorf("failed to write report: %w", ferr))
+	}
+
+	if ferr := w.Close(); ferr != nil {
+		return errors.Join(err, fmt.Errorf("failed to write report: %w", ferr))
+	}
+
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"github.com/postfinance/topf/internal/cmd/reset"
+	"github.com/postfinance/topf/internal/progress"
 	"github.com/urfave/cli/v3"
 )
 
@@ -14,7 +15,7 @@ func newResetCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "reset",
 		Usage: "reset talos node(s) to maintenance mode",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "full",
 				Value:   true,
@@ -39,20 +40,38 @@ func newResetCmd() *cli.Command {
 				Usage:   "wait for all reset nodes to reach maintenance mode",
 				Sources: cli.EnvVars("TOPF_WAIT_FOR_MAINTENANCE"),
 			},
-		},
+			newAllowQuorumLossFlag(),
+			newSkipHooksFlag(),
+			newReportFlag(),
+			newUIFlag(),
+		}, newTelemetryFlags()...),
 		Description: `This command resets a Talos node to its initial state, wiping the state and ephemeral system partitions and rebooting the node.`,
 		Before:      noPositionalArgs,
 		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
+			rep, err := newReport(c, false)
+			if err != nil {
+				return err
+			}
+
 			opts := reset.Options{
 				Full:               c.Bool("full"),
 				Graceful:           c.Bool("graceful"),
 				Shutdown:           c.Bool("shutdown"),
 				WaitForMaintenance: c.Bool("wait-for-maintenance"),
+				AllowQuorumLoss:    c.Bool(allowQuorumLossFlag),
+				Hooks:              newHooks(t, c, false),
+				Report:             rep,
 			}
 
-			return reset.Execute(ctx, t, opts)
+			err = withObservers(ctx, c, t, false, func(ctx context.Context, tracker *progress.Tracker) error {
+				opts.Progress = tracker
+
+				return reset.Execute(ctx, t, opts)
+			})
+
+			return writeReport(t, c, rep, err)
 		},
 	}
 }
@@ -0,0 +1,202 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/postfinance/topf/internal/control"
+	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+func newRolloutFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "waves",
+			Usage:   "roll out worker nodes in waves of the given comma-separated sizes, each a node count, a percentage of the worker nodes or \"rest\" (e.g. \"1,10%,25%,rest\"); overrides rollout.waves in topf.yaml",
+			Sources: cli.EnvVars("TOPF_WAVES"),
+			Validator: func(value string) error {
+				_, err := nodepool.ParseWaves(value)
+				return err
+			},
+		},
+		&cli.DurationFlag{
+			Name:    "wave-pause",
+			Usage:   "time to wait between two waves; overrides rollout.pause in topf.yaml",
+			Sources: cli.EnvVars("TOPF_WAVE_PAUSE"),
+		},
+		&cli.IntFlag{
+			Name:    "max-failures-per-wave",
+			Usage:   "number of failed nodes tolerated within a wave before the rollout halts; overrides rollout.maxFailures in topf.yaml",
+			Sources: cli.EnvVars("TOPF_MAX_FAILURES_PER_WAVE"),
+		},
+		&cli.StringFlag{
+			Name:    "topology-key",
+			Usage:   "node data key holding the failure domain of a node, e.g. \"rack\" or \"location.zone\"; worker nodes are rolled out one domain at a time unless limited otherwise; overrides rollout.topology.key in topf.yaml",
+			Sources: cli.EnvVars("TOPF_TOPOLOGY_KEY"),
+		},
+		&cli.IntFlag{
+			Name:    "topology-max-domains",
+			Usage:   "number of failure domains with nodes in flight at once (0: 1, or unlimited with --topology-max-per-domain); overrides rollout.topology.maxDomains in topf.yaml",
+			Sources: cli.EnvVars("TOPF_TOPOLOGY_MAX_DOMAINS"),
+		},
+		&cli.IntFlag{
+			Name:    "topology-max-per-domain",
+			Usage:   "number of nodes of the same failure domain in flight at once (0: unlimited); overrides rollout.topology.maxPerDomain in topf.yaml",
+			Sources: cli.EnvVars("TOPF_TOPOLOGY_MAX_PER_DOMAIN"),
+		},
+		&cli.StringFlag{
+			Name:    "pause-after",
+			Value:   string(control.PauseAfterNone),
+			Usage:   "pause the rollout for an operator command (continue, skip, abort, continue-all) before every \"node\" or \"wave\" but the first, or \"none\"; commands are read from the terminal, or from --control-file or --control-socket if set",
+			Sources: cli.EnvVars("TOPF_PAUSE_AFTER"),
+			Validator: func(value string) error {
+				_, err := control.ParsePauseAfter(value)
+				return err
+			},
+		},
+		&cli.StringFlag{
+			Name:    "control-file",
+			Usage:   "file polled for rollout commands (pause, continue, skip, abort, continue-all), one per line; the file is removed once read",
+			Sources: cli.EnvVars("TOPF_CONTROL_FILE"),
+		},
+		&cli.StringFlag{
+			Name:    "control-socket",
+			Usage:   "Unix socket accepting rollout commands (pause, continue, skip, abort, continue-all, status), one per line",
+			Sources: cli.EnvVars("TOPF_CONTROL_SOCKET"),
+		},
+	}
+}
+
+// newWaves returns the rollout waves and topology configured in topf.yaml,
+// overridden by the flags that are set.
+func newWaves(t topf.Topf, c *cli.Command) (nodepool.Waves, error) {
+	var waves nodepool.Waves
+
+	if rollout := t.Config().Rollout; rollout != nil {
+		sizes, err := nodepool.ParseWaveList(rollout.Waves)
+		if err != nil {
+			return nodepool.Waves{}, fmt.Errorf("invalid rollout in topf.yaml: %w", err)
+		}
+
+		waves = nodepool.Waves{Sizes: sizes, Pause: rollout.Pause, MaxFailures: rollout.MaxFailures}
+
+		if topology := rollout.Topology; topology != nil {
+			waves.Topology = nodepool.Topology{Key: topology.Key, MaxDomains: topology.MaxDomains, MaxPerDomain: topology.MaxPerDomain}
+		}
+	}
+
+	if c.IsSet("waves") {
+		sizes, err := nodepool.ParseWaves(c.String("waves"))
+		if err != nil {
+			return nodepool.Waves{}, err
+		}
+
+		waves.Sizes = sizes
+	}
+
+	if c.IsSet("wave-pause") {
+		waves.Pause = c.Duration("wave-pause")
+	}
+
+	if c.IsSet("max-failures-per-wave") {
+		if c.Int("max-failures-per-wave") < 0 {
+			return nodepool.Waves{}, fmt.Errorf("invalid max-failures-per-wave %d: can't be negative", c.Int("max-failures-per-wave"))
+		}
+
+		waves.MaxFailures = c.Int("max-failures-per-wave")
+	}
+
+	if c.IsSet("topology-key") {
+		waves.Topology.Key = c.String("topology-key")
+	}
+
+	for _, limit := range []struct {
+		flag  string
+		value *int
+	}{
+		{flag: "topology-max-domains", value: &waves.Topology.MaxDomains},
+		{flag: "topology-max-per-domain", value: &waves.Topology.MaxPerDomain},
+	} {
+		if !c.IsSet(limit.flag) {
+			continue
+		}
+
+		if c.Int(limit.flag) < 0 {
+			return nodepool.Waves{}, fmt.Errorf("invalid %s %d: can't be negative", limit.flag, c.Int(limit.flag))
+		}
+
+		if waves.Topology.Key == "" {
+			return nodepool.Waves{}, fmt.Errorf("--%s requires --topology-key or rollout.topology in topf.yaml", limit.flag)
+		}
+
+		*limit.value = c.Int(limit.flag)
+	}
+
+	return waves, nil
+}
+
+// controlFilePollInterval is how often the control file is checked for commands
+const controlFilePollInterval = time.Second
+
+// newController returns the rollout controller configured by the flags, or
+// nil if the rollout is never paused. The returned function stops receiving
+// commands.
+func newController(ctx context.Context, t topf.Topf, c *cli.Command) (*control.Controller, func(), error) {
+	pauseAfter, err := control.ParsePauseAfter(c.String("pause-after"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, socket := c.String("control-file"), c.String("control-socket")
+
+	// dry runs don't change nodes, there is nothing to pause for
+	if c.Bool("dry-run") || (pauseAfter == control.PauseAfterNone && file == "" && socket == "") {
+		return nil, func() {}, nil
+	}
+
+	controller := control.New(pauseAfter, t.Logger().With("command", c.Name))
+
+	if file == "" && socket == "" {
+		if c.String("ui") == uiTUI {
+			return nil, nil, errors.New("--pause-after with --ui=tui requires --control-file or --control-socket, as commands can't be entered in the dashboard")
+		}
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return nil, nil, errors.New("--pause-after requires a terminal to enter commands, or --control-file or --control-socket")
+		}
+
+		controller.Prompt(os.Stdin, os.Stdout)
+
+		return controller, func() {}, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	if file != "" {
+		controller.WatchFile(ctx, file, controlFilePollInterval)
+	}
+
+	if socket != "" {
+		closeSocket, err := controller.Listen(socket)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+
+		return controller, func() {
+			closeSocket()
+			cancel()
+		}, nil
+	}
+
+	return controller, cancel, nil
+}
@@ -24,6 +24,8 @@ const (
 var version = "dev"
 
 func main() {
+	var runtime topf.Topf
+
 	app := &cli.Command{
 		Name:        "topf",
 		Usage:       "Talos Orchestrator by PostFinance",
@@ -48,6 +50,24 @@ func main() {
 				Usage:   "set the logging level (debug, info, warn, error)",
 				Sources: cli.EnvVars("LOG_LEVEL"),
 			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				Value:   "text",
+				Usage:   "set the log output format (text, json)",
+				Sources: cli.EnvVars("TOPF_LOG_FORMAT"),
+			},
+			&cli.StringFlag{
+				Name:    "log-file",
+				Value:   "",
+				Usage:   "additionally append logs to the given file, with its own level (see --log-file-level)",
+				Sources: cli.EnvVars("TOPF_LOG_FILE"),
+			},
+			&cli.StringFlag{
+				Name:    "log-file-level",
+				Value:   "debug",
+				Usage:   "set the logging level of the log file (debug, info, warn, error)",
+				Sources: cli.EnvVars("TOPF_LOG_FILE_LEVEL"),
+			},
 			&cli.BoolFlag{
 				Name:    "redact",
 				Value:   true,
@@ -72,6 +92,9 @@ func main() {
 				ConfigPath:       c.String("topfconfig"),
 				NodesRegexFilter: c.String("nodes-filter"),
 				LogLevel:         c.String("log-level"),
+				LogFormat:        c.String("log-format"),
+				LogFile:          c.String("log-file"),
+				LogFileLevel:     c.String("log-file-level"),
 				Redact:           c.Bool("redact"),
 				Confirm:          c.Bool("confirm"),
 				SubmitToFactory:  c.Bool("submit-to-factory"),
@@ -81,14 +104,23 @@ func main() {
 				return ctx, err
 			}
 
+			// route errors logged by main through the configured (redacting) sinks
+			runtime = topf
+			setDefaultLogger(topf.Logger())
+
 			return context.WithValue(ctx, topfRuntimeCtxKey, topf), nil
 		},
 		Commands: []*cli.Command{
 			newApplyCmd(),
 			newUpgradeCmd(),
+			newUpgradeK8sCmd(),
 			newResetCmd(),
+			newDecommissionCmd(),
+			newEtcdCmd(),
 			newClusterInfoCmd(),
 			newNodesCmd(),
+			newStatusCmd(),
+			newDriftCmd(),
 			newSchematicIDsCmd(),
 			newRenderCmd(),
 			newSecretsCmd(),
@@ -97,8 +129,18 @@ func main() {
 		},
 	}
 
-	if err := app.Run(context.Background(), os.Args); err != nil {
+	err := app.Run(context.Background(), os.Args)
+	if err != nil {
 		slog.Error("error", "error", err)
+	}
+
+	if runtime != nil {
+		if cerr := runtime.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "failed to close log sinks: %v\n", cerr)
+		}
+	}
+
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -113,6 +155,15 @@ func MustGetRuntime(ctx context.Context) topf.Topf {
 	return t
 }
 
+// setDefaultLogger routes slog and the log package through logger. Libraries
+// log through the log package, such as go-retry which logs every retried error
+// when the log file is at debug level, so their output is kept at debug level
+// instead of reaching the terminal at info level.
+func setDefaultLogger(logger *slog.Logger) {
+	slog.SetDefault(logger)
+	slog.SetLogLoggerLevel(slog.LevelDebug)
+}
+
 // noPositionalArgs is a Before hook that rejects any positional arguments.
 // Use this for commands that only accept flags.
 func noPositionalArgs(ctx context.Context, c *cli.Command) (context.Context, error) {
@@ -0,0 +1,41 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/postfinance/topf/internal/logging"
+)
+
+func TestSetDefaultLogger(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		slog.SetLogLoggerLevel(slog.LevelInfo)
+	})
+
+	// the terminal at the default info level and a log file at debug level,
+	// as with --log-file
+	var terminal, file bytes.Buffer
+
+	setDefaultLogger(slog.New(logging.NewFanoutHandler(
+		logging.NewHandler(logging.FormatText, &terminal, slog.LevelInfo),
+		logging.NewHandler(logging.FormatText, &file, slog.LevelDebug),
+	)))
+
+	log.Printf("retrying error: connection refused")
+
+	if terminal.Len() > 0 {
+		t.Errorf("log output reached the terminal: %s", terminal.String())
+	}
+
+	if !strings.Contains(file.String(), "level=DEBUG") || !strings.Contains(file.String(), "retrying error: connection refused") {
+		t.Errorf("expected the log output in the log file at debug level, got %q", file.String())
+	}
+}
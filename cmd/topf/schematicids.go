@@ -5,22 +5,62 @@ package main
 
 import (
 	"context"
-	"os"
+	"fmt"
+	"io"
+	"strings"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/postfinance/topf/internal/cmd/schematicids"
 	"github.com/urfave/cli/v3"
 )
 
 func newSchematicIDsCmd() *cli.Command {
 	return &cli.Command{
-		Name:        "schematic-ids",
-		Usage:       "output resolved schematic IDs for all nodes",
-		Description: `Resolves all schematic IDs (including @-prefixed file references) and prints them to stdout, one per line.`,
-		Before:      noPositionalArgs,
-		Action: func(ctx context.Context, _ *cli.Command) error {
+		Name:  "schematic-ids",
+		Usage: "output resolved schematic IDs for all nodes",
+		Description: `Resolves all schematic IDs (including @-prefixed file references) and prints them to stdout, one per line. ` +
+			`The wide format adds the nodes installing each schematic.`,
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			newOutputFlag(outputTable, true),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
-			return schematicids.Execute(ctx, t, os.Stdout)
+			schematics, err := schematicids.Resolve(ctx, t)
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(c, output{
+				data: schematics,
+				table: func(w io.Writer, wide bool) error {
+					renderSchematicIDs(w, schematics, wide)
+					return nil
+				},
+			})
 		},
 	}
 }
+
+// renderSchematicIDs prints one ID per line, so that the default output can
+// be consumed by scripts as is
+func renderSchematicIDs(w io.Writer, schematics []schematicids.Schematic, wide bool) {
+	if !wide {
+		for _, s := range schematics {
+			fmt.Fprintln(w, s.ID)
+		}
+
+		return
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	tw.AppendHeader(table.Row{"Schematic ID", "Nodes"})
+
+	for _, s := range schematics {
+		tw.AppendRow(table.Row{s.ID, strings.Join(s.Nodes, "\n")})
+	}
+
+	tw.Render()
+}
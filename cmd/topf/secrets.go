@@ -8,7 +8,6 @@ import (
 	"fmt"
 
 	"github.com/urfave/cli/v3"
-	"go.yaml.in/yaml/v4"
 )
 
 func newSecretsCmd() *cli.Command {
@@ -16,7 +15,10 @@ func newSecretsCmd() *cli.Command {
 		Name:   "secrets",
 		Usage:  "get or generate secrets.yaml for cluster",
 		Before: noPositionalArgs,
-		Action: func(ctx context.Context, _ *cli.Command) error {
+		Flags: []cli.Flag{
+			newOutputFlag(outputYAML, false),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
 			secretsBundle, err := t.Secrets()
@@ -24,14 +26,7 @@ func newSecretsCmd() *cli.Command {
 				return fmt.Errorf("failed to load secrets bundle: %w", err)
 			}
 
-			yamlBytes, err := yaml.Marshal(secretsBundle)
-			if err != nil {
-				return err
-			}
-
-			fmt.Println(string(yamlBytes))
-
-			return nil
+			return writeOutput(c, output{data: secretsBundle})
 		},
 	}
 }
@@ -0,0 +1,143 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/postfinance/topf/internal/cmd/status"
+	"github.com/postfinance/topf/internal/drift"
+	"github.com/urfave/cli/v3"
+)
+
+// exitDegraded is the exit code of status when the cluster is degraded
+const exitDegraded = 2
+
+func newStatusCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "show a cluster-wide state snapshot",
+		Description: `Aggregates the etcd members and leader, the Kubernetes node readiness and versions, the Talos version, schematic and config drift of each node, ` +
+			`the expiry of the secrets bundle CAs and the bootstrap state. Exits with code 2 if the cluster is degraded.`,
+		Before: noPositionalArgs,
+		Flags: []cli.Flag{
+			newOutputFlag(outputTable, true),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			st, err := status.Gather(ctx, t)
+			if err != nil {
+				return err
+			}
+
+			err = writeOutput(c, output{
+				data: st,
+				table: func(w io.Writer, wide bool) error {
+					renderStatusTable(w, st, wide)
+					return nil
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			if st.Degraded() {
+				return cli.Exit(fmt.Sprintf("cluster is degraded: %d problem(s) found", len(st.Problems)), exitDegraded)
+			}
+
+			return nil
+		},
+	}
+}
+
+func renderStatusTable(w io.Writer, st *status.Status, wide bool) {
+	check := func(b bool) string {
+		if b {
+			return "✓"
+		}
+
+		return "✗"
+	}
+
+	versionDrift := func(d drift.Drift) string {
+		if d.Drifted() {
+			return d.Running + " → " + d.Desired
+		}
+
+		return d.Running
+	}
+
+	elipsis := func(s string) string {
+		if len(s) > 8 && !wide {
+			return s[:8] + "..."
+		}
+
+		return s
+	}
+
+	fmt.Fprintf(w, "Cluster: %s\nBootstrapped: %s\nEtcd leader: %s\n\n", st.Cluster, check(st.Bootstrapped), st.Etcd.Leader)
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(w)
+	tw.AppendHeader(table.Row{"Host", "Role", "Stage", "Ready", "Talos", "Schematic", "Config", "K8s Node", "K8s Ready", "Kubelet", "Error"})
+
+	if !wide {
+		tw.SetColumnConfigs([]table.ColumnConfig{{Name: "Error", WidthMax: 30}})
+	}
+
+	for _, n := range st.Nodes {
+		schematic := elipsis(n.Schematic.Running)
+		if n.Schematic.Drifted() {
+			schematic += " → " + elipsis(n.Schematic.Desired)
+		}
+
+		var configState string
+
+		switch {
+		case n.Config == nil:
+		case n.Config.Error != "":
+			configState = "?"
+		case n.Config.Drifted && len(n.Config.Documents) == 0:
+			configState = n.Config.Changes()
+		case n.Config.Drifted:
+			configState = fmt.Sprintf("%d changed (%s)", len(n.Config.Documents), n.Config.Mode)
+		default:
+			configState = "in sync"
+		}
+
+		var k8sName, k8sReady, kubelet string
+
+		if kn := n.Kubernetes; kn != nil {
+			k8sName, k8sReady, kubelet = kn.Name, check(kn.Ready), versionDrift(kn.Version)
+		}
+
+		tw.AppendRow(table.Row{n.Host, n.Role, n.Stage, check(n.Ready), versionDrift(n.Talos), schematic, configState, k8sName, k8sReady, kubelet, n.Error})
+	}
+
+	tw.Render()
+
+	if len(st.Certificates) > 0 {
+		fmt.Fprintln(w)
+
+		cw := table.NewWriter()
+		cw.SetOutputMirror(w)
+		cw.AppendHeader(table.Row{"Certificate", "Not After", "Expires In"})
+
+		for _, cert := range st.Certificates {
+			cw.AppendRow(table.Row{cert.Name, cert.NotAfter.Format(time.RFC3339), fmt.Sprintf("%dd", int(time.Until(cert.NotAfter).Hours()/24))})
+		}
+
+		cw.Render()
+	}
+
+	if len(st.Problems) > 0 {
+		fmt.Fprintf(w, "\nProblems:\n  - %s\n", strings.Join(st.Problems, "\n  - "))
+	}
+}
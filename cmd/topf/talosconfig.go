@@ -5,7 +5,6 @@ package main
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/postfinance/topf/internal/cmd/talosconfig"
 	"github.com/urfave/cli/v3"
@@ -16,7 +15,10 @@ func newTalosconfigCmd() *cli.Command {
 		Name:   "talosconfig",
 		Usage:  "generate and save talosconfig from secrets bundle",
 		Before: noPositionalArgs,
-		Action: func(ctx context.Context, _ *cli.Command) error {
+		Flags: []cli.Flag{
+			newOutputFlag(outputYAML, false),
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
 
 			talosCfg, err := talosconfig.Generate(t)
@@ -24,9 +26,7 @@ func newTalosconfigCmd() *cli.Command {
 				return err
 			}
 
-			fmt.Println(string(talosCfg))
-
-			return nil
+			return writeOutput(c, output{data: rawYAML(talosCfg)})
 		},
 	}
 }
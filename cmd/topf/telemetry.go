@@ -0,0 +1,48 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+func newTelemetryFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "otlp-endpoint",
+			Usage:   "export traces with a span per command, node and phase to the given OTLP/HTTP collector URL (e.g. \"http://localhost:4318\"); further settings are taken from the OTEL_EXPORTER_OTLP_* environment variables",
+			Sources: cli.EnvVars("TOPF_OTLP_ENDPOINT"),
+		},
+		&cli.StringFlag{
+			Name:    "metrics-file",
+			Usage:   "write Prometheus metrics of the run (durations, node outcomes, retries) to the given file, e.g. for the textfile collector of the node exporter",
+			Sources: cli.EnvVars("TOPF_METRICS_FILE"),
+		},
+		&cli.StringFlag{
+			Name:    "metrics-push-url",
+			Usage:   "push Prometheus metrics of the run to the given Pushgateway grouping URL (e.g. \"http://pushgateway:9091/metrics/job/topf\")",
+			Sources: cli.EnvVars("TOPF_METRICS_PUSH_URL"),
+		},
+	}
+}
+
+// newRecorder returns a telemetry recorder for the command, or nil if
+// neither tracing nor metrics are enabled
+func newRecorder(ctx context.Context, c *cli.Command, t topf.Topf) (*telemetry.Recorder, error) {
+	return telemetry.New(ctx, telemetry.Options{
+		Command:        c.Name,
+		Cluster:        t.Config().ClusterName,
+		Version:        version,
+		OTLPEndpoint:   c.String("otlp-endpoint"),
+		MetricsFile:    c.String("metrics-file"),
+		MetricsPushURL: c.String("metrics-push-url"),
+		IsDryRunErr:    func(err error) bool { return errors.Is(err, topf.ErrDryRunChangesDetected) },
+		Logger:         t.Logger(),
+	})
+}
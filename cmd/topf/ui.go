@@ -0,0 +1,264 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
+)
+
+// ui modes of the rolling commands
+const (
+	uiPlain = "plain"
+	uiTUI   = "tui"
+)
+
+const (
+	// dashboardLogLines is the number of log lines kept for the log pane
+	dashboardLogLines = 10000
+	// dashboardRedrawInterval limits how often the dashboard is redrawn
+	dashboardRedrawInterval = 100 * time.Millisecond
+	// exitInterrupted is the exit code after Ctrl-C, like a shell reports SIGINT
+	exitInterrupted = 130
+)
+
+func newUIFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "ui",
+		Value:   uiPlain,
+		Usage:   "how progress is shown: \"plain\" writes logs, \"tui\" shows a dashboard with the phase of every node and a scrollable log pane (requires --confirm=false, falls back to plain when stdout is not a terminal)",
+		Sources: cli.EnvVars("TOPF_UI"),
+		Validator: func(value string) error {
+			if value != uiPlain && value != uiTUI {
+				return fmt.Errorf("invalid ui %q, valid values: %s, %s", value, uiPlain, uiTUI)
+			}
+
+			return nil
+		},
+	}
+}
+
+// withProgress runs fn with a progress tracker shown as a dashboard when
+// --ui=tui and stdout is a terminal. Otherwise fn gets a nil tracker and logs
+// are written as usual.
+func withProgress(c *cli.Command, t topf.Topf, fn func(tracker *progress.Tracker) error) error {
+	if c.String("ui") != uiTUI {
+		return fn(nil)
+	}
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		t.Logger().Debug("stdout is not a terminal, falling back to plain output")
+		return fn(nil)
+	}
+
+	if t.Confirm() {
+		return errors.New("--ui=tui requires --confirm=false, as prompts can't be answered in the dashboard")
+	}
+
+	ui := newDashboardUI(c.Name, t)
+	defer ui.close()
+
+	return fn(ui.tracker)
+}
+
+// dashboardUI shows a progress dashboard on the terminal, with all output of
+// the runtime redirected to its log pane.
+type dashboardUI struct {
+	tracker *progress.Tracker
+	dash    *progress.Dashboard
+	redraw  chan struct{}
+	done    chan struct{}
+
+	// mu serializes terminal writes with close
+	mu      sync.Mutex
+	closed  bool
+	cleanup []func()
+}
+
+// newDashboardUI switches the terminal to the dashboard and starts drawing
+// it until close is called.
+func newDashboardUI(title string, t topf.Topf) *dashboardUI {
+	tracker := progress.New()
+	logs := progress.NewLogBuffer(dashboardLogLines)
+
+	ui := &dashboardUI{
+		tracker: tracker,
+		dash:    progress.NewDashboard(title, tracker, logs),
+		redraw:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	ui.dash.Color = os.Getenv("NO_COLOR") == ""
+
+	ui.cleanup = append(ui.cleanup, t.RedirectOutput(logs, tracker.Handler(slog.LevelInfo)))
+
+	// use the alternate screen, so that the dashboard doesn't mess up the
+	// scrollback of the terminal, and hide the cursor
+	fmt.Print("\033[?1049h\033[?25l")
+	ui.cleanup = append(ui.cleanup, func() { fmt.Print("\033[?25h\033[?1049l") })
+
+	// keys are read unbuffered, without echo
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			ui.cleanup = append(ui.cleanup, func() { _ = term.Restore(fd, state) })
+
+			go ui.readKeys()
+		}
+	}
+
+	// Ctrl-C in raw mode is handled by readKeys; signals from elsewhere
+	// restore the terminal before exiting
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	ui.cleanup = append(ui.cleanup, func() { signal.Stop(signals) })
+
+	go func() {
+		select {
+		case <-signals:
+			ui.interrupt()
+		case <-ui.done:
+		}
+	}()
+
+	go ui.drawLoop(logs)
+
+	return ui
+}
+
+// close stops drawing, restores the terminal and the output of the runtime,
+// and prints the final state of the nodes.
+func (ui *dashboardUI) close() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if ui.closed {
+		return
+	}
+
+	ui.closed = true
+	close(ui.done)
+
+	for i := len(ui.cleanup) - 1; i >= 0; i-- {
+		ui.cleanup[i]()
+	}
+
+	width, _ := terminalSize()
+	fmt.Println(strings.Join(ui.dash.Summary(width, time.Now()), "\n"))
+}
+
+// interrupt closes the dashboard and exits, as SIGINT would without it
+func (ui *dashboardUI) interrupt() {
+	ui.close()
+	os.Exit(exitInterrupted)
+}
+
+func (ui *dashboardUI) drawLoop(logs *progress.LogBuffer) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		ui.draw()
+
+		select {
+		case <-ui.done:
+			return
+		case <-ticker.C:
+		case <-ui.tracker.Updated():
+		case <-logs.Updated():
+		case <-ui.redraw:
+		}
+
+		time.Sleep(dashboardRedrawInterval)
+	}
+}
+
+// draw redraws the whole screen from the top left corner
+func (ui *dashboardUI) draw() {
+	width, height := terminalSize()
+	lines := ui.dash.Render(width, height, time.Now())
+
+	var buf bytes.Buffer
+
+	buf.WriteString("\033[H")
+
+	for i, line := range lines {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+
+		// clear the rest of the line and, after the last one, the screen
+		buf.WriteString(line + "\033[K")
+	}
+
+	buf.WriteString("\033[J")
+
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if !ui.closed {
+		_, _ = os.Stdout.Write(buf.Bytes())
+	}
+}
+
+// readKeys scrolls the log pane on key presses. It runs until the process
+// exits, as reads from stdin can't be interrupted.
+func (ui *dashboardUI) readKeys() {
+	buf := make([]byte, 16)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+
+		_, height := terminalSize()
+
+		switch string(buf[:n]) {
+		case "\x03": // Ctrl-C
+			ui.interrupt()
+		case "\x1b[A", "k":
+			ui.dash.Scroll(1)
+		case "\x1b[B", "j":
+			ui.dash.Scroll(-1)
+		case "\x1b[5~", "b":
+			ui.dash.Scroll(height / 2)
+		case "\x1b[6~", " ":
+			ui.dash.Scroll(-height / 2)
+		case "\x1b[H", "\x1b[1~", "g":
+			ui.dash.Scroll(dashboardLogLines)
+		case "\x1b[F", "\x1b[4~", "G":
+			ui.dash.Follow()
+		default:
+			continue
+		}
+
+		select {
+		case ui.redraw <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// terminalSize returns the size of the terminal on stdout, or 80x24 if it
+// can't be determined
+func terminalSize() (width, height int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+
+	return width, height
+}
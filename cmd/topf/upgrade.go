@@ -14,6 +14,7 @@ import (
 
 	"github.com/postfinance/topf/internal/cmd/upgrade"
 	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/progress"
 	"github.com/postfinance/topf/internal/topf"
 	"github.com/siderolabs/talos/cmd/talosctl/pkg/talos/nodedrain"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
@@ -25,7 +26,7 @@ func newUpgradeCmd() *cli.Command {
 		Name:        "upgrade",
 		Usage:       "upgrades talos on each node to the desired version",
 		Description: `Issues upgrade commands to each node to upgrade Talos to the desired version specified in the installer image.`,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "dry-run",
 				Usage:   "only show what upgrades would be performed without actually upgrading",
@@ -68,7 +69,18 @@ func newUpgradeCmd() *cli.Command {
 				Value:   false,
 				Sources: cli.EnvVars("TOPF_FORCE"),
 			},
-		},
+			&cli.BoolFlag{
+				Name:    "resume",
+				Usage:   "continue the upgrades interrupted by a previous run from their last completed phase, as recorded in " + upgrade.StateFileName + " next to topf.yaml, and uncordon nodes left cordoned",
+				Value:   false,
+				Sources: cli.EnvVars("TOPF_RESUME"),
+			},
+			newAllowQuorumLossFlag(),
+			newSkipHealthGatesFlag(),
+			newSkipHooksFlag(),
+			newReportFlag(),
+			newUIFlag(),
+		}, slices.Concat(newEtcdBackupFlags(), newRolloutFlags(), newTelemetryFlags())...),
 		Before: noPositionalArgs,
 		Action: func(ctx context.Context, c *cli.Command) error {
 			t := MustGetRuntime(ctx)
@@ -83,15 +95,43 @@ func newUpgradeCmd() *cli.Command {
 				return err
 			}
 
-			err = upgrade.Execute(ctx, t, upgrade.Options{
-				DryRun:                c.Bool("dry-run"),
-				RebootMode:            rebootMode,
-				Force:                 c.Bool("force"),
-				Drain:                 c.Bool("drain"),
-				DrainTimeout:          c.Duration("drain-timeout"),
-				DeleteIfEvictionFails: c.Bool("delete-if-eviction-fails"),
-				MaxParallel:           maxParallel,
+			waves, err := newWaves(t, c)
+			if err != nil {
+				return err
+			}
+
+			rep, err := newReport(c, c.Bool("dry-run"))
+			if err != nil {
+				return err
+			}
+
+			controller, stopControl, err := newController(ctx, t, c)
+			if err != nil {
+				return err
+			}
+			defer stopControl()
+
+			err = withObservers(ctx, c, t, c.Bool("dry-run"), func(ctx context.Context, tracker *progress.Tracker) error {
+				return upgrade.Execute(ctx, t, upgrade.Options{
+					DryRun:                c.Bool("dry-run"),
+					RebootMode:            rebootMode,
+					Force:                 c.Bool("force"),
+					Drain:                 c.Bool("drain"),
+					DrainTimeout:          c.Duration("drain-timeout"),
+					DeleteIfEvictionFails: c.Bool("delete-if-eviction-fails"),
+					Waves:                 waves,
+					Control:               controller,
+					MaxParallel:           maxParallel,
+					Resume:                c.Bool("resume"),
+					EtcdBackupDir:         etcdBackupDir(c),
+					AllowQuorumLoss:       c.Bool(allowQuorumLossFlag),
+					HealthGates:           newHealthGates(t, c),
+					Hooks:                 newHooks(t, c, c.Bool("dry-run")),
+					Report:                rep,
+					Progress:              tracker,
+				})
 			})
+			err = writeReport(t, c, rep, err)
 			if errors.Is(err, topf.ErrDryRunChangesDetected) {
 				return cli.Exit(err.Error(), 2)
 			}
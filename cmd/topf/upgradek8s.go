@@ -0,0 +1,46 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/postfinance/topf/internal/cmd/upgradek8s"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/urfave/cli/v3"
+)
+
+func newUpgradeK8sCmd() *cli.Command {
+	return &cli.Command{
+		Name:        "upgrade-k8s",
+		Usage:       "upgrades kubernetes components to the version set in topf.yaml",
+		Description: `Upgrades kube-apiserver, kube-controller-manager, kube-scheduler and the kubelet, component by component and one node at a time, to the kubernetesVersion configured in topf.yaml. Version skew is validated before any node is touched.`,
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Usage:   "only show which components would be upgraded without actually upgrading",
+				Value:   false,
+				Sources: cli.EnvVars("TOPF_DRY_RUN"),
+			},
+		}, newTelemetryFlags()...),
+		Before: noPositionalArgs,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			t := MustGetRuntime(ctx)
+
+			err := withObservers(ctx, c, t, c.Bool("dry-run"), func(ctx context.Context, tracker *progress.Tracker) error {
+				return upgradek8s.Execute(ctx, t, upgradek8s.Options{
+					DryRun:   c.Bool("dry-run"),
+					Progress: tracker,
+				})
+			})
+			if errors.Is(err, topf.ErrDryRunChangesDetected) {
+				return cli.Exit(err.Error(), 2)
+			}
+
+			return err
+		},
+	}
+}
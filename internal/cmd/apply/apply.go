@@ -12,8 +12,16 @@ import (
 	"slices"
 	"time"
 
+	"github.com/postfinance/topf/internal/configdiff"
+	"github.com/postfinance/topf/internal/control"
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/postfinance/topf/internal/healthgate"
+	"github.com/postfinance/topf/internal/hooks"
 	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/report"
 	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
 	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
 )
@@ -32,13 +40,37 @@ type Options struct {
 	AllowNotReady bool
 	// Apply mode passed to Talos (auto, reboot, no-reboot, staged, try)
 	Mode machine.ApplyConfigurationRequest_Mode
+	// DiffFormat controls how configuration changes are printed (unified, json)
+	DiffFormat configdiff.Format
+	// AllowQuorumLoss applies configurations that reboot control-plane nodes
+	// even if etcd loses quorum while they reboot
+	AllowQuorumLoss bool
 	// MaxParallel controls how many worker nodes are applied to concurrently.
 	// Control-plane nodes are always applied to one at a time.
 	MaxParallel nodepool.MaxParallel
+	// Waves splits the worker nodes into batches that are applied to one
+	// after the other, and limits the failure domains in flight
+	Waves nodepool.Waves
+	// Control pauses between nodes and waves for operator commands, if set
+	Control *control.Controller
+	// Hooks are run before and after the command and each node, if set
+	Hooks *hooks.Runner
+	// EtcdBackupDir is the directory an etcd snapshot is written to before
+	// the first running control-plane node is applied to. Empty disables the backup
+	EtcdBackupDir string
+	// HealthGates are waited for after each node stabilized, if set
+	HealthGates *healthgate.Runner
+	// Report records the per-node outcome, if set
+	Report *report.Report
+	// Progress tracks the current phase of each node, if set
+	Progress *progress.Tracker
+
+	// applyConfig replaces Node.Apply in tests
+	applyConfig func(ctx context.Context, node *topf.Node, beforeApply func(context.Context) error) (*topf.ApplyResult, error)
 }
 
 // Execute applies the Talos configurations to all nodes in the cluster
-func Execute(ctx context.Context, t topf.Topf, opts Options) error {
+func Execute(ctx context.Context, t topf.Topf, opts Options) (err error) {
 	logger := t.Logger().With("command", "apply")
 
 	nodes, err := t.FilteredNodes(ctx)
@@ -49,14 +81,35 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		return nil
 	}
 
+	opts.Report.AddNodes(nodes)
+	opts.Progress.AddNodes(nodes)
+
 	// Pre-flight checks
 	filteredNodes, err := runPreflightChecks(logger, nodes, &opts)
 	if err != nil {
 		return err
 	}
 
+	// Back up etcd before touching control-plane nodes. Nodes in maintenance
+	// mode don't run etcd yet, so there is nothing to back up for them.
+	if opts.EtcdBackupDir != "" && !opts.DryRun && slices.ContainsFunc(filteredNodes, runsEtcd) {
+		if err := etcd.Backup(ctx, t, opts.EtcdBackupDir, logger); err != nil {
+			return err
+		}
+	}
+
+	if err := opts.Hooks.PreCluster(ctx, filteredNodes, logger); err != nil {
+		return err
+	}
+
+	defer func() {
+		if hookErr := opts.Hooks.PostCluster(ctx, filteredNodes, err, logger); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+	}()
+
 	// Apply configs
-	if err := applyConfigs(ctx, logger, filteredNodes, opts); err != nil {
+	if err := applyConfigs(ctx, t, logger, filteredNodes, opts); err != nil {
 		return err
 	}
 
@@ -77,21 +130,29 @@ func runPreflightChecks(logger *slog.Logger, nodes []*topf.Node, opts *Options)
 
 		if node.Error != nil {
 			logger.Error("node pre-checks", "error", node.Error)
+			failPreflight(node, opts, node.Error)
+
 			return true
 		}
 
 		// when AllowNotReady is true, we skip the readiness check
 		if !opts.AllowNotReady && !node.MachineStatus.Status.Ready {
 			logger.Error("node not ready", "unmet conditions", node.MachineStatus.Status.UnmetConditions)
+			failPreflight(node, opts, fmt.Errorf("node not ready, unmet conditions: %v", node.MachineStatus.Status.UnmetConditions))
+
 			return true
 		}
 
 		st := node.MachineStatus.Stage
 		if !slices.Contains([]runtime.MachineStage{runtime.MachineStageRunning, runtime.MachineStageMaintenance, runtime.MachineStageBooting}, st) {
 			logger.Error("node in unprocessable stage", "stage", st.String())
+			failPreflight(node, opts, fmt.Errorf("node in unprocessable stage %s", st.String()))
+
 			return true
 		}
 
+		opts.Report.Update(node, func(n *report.Node) { n.Preflight = report.PreflightPassed })
+
 		if st == runtime.MachineStageMaintenance {
 			maintenanceNodesCnt++
 		}
@@ -120,11 +181,37 @@ func runPreflightChecks(logger *slog.Logger, nodes []*topf.Node, opts *Options)
 	return filteredNodes, nil
 }
 
+// runsEtcd reports whether a node is a control-plane node past maintenance mode.
+func runsEtcd(node *topf.Node) bool {
+	return node.Node.Role == config.RoleControlPlane && node.MachineStatus.Stage != runtime.MachineStageMaintenance
+}
+
+// failPreflight records a failed pre-flight check in the report and the
+// progress. The node is skipped when problematic nodes are skipped, and
+// failed otherwise.
+func failPreflight(node *topf.Node, opts *Options, err error) {
+	opts.Report.Update(node, func(n *report.Node) {
+		n.FailPreflight(err)
+
+		if opts.SkipProblematicNodes {
+			n.Status = report.StatusSkipped
+		} else {
+			n.Fail(err)
+		}
+	})
+
+	if opts.SkipProblematicNodes {
+		opts.Progress.Set(node, progress.PhaseSkipped)
+	} else {
+		opts.Progress.Finish(node, err)
+	}
+}
+
 // applyConfigs applies configuration to all filtered nodes. In dry-run mode all
 // nodes are processed sequentially. Otherwise control-plane nodes are applied to
 // one at a time (to preserve etcd quorum and keep the bootstrap node first), and
 // worker nodes are applied to using a rolling pool of at most MaxParallel nodes.
-func applyConfigs(ctx context.Context, logger *slog.Logger, nodes []*topf.Node, opts Options) error {
+func applyConfigs(ctx context.Context, t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) error {
 	if opts.DryRun {
 		return applyDryRun(ctx, logger, nodes, opts)
 	}
@@ -132,7 +219,17 @@ func applyConfigs(ctx context.Context, logger *slog.Logger, nodes []*topf.Node,
 	controlPlane, workers := nodepool.PartitionByRole(nodes)
 
 	for _, node := range controlPlane {
-		if err := applyNode(ctx, node, opts, logger.With(node.Attrs())); err != nil {
+		logger := logger.With(node.Attrs())
+
+		// a reboot takes the etcd member down, which must not cost quorum
+		var beforeReboot func(context.Context) error
+		if runsEtcd(node) {
+			beforeReboot = func(ctx context.Context) error {
+				return etcd.GuardQuorum(ctx, t, []string{node.Node.Host}, false, opts.AllowQuorumLoss, logger)
+			}
+		}
+
+		if err := applyNode(ctx, node, opts, beforeReboot, logger); err != nil {
 			return err
 		}
 	}
@@ -141,9 +238,12 @@ func applyConfigs(ctx context.Context, logger *slog.Logger, nodes []*topf.Node,
 		concurrency := opts.MaxParallel.Resolve(len(nodes))
 		logger.Info("applying to worker nodes", "count", len(workers), "concurrency", concurrency)
 
-		return nodepool.RunConcurrent(ctx, workers, concurrency,
+		waves := opts.Waves
+		waves.Control = opts.Control
+
+		return nodepool.RunWaves(ctx, workers, waves, concurrency,
 			func(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
-				return applyNode(ctx, node, opts, logger)
+				return applyNode(ctx, node, opts, nil, logger)
 			}, logger)
 	}
 
@@ -158,7 +258,7 @@ func applyDryRun(ctx context.Context, logger *slog.Logger, nodes []*topf.Node, o
 	for _, node := range nodes {
 		logger := logger.With(node.Attrs())
 
-		err := applyNode(ctx, node, opts, logger)
+		err := applyNode(ctx, node, opts, nil, logger)
 		if errors.Is(err, topf.ErrDryRunChangesDetected) {
 			changesDetected = true
 			continue
@@ -176,28 +276,169 @@ func applyDryRun(ctx context.Context, logger *slog.Logger, nodes []*topf.Node, o
 	return nil
 }
 
-// applyNode applies the configuration to a single node and, unless skipped,
-// waits for it to stabilize. The provided logger is expected to already carry
+// applyNode applies the configuration to a single node once the operator
+// lets it start. The node hooks only run around a configuration that is
+// actually applied: the pre-node hooks once the changes are confirmed, the
+// post-node hooks before the outcome of the node is recorded. beforeReboot is
+// passed on to Node.Apply. The provided logger is expected to already carry
 // the node's attributes. In dry-run mode it returns ErrDryRunChangesDetected
 // when changes are detected.
-func applyNode(ctx context.Context, node *topf.Node, opts Options, logger *slog.Logger) error {
-	applied, err := node.Apply(ctx, logger, opts.DryRun, opts.Mode)
-	if errors.Is(err, topf.ErrDryRunChangesDetected) {
+func applyNode(ctx context.Context, node *topf.Node, opts Options, beforeReboot func(context.Context) error, logger *slog.Logger) error {
+	if skip, err := startNode(ctx, node, opts, logger); skip || err != nil {
 		return err
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to apply config to node %v: %w", node.Node.Host, err)
+	hooked := false
+	beforeApply := func(ctx context.Context) error {
+		if err := opts.Hooks.PreNode(ctx, node, logger); err != nil {
+			return err
+		}
+
+		hooked = true
+
+		return nil
 	}
 
-	// if nothing was applied or dry-run mode, skip healthchecks
-	if !applied || opts.DryRun || opts.SkipPostApplyChecks {
+	phase, err := configureNode(ctx, node, opts, beforeApply, beforeReboot, logger)
+
+	if errors.Is(err, hooks.ErrSkipNode) {
+		logger.Warn("skipping node", "reason", err)
+		opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusSkipped })
+		opts.Progress.Set(node, progress.PhaseSkipped)
+
 		return nil
 	}
 
+	if hooked {
+		if hookErr := opts.Hooks.PostNode(ctx, node, err, logger); hookErr != nil && err == nil {
+			err = hookErr
+			opts.Report.Update(node, func(n *report.Node) { n.Fail(err) })
+		}
+	}
+
+	if err != nil && !errors.Is(err, topf.ErrDryRunChangesDetected) {
+		opts.Progress.Finish(node, err)
+	} else {
+		opts.Progress.Set(node, phase)
+	}
+
+	return err
+}
+
+// startNode waits until the operator lets the node start. It reports whether
+// the node is skipped, as recorded in the report and the progress.
+func startNode(ctx context.Context, node *topf.Node, opts Options, logger *slog.Logger) (bool, error) {
+	err := opts.Control.BeforeNode(ctx, node.Node.Host)
+
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.Is(err, control.ErrSkipped):
+		logger.Warn("skipping node", "reason", err)
+		opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusSkipped })
+		opts.Progress.Set(node, progress.PhaseSkipped)
+
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+// configureNode applies the configuration to a single node and, unless
+// skipped, waits for it to stabilize and pass the health gates. It records
+// the outcome in the report and returns the final progress phase of the
+// node, which is left to the caller to set.
+func configureNode(ctx context.Context, node *topf.Node, opts Options, beforeApply, beforeReboot func(context.Context) error, logger *slog.Logger) (progress.Phase, error) {
+	opts.Progress.Set(node, progress.PhaseApplying)
+
+	var (
+		result *topf.ApplyResult
+		err    error
+	)
+
+	if opts.applyConfig != nil {
+		result, err = opts.applyConfig(ctx, node, beforeApply)
+	} else {
+		result, err = node.Apply(ctx, logger, opts.DryRun, opts.Mode, opts.DiffFormat, beforeApply, beforeReboot)
+	}
+
+	if errors.Is(err, hooks.ErrSkipNode) {
+		return progress.PhaseSkipped, err
+	}
+
+	if err != nil && !errors.Is(err, topf.ErrDryRunChangesDetected) {
+		err = fmt.Errorf("failed to apply config to node %v: %w", node.Node.Host, err)
+		opts.Report.Update(node, func(n *report.Node) { n.Fail(err) })
+
+		return progress.PhaseFailed, err
+	}
+
+	opts.Report.Update(node, func(n *report.Node) { recordApplyResult(n, result, opts.DryRun) })
+
+	// if nothing was applied or dry-run mode, skip healthchecks
+	if err != nil || !result.Applied || opts.DryRun || opts.SkipPostApplyChecks {
+		return applyPhase(result, opts.DryRun), err
+	}
+
+	opts.Progress.Set(node, progress.PhaseStabilizing)
+
+	start := time.Now()
+
 	if err = node.Stabilize(ctx, logger, time.Second*30); err != nil {
-		return fmt.Errorf("node didn't stabilize: %w", err)
+		err = fmt.Errorf("node didn't stabilize: %w", err)
+		opts.Report.Update(node, func(n *report.Node) { n.Fail(err) })
+
+		return progress.PhaseFailed, err
 	}
 
-	return nil
+	opts.Report.Update(node, func(n *report.Node) { n.SetStabilization(time.Since(start)) })
+	opts.Progress.Set(node, progress.PhaseHealthGates)
+
+	if err = opts.HealthGates.Wait(ctx, node, logger); err != nil {
+		opts.Report.Update(node, func(n *report.Node) { n.Fail(err) })
+
+		return progress.PhaseFailed, err
+	}
+
+	return progress.PhaseDone, nil
+}
+
+// applyPhase returns the final progress phase of a node after an apply that
+// skipped the post-apply checks.
+func applyPhase(result *topf.ApplyResult, dryRun bool) progress.Phase {
+	switch {
+	case !result.Changed:
+		return progress.PhaseUnchanged
+	case result.Applied || dryRun:
+		return progress.PhaseDone
+	default:
+		return progress.PhaseSkipped
+	}
+}
+
+// recordApplyResult records the detected changes and the outcome of an apply
+// in the report entry of a node.
+func recordApplyResult(n *report.Node, result *topf.ApplyResult, dryRun bool) {
+	for _, doc := range result.Diff.Documents {
+		if !result.Changed || doc.Status == configdiff.StatusUnchanged {
+			continue
+		}
+
+		n.Changes = append(n.Changes, report.Change{Target: doc.ID, Status: string(doc.Status), Added: doc.Added, Removed: doc.Removed})
+	}
+
+	switch {
+	case !result.Changed:
+		n.Status = report.StatusUnchanged
+
+		return
+	case result.Applied:
+		n.Status = report.StatusSucceeded
+	case dryRun:
+		n.Status = report.StatusChangesDetected
+	default:
+		n.Status = report.StatusSkipped
+	}
+
+	n.Mode = result.Mode.String()
 }
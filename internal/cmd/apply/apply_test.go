@@ -0,0 +1,134 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package apply
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/postfinance/topf/internal/configdiff"
+	"github.com/postfinance/topf/internal/hooks"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// writeHook writes a shell script hook appending its phase and the result
+// of the node to log, then exiting with exitCode
+func writeHook(t *testing.T, dir, name, log, exitCode string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	body := "#!/bin/sh\necho \"$1 $TOPF_NODE_HOST $TOPF_RESULT\" >> " + log + "\nexit " + exitCode + "\n"
+
+	if err := os.WriteFile(path, []byte(body), 0o700); err != nil { //nolint:gosec // the hook has to be executable
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// fakeApply applies nothing to unchanged nodes and runs beforeApply for the
+// others, like Node.Apply
+func fakeApply(changed map[string]bool) func(context.Context, *topf.Node, func(context.Context) error) (*topf.ApplyResult, error) {
+	return func(ctx context.Context, node *topf.Node, beforeApply func(context.Context) error) (*topf.ApplyResult, error) {
+		result := &topf.ApplyResult{Diff: &configdiff.Diff{}}
+		if !changed[node.Node.Host] {
+			return result, nil
+		}
+
+		if err := beforeApply(ctx); err != nil {
+			return nil, err
+		}
+
+		result.Changed, result.Applied = true, true
+
+		return result, nil
+	}
+}
+
+func testNodes(hosts ...string) []*topf.Node {
+	nodes := make([]*topf.Node, len(hosts))
+	for i, host := range hosts {
+		nodes[i] = &topf.Node{Node: &config.Node{Host: host, Role: config.RoleWorker}}
+	}
+
+	return nodes
+}
+
+func TestApplyNodeHooks(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+
+	opts := Options{
+		SkipPostApplyChecks: true,
+		Hooks: hooks.New(&config.Hooks{
+			PreNode:  []config.Hook{{Command: writeHook(t, dir, "pre", log, "0")}},
+			PostNode: []config.Hook{{Command: writeHook(t, dir, "post", log, "0")}},
+		}, "test", "apply"),
+		Progress:    progress.New(),
+		applyConfig: fakeApply(map[string]bool{"changed": true}),
+	}
+
+	nodes := testNodes("unchanged", "changed")
+	opts.Progress.AddNodes(nodes)
+
+	for _, node := range nodes {
+		if err := applyNode(context.Background(), node, opts, nil, slog.New(slog.DiscardHandler)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(content), "pre-node changed \npost-node changed succeeded\n"; got != want {
+		t.Errorf("hooks ran:\n%s\nwant:\n%s", got, want)
+	}
+
+	phases := map[string]progress.Phase{}
+	for _, n := range opts.Progress.Nodes() {
+		phases[n.Host] = n.Phase
+	}
+
+	if phases["unchanged"] != progress.PhaseUnchanged || phases["changed"] != progress.PhaseDone {
+		t.Errorf("unexpected phases: %v", phases)
+	}
+}
+
+func TestApplyNodeFailingPostNodeHook(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+
+	opts := Options{
+		SkipPostApplyChecks: true,
+		Hooks: hooks.New(&config.Hooks{
+			PostNode: []config.Hook{{Command: writeHook(t, dir, "post", log, "1"), OnFailure: config.HookAbort}},
+		}, "test", "apply"),
+		Progress:    progress.New(),
+		applyConfig: fakeApply(map[string]bool{"node1": true}),
+	}
+
+	nodes := testNodes("node1")
+	opts.Progress.AddNodes(nodes)
+
+	// the node events, as sent to the webhooks
+	var events []progress.Node
+
+	opts.Progress.Observe(func(n progress.Node) { events = append(events, n) })
+
+	if err := applyNode(context.Background(), nodes[0], opts, nil, slog.New(slog.DiscardHandler)); err == nil {
+		t.Fatal("expected the failed post-node hook to fail the node")
+	}
+
+	if len(events) != 1 || events[0].Phase != progress.PhaseFailed || !strings.Contains(events[0].Error, "post-node") {
+		t.Errorf("node events = %+v, want a single failed event", events)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package decommission contains the logic to remove nodes from a cluster for
+// good, cleaning up their traces in Kubernetes and etcd
+package decommission
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/k8s"
+	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/report"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
+)
+
+// Options contains the options for the decommission execution
+type Options struct {
+	// DrainTimeout is the maximum time to wait for pod evictions to complete.
+	DrainTimeout time.Duration
+
+	// DeleteIfEvictionFails retries the drain with direct pod deletion
+	// (DELETE instead of EVICT, bypassing PDBs) if the graceful drain fails.
+	DeleteIfEvictionFails bool
+
+	// Shutdown powers the machine off after the reset instead of rebooting
+	// it into maintenance mode.
+	Shutdown bool
+
+	// AllowQuorumLoss removes etcd members even if etcd loses quorum.
+	AllowQuorumLoss bool
+
+	// Report records the per-node outcome, if set.
+	Report *report.Report
+}
+
+// Execute decommissions the selected nodes one at a time: the Kubernetes node
+// is cordoned and drained, the etcd member of control-plane nodes is removed,
+// the machine is reset and the Kubernetes Node object is deleted. The first
+// failure stops the run, so that no further node is decommissioned on top of
+// an unexpected cluster state.
+func Execute(ctx context.Context, t topf.Topf, opts Options) error {
+	logger := t.Logger().With("command", "decommission")
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		logger.Info("no node to act upon")
+		return nil
+	}
+
+	opts.Report.AddNodes(nodes)
+
+	var decommissioned []string
+
+	err = nodepool.RunConcurrent(ctx, nodes, 1, func(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
+		done, err := decommissionNode(ctx, t, node, opts, logger)
+		if err != nil {
+			opts.Report.Update(node, func(rn *report.Node) { rn.Fail(err) })
+			return fmt.Errorf("node %s: %w", node.Node.Host, err)
+		}
+
+		if done {
+			decommissioned = append(decommissioned, node.Node.Host)
+		}
+
+		return nil
+	}, logger)
+
+	warnStillConfigured(t.Config(), decommissioned, logger)
+
+	return err
+}
+
+// decommissionNode returns whether the node was decommissioned, as opposed to
+// skipped
+func decommissionNode(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, logger *slog.Logger) (bool, error) {
+	if node.Error != nil {
+		return false, node.Error
+	}
+
+	if node.MachineStatus.Stage == runtime.MachineStageMaintenance {
+		logger.Info("already in maintenance mode, nothing to decommission")
+		opts.Report.Update(node, func(rn *report.Node) { rn.Status = report.StatusUnchanged })
+
+		return false, nil
+	}
+
+	if t.Confirm() {
+		message := fmt.Sprintf("Do you want to decommission %s? The node is drained, removed from etcd and Kubernetes, and wiped.", node.Node.Host)
+		if interactive.ConfirmPrompt(message) == 'n' {
+			logger.Info("skipping")
+			opts.Report.Update(node, func(rn *report.Node) { rn.Status = report.StatusSkipped })
+
+			return false, nil
+		}
+	}
+
+	k8sNodeName, err := k8s.NodeName(ctx, node)
+	if err != nil {
+		return false, err
+	}
+
+	// The clientset talks to the Kubernetes API directly and stays usable
+	// once the node (possibly a control-plane node) is reset.
+	clientset, err := k8s.NewClientset(ctx, t, logger)
+	if err != nil {
+		return false, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	if err := k8s.Drain(ctx, clientset, k8sNodeName, opts.DrainTimeout, opts.DeleteIfEvictionFails, logger); err != nil {
+		return false, err
+	}
+
+	recordChange(opts.Report, node, report.Change{Target: "kubernetes/" + k8sNodeName, Status: "drained"})
+
+	if node.Node.Role == config.RoleControlPlane {
+		memberID, err := etcd.RemoveNode(ctx, t, node, opts.AllowQuorumLoss, logger)
+		if err != nil {
+			return false, err
+		}
+
+		if memberID != "" {
+			recordChange(opts.Report, node, report.Change{Target: "etcd/" + memberID, Status: "removed"})
+		}
+	}
+
+	if err := resetNode(ctx, node, opts); err != nil {
+		return false, err
+	}
+
+	logger.Info("reset initiated")
+	recordChange(opts.Report, node, report.Change{Target: "machine", Status: "reset"})
+
+	// The Node object is deleted last: a kubelet still running would
+	// register it again.
+	if err := k8s.DeleteNode(ctx, clientset, k8sNodeName); err != nil {
+		return false, err
+	}
+
+	logger.Info("kubernetes node deleted", "k8s_node", k8sNodeName)
+	recordChange(opts.Report, node, report.Change{Target: "kubernetes/" + k8sNodeName, Status: "deleted"})
+
+	opts.Report.Update(node, func(rn *report.Node) { rn.Status = report.StatusSucceeded })
+
+	return true, nil
+}
+
+// resetNode wipes the whole install disk. The node was already drained and
+// removed from etcd, so the reset doesn't need to be graceful.
+func resetNode(ctx context.Context, node *topf.Node, opts Options) error {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	_, err = nodeClient.MachineClient.Reset(ctx, &machine.ResetRequest{
+		Graceful: false,
+		Reboot:   !opts.Shutdown,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initiate reset: %w", err)
+	}
+
+	return nil
+}
+
+func recordChange(rep *report.Report, node *topf.Node, change report.Change) {
+	rep.Update(node, func(rn *report.Node) { rn.Changes = append(rn.Changes, change) })
+}
+
+// warnStillConfigured warns about decommissioned hosts that are still part of
+// the configuration, as the next apply would bring them back.
+func warnStillConfigured(cfg *config.TopfConfig, hosts []string, logger *slog.Logger) {
+	for _, configured := range cfg.Nodes {
+		if !slices.Contains(hosts, configured.Host) {
+			continue
+		}
+
+		if configured.Provided {
+			logger.Warn("decommissioned host is still returned by the nodes provider, remove it there", "node", configured.Host, "nodes_provider", cfg.NodesProvider)
+		} else {
+			logger.Warn("decommissioned host is still listed in topf.yaml, remove it", "node", configured.Host)
+		}
+	}
+}
@@ -9,9 +9,15 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/postfinance/topf/internal/hooks"
 	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/report"
 	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
 	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
 )
@@ -24,6 +30,14 @@ type Options struct {
 	Graceful           bool
 	Shutdown           bool
 	WaitForMaintenance bool
+	// AllowQuorumLoss resets control-plane nodes even if etcd loses quorum
+	AllowQuorumLoss bool
+	// Report records the per-node outcome, if set
+	Report *report.Report
+	// Progress tracks the current phase of each node, if set
+	Progress *progress.Tracker
+	// Hooks are run before and after the command and each node, if set
+	Hooks *hooks.Runner
 }
 
 // Result contains the result of the reset operation
@@ -34,7 +48,7 @@ type Result struct {
 }
 
 // Execute performs the reset operation on all nodes in the cluster
-func Execute(ctx context.Context, t topf.Topf, opts Options) error {
+func Execute(ctx context.Context, t topf.Topf, opts Options) (err error) {
 	logger := t.Logger().With("command", "reset")
 	result := &Result{}
 
@@ -49,13 +63,32 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		return nil
 	}
 
-	var resetNodes []*topf.Node
+	opts.Report.AddNodes(nodes)
+	opts.Progress.AddNodes(nodes)
+
+	if err := opts.Hooks.PreCluster(ctx, nodes, logger); err != nil {
+		return err
+	}
+
+	defer func() {
+		if hookErr := opts.Hooks.PostCluster(ctx, nodes, err, logger); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+	}()
+
+	var (
+		resetNodes   []*topf.Node
+		controlPlane []string
+		refused      []error
+	)
 
 	for _, n := range nodes {
 		logger := logger.With(n.Attrs())
 
 		if n.MachineStatus.Stage == runtime.MachineStageMaintenance {
 			logger.Info("already in maintenance mode")
+			opts.Report.Update(n, func(rn *report.Node) { rn.Status = report.StatusUnchanged })
+			opts.Progress.Set(n, progress.PhaseUnchanged)
 
 			result.SkipCount++
 
@@ -65,6 +98,11 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		nodeClient, err := n.Client(ctx)
 		if err != nil {
 			logger.Info("couldn't get client", "error", err)
+			opts.Report.Update(n, func(rn *report.Node) {
+				rn.Status = report.StatusSkipped
+				rn.Error = err.Error()
+			})
+			opts.Progress.Set(n, progress.PhaseSkipped)
 
 			result.SkipCount++
 
@@ -82,18 +120,64 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 			partitions = nil
 		}
 
+		// Nodes reset earlier in this run count as down. A graceful reset
+		// makes the member leave etcd, which lowers the quorum instead.
+		if n.Node.Role == config.RoleControlPlane {
+			opts.Progress.Set(n, progress.PhasePreflight)
+
+			if err := etcd.GuardQuorum(ctx, t, append(controlPlane, n.Node.Host), opts.Graceful, opts.AllowQuorumLoss, logger); err != nil {
+				logger.Error("refusing to reset", "error", err)
+				opts.Report.Update(n, func(rn *report.Node) { rn.Fail(err) })
+				opts.Progress.Finish(n, err)
+
+				result.FailCount++
+
+				refused = append(refused, err)
+
+				continue
+			}
+		}
+
 		// ask for user confirmation
 		if t.Confirm() {
 			message := fmt.Sprintf("Do you want to reset %s ?", n.Node.Host)
 			if interactive.ConfirmPrompt(message) == 'n' {
 				logger.Info("skipping")
+				opts.Report.Update(n, func(rn *report.Node) { rn.Status = report.StatusSkipped })
+				opts.Progress.Set(n, progress.PhaseSkipped)
+
+				result.SkipCount++
+
+				continue
+			}
+		}
+
+		if err := opts.Hooks.PreNode(ctx, n, logger); err != nil {
+			if errors.Is(err, hooks.ErrSkipNode) {
+				logger.Warn("skipping node", "reason", err)
+				opts.Report.Update(n, func(rn *report.Node) { rn.Status = report.StatusSkipped })
+				opts.Progress.Set(n, progress.PhaseSkipped)
 
 				result.SkipCount++
 
 				continue
 			}
+
+			// no further nodes are reset, but the ones reset already are
+			// waited for
+			logger.Error("pre-node hook failed, stopping", "error", err)
+			opts.Report.Update(n, func(rn *report.Node) { rn.Fail(err) })
+			opts.Progress.Finish(n, err)
+
+			result.FailCount++
+
+			refused = append(refused, err)
+
+			break
 		}
 
+		opts.Progress.Set(n, progress.PhaseResetting)
+
 		_, err = nodeClient.MachineClient.Reset(ctx, &machine.ResetRequest{
 			SystemPartitionsToWipe: partitions,
 			Graceful:               opts.Graceful,
@@ -101,17 +185,44 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		})
 		if err != nil {
 			logger.Error("failed to initiate reset", "error", err)
+			opts.Report.Update(n, func(rn *report.Node) { rn.Fail(err) })
 
 			result.FailCount++
 
+			if hookErr := opts.Hooks.PostNode(ctx, n, err, logger); hookErr != nil {
+				refused = append(refused, hookErr)
+			}
+
+			opts.Progress.Finish(n, err)
+
 			continue
 		}
 
 		logger.Info("reset initiated")
+		opts.Report.Update(n, func(rn *report.Node) { rn.Status = report.StatusSucceeded })
+
+		// the node reboots into maintenance mode, which is waited for below
+		// before the post-node hooks run
+		if opts.WaitForMaintenance {
+			opts.Progress.Set(n, progress.PhaseRebooting)
+		} else {
+			hookErr := opts.Hooks.PostNode(ctx, n, nil, logger)
+			if hookErr != nil {
+				opts.Report.Update(n, func(rn *report.Node) { rn.Fail(hookErr) })
+
+				refused = append(refused, hookErr)
+			}
+
+			opts.Progress.Finish(n, hookErr)
+		}
 
 		result.SuccessCount++
 
 		resetNodes = append(resetNodes, n)
+
+		if n.Node.Role == config.RoleControlPlane {
+			controlPlane = append(controlPlane, n.Node.Host)
+		}
 	}
 
 	logger.Info("reset completed", "result", *result)
@@ -130,16 +241,24 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 				defer wg.Done()
 
 				logger := logger.With(n.Attrs())
+				start := time.Now()
 
-				if err := n.WaitForMaintenance(ctx, logger); err != nil {
+				err := n.WaitForMaintenance(ctx, logger)
+				if err != nil {
 					logger.Error("failed waiting for maintenance mode", "error", err)
+				} else {
+					logger.Info("node is in maintenance mode")
+					opts.Report.Update(n, func(rn *report.Node) { rn.SetStabilization(time.Since(start)) })
+				}
 
-					errs <- err
+				err = errors.Join(err, opts.Hooks.PostNode(ctx, n, err, logger))
+				if err != nil {
+					opts.Report.Update(n, func(rn *report.Node) { rn.Fail(err) })
 
-					return
+					errs <- err
 				}
 
-				logger.Info("node is in maintenance mode")
+				opts.Progress.Finish(n, err)
 			}(n)
 		}
 
@@ -152,9 +271,9 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		}
 
 		if err := errors.Join(waitErrs...); err != nil {
-			return err
+			return errors.Join(append(refused, err)...)
 		}
 	}
 
-	return nil
+	return errors.Join(refused...)
 }
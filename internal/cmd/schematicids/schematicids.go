@@ -8,21 +8,25 @@ import (
 	"cmp"
 	"context"
 	"fmt"
-	"io"
 	"sort"
 
 	"github.com/postfinance/topf/internal/topf"
 	"github.com/postfinance/topf/pkg/config"
 )
 
-// Execute resolves all schematic IDs for the configured nodes and prints
-// them to w, one per line, deduplicated and sorted. Per-node schematicId
-// overrides are honored. @-prefixed references are resolved via the
-// schematic resolver.
-func Execute(ctx context.Context, t topf.Topf, w io.Writer) error {
+// Schematic is a resolved schematic ID and the hosts installing it
+type Schematic struct {
+	ID    string   `yaml:"id"`
+	Nodes []string `yaml:"nodes"`
+}
+
+// Resolve resolves all schematic IDs for the configured nodes, deduplicated
+// and sorted. Per-node schematicId overrides are honored. @-prefixed
+// references are resolved via the schematic resolver.
+func Resolve(ctx context.Context, t topf.Topf) ([]Schematic, error) {
 	cfg := t.Config()
 
-	ids := make(map[string]struct{})
+	nodes := make(map[string][]string)
 
 	for _, node := range cfg.Nodes {
 		factory := cmp.Or(node.Factory, cfg.Factory, topf.DefaultFactory)
@@ -40,22 +44,18 @@ func Execute(ctx context.Context, t topf.Topf, w io.Writer) error {
 
 		resolved, err := t.ResolveSchematic(ctx, factory, schematicID, patchCtx)
 		if err != nil {
-			return fmt.Errorf("failed to resolve schematic for node %s: %w", node.Host, err)
+			return nil, fmt.Errorf("failed to resolve schematic for node %s: %w", node.Host, err)
 		}
 
-		ids[resolved] = struct{}{}
+		nodes[resolved] = append(nodes[resolved], node.Host)
 	}
 
-	sorted := make([]string, 0, len(ids))
-	for id := range ids {
-		sorted = append(sorted, id)
+	schematics := make([]Schematic, 0, len(nodes))
+	for id, hosts := range nodes {
+		schematics = append(schematics, Schematic{ID: id, Nodes: hosts})
 	}
 
-	sort.Strings(sorted)
-
-	for _, id := range sorted {
-		fmt.Fprintln(w, id)
-	}
+	sort.Slice(schematics, func(i, j int) bool { return schematics[i].ID < schematics[j].ID })
 
-	return nil
+	return schematics, nil
 }
@@ -0,0 +1,351 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package status contains the logic to gather a cluster-wide state snapshot
+package status
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/postfinance/topf/internal/drift"
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/postfinance/topf/internal/k8s"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/talos/pkg/machinery/config/generate/secrets"
+	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateExpiryThreshold is how long before its expiry a CA certificate
+// is reported as a problem
+const CertificateExpiryThreshold = 30 * 24 * time.Hour
+
+// Status is a cluster-wide state snapshot. The cluster is degraded if any
+// problem was found.
+type Status struct {
+	Cluster      string        `yaml:"cluster"`
+	Bootstrapped bool          `yaml:"bootstrapped"`
+	Etcd         Etcd          `yaml:"etcd"`
+	Nodes        []*Node       `yaml:"nodes"`
+	Certificates []Certificate `yaml:"certificates"`
+	Problems     []string      `yaml:"problems"`
+}
+
+// Etcd is the state of the etcd cluster
+type Etcd struct {
+	Leader  string        `yaml:"leader,omitempty"`
+	Members []etcd.Member `yaml:"members"`
+	Healthy []string      `yaml:"healthy"`
+	Error   string        `yaml:"error,omitempty"`
+}
+
+// Node is the state of a single node
+type Node struct {
+	Host       string        `yaml:"host"`
+	Role       string        `yaml:"role"`
+	Stage      string        `yaml:"stage,omitempty"`
+	Ready      bool          `yaml:"ready"`
+	Talos      drift.Drift   `yaml:"talos"`
+	Schematic  drift.Drift   `yaml:"schematic"`
+	Config     *drift.Config `yaml:"config,omitempty"`
+	Kubernetes *Kubernetes   `yaml:"kubernetes,omitempty"`
+	Error      string        `yaml:"error,omitempty"`
+}
+
+// Kubernetes is the state of the Kubernetes node of a node
+type Kubernetes struct {
+	Name    string      `yaml:"name,omitempty"`
+	Ready   bool        `yaml:"ready"`
+	Version drift.Drift `yaml:"version"`
+	Error   string      `yaml:"error,omitempty"`
+}
+
+// Certificate is a CA certificate of the secrets bundle
+type Certificate struct {
+	Name     string    `yaml:"name"`
+	NotAfter time.Time `yaml:"notAfter"`
+}
+
+// Degraded reports whether any problem was found
+func (s *Status) Degraded() bool {
+	return len(s.Problems) > 0
+}
+
+func (s *Status) problem(format string, args ...any) {
+	s.Problems = append(s.Problems, fmt.Sprintf(format, args...))
+}
+
+// Gather collects the state of the selected nodes and of the cluster they
+// belong to. Unreachable components are reported as problems, not errors.
+func Gather(ctx context.Context, t topf.Topf) (*Status, error) {
+	logger := t.Logger().With("command", "status")
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Cluster: t.Config().ClusterName, Problems: []string{}}
+
+	gatherEtcd(ctx, t, status)
+
+	status.Nodes = gatherNodes(ctx, nodes)
+	gatherKubernetes(ctx, t, nodes, status, logger)
+
+	for _, n := range status.Nodes {
+		nodeProblems(n, status)
+	}
+
+	secretsBundle, err := t.Secrets()
+	if err != nil {
+		status.problem("secrets: %v", err)
+	} else {
+		status.Certificates = certificates(secretsBundle, status)
+	}
+
+	return status, nil
+}
+
+func gatherEtcd(ctx context.Context, t topf.Topf, status *Status) {
+	health, err := etcd.ClusterHealth(ctx, t)
+	if err != nil {
+		status.Etcd.Error = err.Error()
+		status.problem("etcd: %v", err)
+
+		return
+	}
+
+	status.Etcd.Members = health.Members
+	status.Bootstrapped = len(health.Members) > 0
+
+	if !status.Bootstrapped {
+		status.problem("etcd: not bootstrapped")
+		return
+	}
+
+	controlPlanes := len(t.ControlPlaneNodes())
+	voters := 0
+
+	for _, m := range health.Members {
+		name := memberName(m)
+
+		if m.Leader {
+			status.Etcd.Leader = name
+		}
+
+		if health.Healthy[m.ID] {
+			status.Etcd.Healthy = append(status.Etcd.Healthy, name)
+		} else {
+			status.problem("etcd: member %s is unhealthy", name)
+		}
+
+		if m.Host == "" {
+			status.problem("etcd: member %s (%s) runs on no control-plane node of topf.yaml", m.Name, m.ID)
+		}
+
+		if m.Learner {
+			status.problem("etcd: member %s is a learner", name)
+		} else {
+			voters++
+		}
+	}
+
+	if status.Etcd.Leader == "" {
+		status.problem("etcd: no leader")
+	}
+
+	if voters != controlPlanes {
+		status.problem("etcd: %d voting members for %d control-plane nodes", voters, controlPlanes)
+	}
+}
+
+// memberName names a member by its topf host, falling back to its member name
+func memberName(m etcd.Member) string {
+	if m.Host != "" {
+		return m.Host
+	}
+
+	return m.Name
+}
+
+// gatherNodes collects the Talos state of the nodes and checks their config
+// drift concurrently
+func gatherNodes(ctx context.Context, nodes []*topf.Node) []*Node {
+	result := make([]*Node, len(nodes))
+
+	var wg sync.WaitGroup
+
+	for i, node := range nodes {
+		n := &Node{Host: node.Node.Host, Role: string(node.Node.Role)}
+		result[i] = n
+
+		if node.Error != nil {
+			n.Error = node.Error.Error()
+			continue
+		}
+
+		n.Stage = node.MachineStatus.Stage.String()
+		n.Ready = node.MachineStatus.Status.Ready
+		n.Talos = drift.Talos(node)
+		n.Schematic = drift.Schematic(node)
+
+		wg.Go(func() {
+			n.Config = drift.CheckConfig(ctx, node)
+		})
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+// gatherKubernetes looks up the Kubernetes node of every running node
+func gatherKubernetes(ctx context.Context, t topf.Topf, nodes []*topf.Node, status *Status, logger *slog.Logger) {
+	if !slices.ContainsFunc(nodes, isRunning) {
+		return
+	}
+
+	clientset, err := k8s.NewClientset(ctx, t, logger)
+	if err != nil {
+		status.problem("kubernetes: %v", err)
+		return
+	}
+
+	list, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		status.problem("kubernetes: listing nodes: %v", err)
+		return
+	}
+
+	desired := strings.TrimPrefix(t.Config().KubernetesVersion, "v")
+
+	for i, node := range nodes {
+		if !isRunning(node) {
+			continue
+		}
+
+		kn := &Kubernetes{Version: drift.Drift{Desired: desired}}
+		status.Nodes[i].Kubernetes = kn
+
+		name, err := k8s.NodeName(ctx, node)
+		if err != nil {
+			kn.Error = err.Error()
+			continue
+		}
+
+		kn.Name = name
+
+		idx := slices.IndexFunc(list.Items, func(n corev1.Node) bool { return n.Name == name })
+		if idx < 0 {
+			kn.Error = "node not registered"
+			continue
+		}
+
+		k8sNode := list.Items[idx]
+		kn.Ready = nodeReady(&k8sNode)
+		kn.Version.Running = strings.TrimPrefix(k8sNode.Status.NodeInfo.KubeletVersion, "v")
+	}
+}
+
+func isRunning(node *topf.Node) bool {
+	return node.Error == nil && node.MachineStatus.Stage == runtime.MachineStageRunning
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// nodeProblems records the problems of a node
+func nodeProblems(n *Node, status *Status) {
+	if n.Error != "" {
+		status.problem("node %s: %s", n.Host, n.Error)
+		return
+	}
+
+	if !n.Ready {
+		status.problem("node %s: not ready (stage %s)", n.Host, n.Stage)
+	}
+
+	if n.Talos.Drifted() {
+		status.problem("node %s: runs Talos %s instead of %s", n.Host, n.Talos.Running, n.Talos.Desired)
+	}
+
+	if n.Schematic.Drifted() {
+		status.problem("node %s: runs schematic %s instead of %s", n.Host, n.Schematic.Running, n.Schematic.Desired)
+	}
+
+	switch {
+	case n.Config == nil:
+	case n.Config.Error != "":
+		status.problem("node %s: config drift check failed: %s", n.Host, n.Config.Error)
+	case n.Config.Drifted:
+		status.problem("node %s: config drifted, %s", n.Host, n.Config.Changes())
+	}
+
+	switch kn := n.Kubernetes; {
+	case kn == nil:
+	case kn.Error != "":
+		status.problem("node %s: kubernetes: %s", n.Host, kn.Error)
+	case !kn.Ready:
+		status.problem("node %s: kubernetes node %s not ready", n.Host, kn.Name)
+	case kn.Version.Drifted():
+		status.problem("node %s: runs kubelet %s instead of %s", n.Host, kn.Version.Running, kn.Version.Desired)
+	}
+}
+
+// certificates returns the expiry of the CA certificates of the secrets
+// bundle, recording those expiring within CertificateExpiryThreshold
+func certificates(bundle *secrets.Bundle, status *Status) []Certificate {
+	if bundle.Certs == nil {
+		return nil
+	}
+
+	var result []Certificate
+
+	add := func(name string, getCert func() (*x509.Certificate, error)) {
+		cert, err := getCert()
+		if err != nil {
+			status.problem("certificate %s: %v", name, err)
+			return
+		}
+
+		result = append(result, Certificate{Name: name, NotAfter: cert.NotAfter})
+
+		if time.Until(cert.NotAfter) < CertificateExpiryThreshold {
+			status.problem("certificate %s: expires %s", name, cert.NotAfter.Format(time.RFC3339))
+		}
+	}
+
+	// the service account key has no certificate
+	if c := bundle.Certs.OS; c != nil {
+		add("talos-ca", c.GetCert)
+	}
+
+	if c := bundle.Certs.Etcd; c != nil {
+		add("etcd-ca", c.GetCert)
+	}
+
+	if c := bundle.Certs.K8s; c != nil {
+		add("kubernetes-ca", c.GetCert)
+	}
+
+	if c := bundle.Certs.K8sAggregator; c != nil {
+		add("kubernetes-aggregator-ca", c.GetCert)
+	}
+
+	return result
+}
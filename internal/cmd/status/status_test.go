@@ -0,0 +1,75 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package status
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/drift"
+	"github.com/siderolabs/talos/pkg/machinery/config/generate/secrets"
+)
+
+func TestNodeProblems(t *testing.T) {
+	st := &Status{}
+
+	nodeProblems(&Node{
+		Host:       "cp1",
+		Ready:      true,
+		Talos:      drift.Drift{Running: "1.12.0", Desired: "1.13.0"},
+		Schematic:  drift.Drift{Running: "abc", Desired: "abc"},
+		Config:     &drift.Config{Drifted: true, Mode: "NO_REBOOT", Documents: []string{"v1alpha1", "ResolverConfig"}},
+		Kubernetes: &Kubernetes{Name: "cp1", Ready: false},
+	}, st)
+
+	got := strings.Join(st.Problems, "\n")
+	for _, want := range []string{"Talos 1.12.0 instead of 1.13.0", "config drifted, v1alpha1, ResolverConfig changed (NO_REBOOT)", "kubernetes node cp1 not ready"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, got)
+		}
+	}
+
+	if len(st.Problems) != 3 {
+		t.Errorf("expected 3 problems, got %d", len(st.Problems))
+	}
+
+	st = &Status{}
+	nodeProblems(&Node{Host: "w1", Ready: true, Config: &drift.Config{}, Kubernetes: &Kubernetes{Ready: true}}, st)
+
+	if st.Degraded() {
+		t.Errorf("expected no problems, got %v", st.Problems)
+	}
+}
+
+func TestCertificates(t *testing.T) {
+	bundle, err := secrets.NewBundle(secrets.NewFixedClock(time.Now()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := &Status{}
+
+	certs := certificates(bundle, st)
+	if len(certs) != 4 {
+		t.Errorf("expected 4 CA certificates, got %d", len(certs))
+	}
+
+	if st.Degraded() {
+		t.Errorf("expected fresh CAs to pass, got %v", st.Problems)
+	}
+
+	// CAs generated 10 years ago expire within the threshold
+	bundle, err = secrets.NewBundle(secrets.NewFixedClock(time.Now().AddDate(-10, 0, 1)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st = &Status{}
+	certificates(bundle, st)
+
+	if len(st.Problems) != 4 {
+		t.Errorf("expected 4 expiring CAs, got %v", st.Problems)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// StateFileName is the name of the upgrade state file, stored next to topf.yaml
+const StateFileName = ".topf-upgrade-state.yaml"
+
+// phase is the last completed step of a node upgrade. Once the node is
+// uncordoned (or stabilized, without drain) the upgrade is complete and the
+// node is removed from the state.
+type phase string
+
+const (
+	phaseNone       phase = ""
+	phasePulled     phase = "pulled"
+	phaseInstalled  phase = "installed"
+	phaseDrained    phase = "drained"
+	phaseRebooted   phase = "rebooted"
+	phaseStabilized phase = "stabilized"
+)
+
+// phases lists all phases in the order they are completed
+var phases = []phase{phaseNone, phasePulled, phaseInstalled, phaseDrained, phaseRebooted, phaseStabilized} //nolint:gochecknoglobals // read-only lookup table
+
+// before reports whether p comes before other in the upgrade sequence.
+// Unknown phases come before all others, so the upgrade starts over.
+func (p phase) before(other phase) bool {
+	return slices.Index(phases, p) < slices.Index(phases, other)
+}
+
+// nodeState is the recorded upgrade progress of a single node
+type nodeState struct {
+	Phase       phase     `yaml:"phase"`
+	Installer   string    `yaml:"installer"`
+	K8sNodeName string    `yaml:"k8sNodeName,omitempty"`
+	Cordoned    bool      `yaml:"cordoned,omitempty"`
+	UpdatedAt   time.Time `yaml:"updatedAt"`
+}
+
+// state is the upgrade progress of all nodes, persisted after every phase so
+// that an interrupted upgrade can be resumed. Nodes are removed once their
+// upgrade completed, and the file is removed once no node is left. A nil
+// *state records nothing (e.g. in dry-run mode).
+type state struct {
+	mu   sync.Mutex
+	path string
+
+	Nodes map[string]*nodeState `yaml:"nodes"`
+}
+
+// loadState reads the state file at path. A missing file yields an empty state.
+func loadState(path string) (*state, error) {
+	s := &state{path: path, Nodes: map[string]*nodeState{}}
+
+	content, err := os.ReadFile(path) //nolint:gosec // the state file lives next to topf.yaml
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upgrade state: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("failed to decode upgrade state %s: %w", path, err)
+	}
+
+	if s.Nodes == nil {
+		s.Nodes = map[string]*nodeState{}
+	}
+
+	return s, nil
+}
+
+// empty reports whether no node upgrade is in progress
+func (s *state) empty() bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.Nodes) == 0
+}
+
+// get returns a copy of the recorded state of a node
+func (s *state) get(host string) (nodeState, bool) {
+	if s == nil {
+		return nodeState{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ns, ok := s.Nodes[host]
+	if !ok {
+		return nodeState{}, false
+	}
+
+	return *ns, true
+}
+
+// hosts returns the hosts of all recorded nodes, sorted
+func (s *state) hosts() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(s.Nodes))
+}
+
+// update calls fn with the state of a node, creating it if needed, and
+// persists the state file.
+func (s *state) update(host string, fn func(ns *nodeState)) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ns, ok := s.Nodes[host]
+	if !ok {
+		ns = &nodeState{}
+		s.Nodes[host] = ns
+	}
+
+	fn(ns)
+	ns.UpdatedAt = time.Now().UTC()
+
+	return s.save()
+}
+
+// record sets the last completed phase of a node and persists the state file.
+func (s *state) record(host string, p phase) error {
+	return s.update(host, func(ns *nodeState) { ns.Phase = p })
+}
+
+// done removes a node whose upgrade completed and persists the state file.
+func (s *state) done(host string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.Nodes, host)
+
+	return s.save()
+}
+
+// save writes the state file atomically, or removes it when no node is left.
+// Must be called with s.mu held.
+func (s *state) save() error {
+	if len(s.Nodes) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove upgrade state: %w", err)
+		}
+
+		return nil
+	}
+
+	content, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode upgrade state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write upgrade state: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close() //nolint:errcheck,gosec // the write error takes precedence
+		return fmt.Errorf("failed to write upgrade state: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write upgrade state: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write upgrade state: %w", err)
+	}
+
+	return nil
+}
+
+// resumePhase determines the phase a recorded node upgrade continues from,
+// given whether the node already runs the target version and schematic.
+//
+// A node running the target version has rebooted into it, whatever the
+// recorded phase says (the process may have died before recording it), so it
+// continues with stabilization. A node not running it continues after the
+// recorded phase, unless it was already recorded as rebooted: then the reboot
+// did not bring up the new version (e.g. a rollback), and the upgrade starts
+// over.
+func resumePhase(recorded phase, upgraded bool) phase {
+	if upgraded {
+		if recorded.before(phaseRebooted) {
+			return phaseRebooted
+		}
+
+		return recorded
+	}
+
+	if recorded.before(phaseRebooted) {
+		return recorded
+	}
+
+	return phaseNone
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumePhase(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded phase
+		upgraded bool
+		expected phase
+	}{
+		{"nothing done", phaseNone, false, phaseNone},
+		{"pulled", phasePulled, false, phasePulled},
+		{"drain interrupted", phaseInstalled, false, phaseInstalled},
+		{"reboot not recorded", phaseDrained, true, phaseRebooted},
+		{"rebooting", phaseRebooted, true, phaseRebooted},
+		{"stabilized", phaseStabilized, true, phaseStabilized},
+		{"rolled back", phaseRebooted, false, phaseNone},
+		{"unknown phase", phase("bogus"), false, phase("bogus")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumePhase(tt.recorded, tt.upgraded); got != tt.expected {
+				t.Errorf("resumePhase(%q, %v) = %q, want %q", tt.recorded, tt.upgraded, got, tt.expected)
+			}
+		})
+	}
+
+	// an unknown phase comes before all others, so every step is run again
+	if !phase("bogus").before(phasePulled) {
+		t.Error("unknown phase should come before pulled")
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+
+	st, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !st.empty() {
+		t.Fatal("missing state file should yield an empty state")
+	}
+
+	if err := st.update("node1", func(ns *nodeState) {
+		ns.Installer = "factory.talos.dev/installer/abc:v1.13.0"
+		ns.K8sNodeName = "node1"
+		ns.Cordoned = true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.record("node1", phaseInstalled); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.record("node2", phasePulled); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns, ok := loaded.get("node1")
+	if !ok {
+		t.Fatal("node1 not found in loaded state")
+	}
+
+	if ns.Phase != phaseInstalled || !ns.Cordoned || ns.K8sNodeName != "node1" || ns.Installer != "factory.talos.dev/installer/abc:v1.13.0" {
+		t.Errorf("unexpected state for node1: %+v", ns)
+	}
+
+	if hosts := loaded.hosts(); len(hosts) != 2 || hosts[0] != "node1" || hosts[1] != "node2" {
+		t.Errorf("unexpected hosts: %v", hosts)
+	}
+
+	for _, host := range []string{"node1", "node2"} {
+		if err := loaded.done(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("state file should be removed once all upgrades completed, got %v", err)
+	}
+}
+
+func TestRecordedKubernetesNodeName(t *testing.T) {
+	// the recorded name is used without asking the node, whose Talos API may
+	// not be back yet
+	name, err := kubernetesNodeName(context.Background(), nil, nodeState{K8sNodeName: "worker-1.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name != "worker-1.example.com" {
+		t.Errorf("got %q, want the recorded name", name)
+	}
+}
+
+func TestNilState(t *testing.T) {
+	var st *state
+
+	if err := st.record("node1", phasePulled); err != nil {
+		t.Fatal(err)
+	}
+
+	if !st.empty() {
+		t.Error("nil state should be empty")
+	}
+
+	if _, ok := st.get("node1"); ok {
+		t.Error("nil state should record nothing")
+	}
+}
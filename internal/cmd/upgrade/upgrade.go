@@ -10,15 +10,22 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"regexp"
 	"slices"
-	"sync"
 	"time"
 
+	"github.com/postfinance/topf/internal/control"
+	"github.com/postfinance/topf/internal/etcd"
+	"github.com/postfinance/topf/internal/healthgate"
+	"github.com/postfinance/topf/internal/hooks"
 	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/k8s"
 	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/report"
 	"github.com/postfinance/topf/internal/topf"
-	taloskubeclient "github.com/siderolabs/talos/cmd/talosctl/pkg/talos/kubeclient"
+	"github.com/postfinance/topf/pkg/config"
 	talosnodedrain "github.com/siderolabs/talos/cmd/talosctl/pkg/talos/nodedrain"
 	"github.com/siderolabs/talos/pkg/machinery/api/common"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
@@ -26,10 +33,6 @@ import (
 	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
 	"github.com/siderolabs/talos/pkg/reporter"
 	"google.golang.org/grpc/codes"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/kubectl/pkg/drain"
 
 	"github.com/blang/semver/v4"
 )
@@ -62,10 +65,41 @@ type Options struct {
 	// MaxParallel controls how many worker nodes are upgraded concurrently.
 	// Control-plane nodes are always upgraded one at a time.
 	MaxParallel nodepool.MaxParallel
+
+	// Waves splits the worker nodes into batches that are upgraded one
+	// after the other, and limits the failure domains in flight.
+	Waves nodepool.Waves
+
+	// Control pauses between nodes and waves for operator commands, if set.
+	Control *control.Controller
+
+	// Resume continues the upgrades interrupted by a previous run, as
+	// recorded in the upgrade state file, before upgrading other nodes.
+	Resume bool
+
+	// EtcdBackupDir is the directory an etcd snapshot is written to before
+	// the first control-plane node is upgraded. Empty disables the backup.
+	EtcdBackupDir string
+
+	// AllowQuorumLoss upgrades control-plane nodes even if etcd loses
+	// quorum while they reboot.
+	AllowQuorumLoss bool
+
+	// HealthGates are waited for after each node was upgraded, if set.
+	HealthGates *healthgate.Runner
+
+	// Report records the per-node outcome, if set.
+	Report *report.Report
+
+	// Progress tracks the current phase of each node, if set.
+	Progress *progress.Tracker
+
+	// Hooks are run before and after the command and each node, if set.
+	Hooks *hooks.Runner
 }
 
 // Execute performs the Talos OS upgrades for all nodes in the cluster
-func Execute(ctx context.Context, t topf.Topf, opts Options) error {
+func Execute(ctx context.Context, t topf.Topf, opts Options) (err error) {
 	logger := t.Logger().With("command", "upgrade")
 
 	// Gather node information
@@ -74,10 +108,37 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		return err
 	}
 
-	if err := preChecks(logger, nodes); err != nil {
+	opts.Report.AddNodes(nodes)
+	opts.Progress.AddNodes(nodes)
+
+	statePath := filepath.Join(t.ConfigDir(), StateFileName)
+
+	st, err := loadState(statePath)
+	if err != nil {
 		return err
 	}
 
+	if !st.empty() && !opts.Resume {
+		if !opts.DryRun {
+			return fmt.Errorf("found the state of an interrupted upgrade in %s: run with --resume to continue it, or remove the file to start over", statePath)
+		}
+
+		logger.Warn("found the state of an interrupted upgrade, run with --resume to continue it", "state", statePath)
+	}
+
+	if err := preChecks(logger, nodes, opts); err != nil {
+		return err
+	}
+
+	var resumed []resumeJob
+
+	if opts.Resume {
+		resumed, nodes, err = planResume(t, logger, nodes, st, opts)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Plan phase: determine which nodes require an upgrade. Interactive
 	// confirmations happen here, sequentially, before any concurrent work.
 	worklist, upgradeRequired, err := plan(t, logger, nodes, opts)
@@ -86,7 +147,7 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 	}
 
 	if opts.DryRun {
-		if upgradeRequired {
+		if upgradeRequired || len(resumed) > 0 {
 			return topf.ErrDryRunChangesDetected
 		}
 
@@ -95,11 +156,41 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 
 	controlPlane, workers := nodepool.PartitionByRole(worklist)
 
+	if targets := append(resumedNodes(resumed), worklist...); len(targets) > 0 {
+		if err := opts.Hooks.PreCluster(ctx, targets, logger); err != nil {
+			return err
+		}
+
+		defer func() {
+			if hookErr := opts.Hooks.PostCluster(ctx, targets, err, logger); hookErr != nil {
+				err = errors.Join(err, hookErr)
+			}
+		}()
+	}
+
+	if opts.EtcdBackupDir != "" && (len(controlPlane) > 0 || slices.ContainsFunc(resumed, func(job resumeJob) bool { return job.node.Node.Role == config.RoleControlPlane })) {
+		if err := etcd.Backup(ctx, t, opts.EtcdBackupDir, logger); err != nil {
+			return err
+		}
+	}
+
+	// Interrupted upgrades are resumed first and one at a time: they may
+	// have left nodes cordoned or control-plane nodes rebooting.
+	for _, job := range resumed {
+		if err := controlledUpgrade(ctx, job.node, opts, logger.With(job.node.Attrs()), func() error {
+			return guardedUpgrade(ctx, t, job.node, opts, st, job.upgrade, logger.With(job.node.Attrs()))
+		}); err != nil {
+			return err
+		}
+	}
+
 	// Control-plane nodes are upgraded strictly one at a time to preserve etcd
 	// quorum; this also satisfies "control-plane upgrades cannot be scheduled
 	// concurrently".
 	for _, node := range controlPlane {
-		if err := upgradeNode(ctx, t, node, opts, logger.With(node.Attrs())); err != nil {
+		if err := controlledUpgrade(ctx, node, opts, logger.With(node.Attrs()), func() error {
+			return guardedUpgrade(ctx, t, node, opts, st, freshUpgrade(node), logger.With(node.Attrs()))
+		}); err != nil {
 			return err
 		}
 	}
@@ -110,18 +201,96 @@ func Execute(ctx context.Context, t topf.Topf, opts Options) error {
 		concurrency := opts.MaxParallel.Resolve(len(nodes))
 		logger.Info("upgrading worker nodes", "count", len(workers), "concurrency", concurrency)
 
-		return nodepool.RunConcurrent(ctx, workers, concurrency,
+		waves := opts.Waves
+		waves.Control = opts.Control
+
+		return nodepool.RunWaves(ctx, workers, waves, concurrency,
 			func(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
-				return upgradeNode(ctx, t, node, opts, logger)
+				return controlledUpgrade(ctx, node, opts, logger, func() error {
+					return upgradeNode(ctx, t, node, opts, st, freshUpgrade(node), logger)
+				})
 			}, logger)
 	}
 
 	return nil
 }
 
+// guardedUpgrade upgrades a node after checking, for control-plane nodes,
+// that etcd keeps quorum while it reboots. The check runs right before each
+// node, as the previous upgrade may have left a member unhealthy.
+func guardedUpgrade(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, st *state, u nodeUpgrade, logger *slog.Logger) error {
+	if node.Node.Role == config.RoleControlPlane {
+		opts.Progress.Set(node, progress.PhasePreflight)
+
+		if err := etcd.GuardQuorum(ctx, t, []string{node.Node.Host}, false, opts.AllowQuorumLoss, logger); err != nil {
+			return err
+		}
+	}
+
+	return upgradeNode(ctx, t, node, opts, st, u, logger)
+}
+
+// controlledUpgrade runs upgrade once the operator and the pre-node hooks
+// let the node start, runs the post-node hooks and records the outcome. A
+// skipped node is recorded as skipped.
+func controlledUpgrade(ctx context.Context, node *topf.Node, opts Options, logger *slog.Logger, upgrade func() error) error {
+	err := opts.Control.BeforeNode(ctx, node.Node.Host)
+	if err == nil {
+		err = opts.Hooks.PreNode(ctx, node, logger)
+	}
+
+	if errors.Is(err, control.ErrSkipped) || errors.Is(err, hooks.ErrSkipNode) {
+		logger.Warn("skipping node", "reason", err)
+		opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusSkipped })
+		opts.Progress.Set(node, progress.PhaseSkipped)
+
+		return nil
+	}
+
+	if errors.Is(err, control.ErrAborted) {
+		return err
+	}
+
+	if err == nil {
+		err = upgrade()
+
+		if hookErr := opts.Hooks.PostNode(ctx, node, err, logger); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+
+	return recordUpgrade(node, opts, err)
+}
+
+// resumedNodes returns the nodes of interrupted upgrades
+func resumedNodes(resumed []resumeJob) []*topf.Node {
+	nodes := make([]*topf.Node, len(resumed))
+	for i, job := range resumed {
+		nodes[i] = job.node
+	}
+
+	return nodes
+}
+
+// recordUpgrade records the outcome of a node upgrade in the report and the
+// progress, and returns err unchanged.
+func recordUpgrade(node *topf.Node, opts Options, err error) error {
+	opts.Progress.Finish(node, err)
+	opts.Report.Update(node, func(n *report.Node) {
+		if err != nil {
+			n.Fail(err)
+			return
+		}
+
+		n.Status = report.StatusSucceeded
+	})
+
+	return err
+}
+
 // preChecks verifies that every node is reachable and running before any
 // upgrade is attempted, reporting all problems at once.
-func preChecks(logger *slog.Logger, nodes []*topf.Node) error {
+func preChecks(logger *slog.Logger, nodes []*topf.Node, opts Options) error {
 	abort := false
 
 	for _, node := range nodes {
@@ -129,6 +298,7 @@ func preChecks(logger *slog.Logger, nodes []*topf.Node) error {
 
 		if node.Error != nil {
 			logger.Error("node pre-checks", "error", node.Error)
+			failPreflight(opts, node, node.Error)
 
 			abort = true
 
@@ -138,10 +308,14 @@ func preChecks(logger *slog.Logger, nodes []*topf.Node) error {
 		if !slices.Contains([]runtime.MachineStage{runtime.MachineStageRunning}, node.MachineStatus.Stage) {
 			logger.Error("node must be 'running' for upgrade", "stage", node.MachineStatus.Stage.String())
 
+			failPreflight(opts, node, fmt.Errorf("node must be 'running' for upgrade, stage is %s", node.MachineStatus.Stage.String()))
+
 			abort = true
 
 			continue
 		}
+
+		opts.Report.Update(node, func(n *report.Node) { n.Preflight = report.PreflightPassed })
 	}
 
 	if abort {
@@ -151,6 +325,15 @@ func preChecks(logger *slog.Logger, nodes []*topf.Node) error {
 	return nil
 }
 
+// failPreflight records a failed pre-flight check, which aborts the upgrade.
+func failPreflight(opts Options, node *topf.Node, err error) {
+	opts.Report.Update(node, func(n *report.Node) {
+		n.FailPreflight(err)
+		n.Fail(err)
+	})
+	opts.Progress.Finish(node, err)
+}
+
 // plan determines which nodes require an upgrade, performing interactive
 // confirmations sequentially before any concurrent work.
 func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (worklist []*topf.Node, upgradeRequired bool, err error) {
@@ -167,6 +350,9 @@ func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (w
 		nodeNeedsUpgrade := node.RunningVersion() != talosVersion || node.RunningSchematic() != schematic
 		if !nodeNeedsUpgrade {
 			logger.Info("no upgrade required")
+			opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusUnchanged })
+			opts.Progress.Set(node, progress.PhaseUnchanged)
+
 			continue
 		}
 
@@ -179,8 +365,16 @@ func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (w
 
 		upgradeRequired = true
 
+		opts.Report.Update(node, func(n *report.Node) {
+			n.Changes = upgradeChanges(node, talosVersion, schematic)
+			n.Mode = opts.RebootMode.String()
+		})
+
 		// in dry-run mode, skip the actual upgrade
 		if opts.DryRun {
+			opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusChangesDetected })
+			opts.Progress.Finish(node, nil)
+
 			continue
 		}
 
@@ -188,6 +382,9 @@ func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (w
 		if t.Confirm() {
 			if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to upgrade node %s with installer %s? This will reboot the node.", node.Node.Host, installerImage)) == 'n' {
 				logger.Info("skipping upgrade")
+				opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusSkipped })
+				opts.Progress.Set(node, progress.PhaseSkipped)
+
 				continue
 			}
 		}
@@ -198,8 +395,107 @@ func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (w
 	return worklist, upgradeRequired, nil
 }
 
-// upgradeNode performs a Talos OS upgrade on a single node. It selects the
-// upgrade mechanism based on the node's running Talos version:
+// resumeJob is an interrupted node upgrade to be resumed
+type resumeJob struct {
+	node    *topf.Node
+	upgrade nodeUpgrade
+}
+
+// planResume determines the phase each node recorded in the upgrade state
+// resumes from, performing interactive confirmations. It returns the nodes
+// to resume and the remaining nodes, which go through the regular plan.
+func planResume(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, st *state, opts Options) (resumed []resumeJob, remaining []*topf.Node, err error) {
+	if st.empty() {
+		logger.Info("no interrupted upgrade to resume")
+		return nil, nodes, nil
+	}
+
+	for _, node := range nodes {
+		recorded, ok := st.get(node.Node.Host)
+		if !ok {
+			remaining = append(remaining, node)
+			continue
+		}
+
+		logger := logger.With(node.Attrs())
+
+		schematic, talosVersion, err := extractSchematicAndVersion(recorded.Installer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't extract schematic and version from recorded installer image '%s': %w", recorded.Installer, err)
+		}
+
+		upgraded := node.RunningVersion() == talosVersion && node.RunningSchematic() == schematic
+		from := resumePhase(recorded.Phase, upgraded)
+
+		logger.Info("resuming interrupted upgrade",
+			"recorded_phase", recorded.Phase,
+			"resume_after", from,
+			"cordoned", recorded.Cordoned,
+			"installer", recorded.Installer)
+
+		opts.Report.Update(node, func(n *report.Node) {
+			n.Changes = upgradeChanges(node, talosVersion, schematic)
+			n.Mode = opts.RebootMode.String()
+		})
+
+		if opts.DryRun {
+			opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusChangesDetected })
+			opts.Progress.Finish(node, nil)
+
+			continue
+		}
+
+		if t.Confirm() {
+			if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to resume the upgrade of node %s with installer %s? This may reboot the node.", node.Node.Host, recorded.Installer)) == 'n' {
+				logger.Info("skipping resume")
+				opts.Report.Update(node, func(n *report.Node) { n.Status = report.StatusSkipped })
+				opts.Progress.Set(node, progress.PhaseSkipped)
+
+				continue
+			}
+		}
+
+		resumed = append(resumed, resumeJob{node: node, upgrade: nodeUpgrade{installer: recorded.Installer, from: from}})
+	}
+
+	for _, host := range st.hosts() {
+		if !slices.ContainsFunc(nodes, func(n *topf.Node) bool { return n.Node.Host == host }) {
+			logger.Warn("interrupted upgrade of node not selected, keeping it in the state", "host", host)
+		}
+	}
+
+	return resumed, remaining, nil
+}
+
+// upgradeChanges lists the version and schematic changes of a node upgrade.
+func upgradeChanges(node *topf.Node, talosVersion, schematic string) []report.Change {
+	var changes []report.Change
+
+	if node.RunningVersion() != talosVersion {
+		changes = append(changes, report.Change{Target: "talosVersion", From: node.RunningVersion(), To: talosVersion})
+	}
+
+	if node.RunningSchematic() != schematic {
+		changes = append(changes, report.Change{Target: "schematic", From: node.RunningSchematic(), To: schematic})
+	}
+
+	return changes
+}
+
+// nodeUpgrade describes the upgrade of a single node: the installer image to
+// upgrade to, and the last phase already completed by an interrupted run.
+type nodeUpgrade struct {
+	installer string
+	from      phase
+}
+
+// freshUpgrade returns the upgrade of a node to its configured installer image.
+func freshUpgrade(node *topf.Node) nodeUpgrade {
+	return nodeUpgrade{installer: node.ConfigProvider().Machine().Install().Image()}
+}
+
+// upgradeNode performs a Talos OS upgrade on a single node. It selects
+// the upgrade mechanism based on the node's running Talos version:
 //
 //   - Talos >= 1.13.0: LifecycleService.Upgrade streaming RPC (the modern
 //     flow), which installs artifacts, then a separate Reboot, with optional
@@ -208,15 +504,25 @@ func plan(t topf.Topf, logger *slog.Logger, nodes []*topf.Node, opts Options) (w
 //     and reboots in a single call). The LifecycleService.Upgrade RPC was
 //     introduced in v1.13.0 and returns codes.Unimplemented on older nodes.
 //
+// Every completed phase is recorded in st, so that an interrupted upgrade can
+// be resumed; phases up to u.from are skipped.
+//
 // The provided logger is expected to already carry the node's attributes.
-func upgradeNode(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, logger *slog.Logger) error {
+func upgradeNode(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, st *state, u nodeUpgrade, logger *slog.Logger) error {
+	if err := st.update(node.Node.Host, func(ns *nodeState) {
+		ns.Installer = u.installer
+		ns.Phase = u.from
+	}); err != nil {
+		return err
+	}
+
 	if supportsLifecycleUpgrade(node.RunningVersion()) {
-		return upgradeNodeLifecycle(ctx, t, node, opts, logger)
+		return upgradeNodeLifecycle(ctx, t, node, opts, st, u, logger)
 	}
 
 	logger.Info("node running Talos < 1.13, using legacy upgrade RPC", "running_version", node.RunningVersion())
 
-	return upgradeNodeLegacy(ctx, t, node, opts, logger)
+	return upgradeNodeLegacy(ctx, t, node, opts, st, u, logger)
 }
 
 // supportsLifecycleUpgrade reports whether the node's running Talos version
@@ -248,9 +554,9 @@ func supportsLifecycleUpgrade(runningVersion string) bool {
 //  4. Drain the Kubernetes node (cordon + evict pods) if draining is enabled.
 //     If the drain fails, the upgrade aborts: the node is left cordoned with
 //     the new artifacts installed but not rebooted. The error propagates to
-//     the caller (RunConcurrent), which stops scheduling new upgrades but
-//     lets in-flight ones finish. The node must be uncordoned and rebooted
-//     manually to recover.
+//     the caller (RunWaves), which stops scheduling new upgrades (unless
+//     the failure is tolerated in the wave) but lets in-flight ones finish.
+//     Run `topf upgrade --resume` to recover.
 //  5. Issue a Reboot with the configured reboot mode. A gRPC Unavailable or
 //     Canceled error from Reboot is treated as success: the node begins
 //     shutting down before the RPC response reaches us.
@@ -261,6 +567,11 @@ func supportsLifecycleUpgrade(runningVersion string) bool {
 //     is re-fetched (rather than reused from step 4) because the reboot may
 //     rotate credentials or invalidate the in-memory kubeconfig, and the
 //     control-plane node we proxy through may itself have been upgraded.
+//     A node cordoned by an interrupted run is uncordoned even when
+//     draining is disabled.
+//  9. Wait for the health gates configured in topf.yaml to pass.
+//
+// Steps 2 to 7 are skipped when u.from records them as already completed.
 //
 // The kubeconfig for drain/uncordon operations is fetched via a control-plane
 // node client (t.ControlPlaneClient), because the Kubeconfig RPC is only
@@ -268,8 +579,8 @@ func supportsLifecycleUpgrade(runningVersion string) bool {
 // client, as the Nodename resource is available on all nodes.
 //
 // The provided logger is expected to already carry the node's attributes.
-func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, logger *slog.Logger) error {
-	installerImage := node.ConfigProvider().Machine().Install().Image()
+func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opts Options, st *state, u nodeUpgrade, logger *slog.Logger) error {
+	host := node.Node.Host
 
 	nodeClient, err := node.Client(ctx)
 	if err != nil {
@@ -277,44 +588,86 @@ func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opt
 	}
 	defer nodeClient.Close()
 
+	recorded, _ := st.get(host)
+	uncordon := opts.Drain || recorded.Cordoned
+
 	// Resolve the Kubernetes node name up front, so that a drain failure
 	// can't be caused by a reason that was knowable before the upgrade.
 	k8sNodeName := ""
 
-	if opts.Drain {
-		k8sNodeName, err = talosnodedrain.GetKubernetesNodeName(ctx, nodeClient)
+	if uncordon {
+		k8sNodeName, err = kubernetesNodeName(ctx, nodeClient, recorded)
 		if err != nil {
-			return fmt.Errorf("resolving kubernetes node name: %w", err)
+			return err
 		}
 	}
 
 	containerdInstance := systemContainerdInstance()
 
-	if err := pullInstallerImage(ctx, nodeClient, containerdInstance, installerImage, logger); err != nil {
-		return fmt.Errorf("pulling installer image: %w", err)
+	if u.from.before(phasePulled) {
+		opts.Progress.Set(node, progress.PhasePulling)
+
+		if err := pullInstallerImage(ctx, nodeClient, containerdInstance, u.installer, logger); err != nil {
+			return fmt.Errorf("pulling installer image: %w", err)
+		}
+
+		if err := st.record(host, phasePulled); err != nil {
+			return err
+		}
 	}
 
-	if err := runUpgrade(ctx, nodeClient, containerdInstance, installerImage, logger); err != nil {
-		return fmt.Errorf("upgrade: %w", err)
+	if u.from.before(phaseInstalled) {
+		opts.Progress.Set(node, progress.PhaseInstalling)
+
+		if err := runUpgrade(ctx, nodeClient, containerdInstance, u.installer, logger); err != nil {
+			return fmt.Errorf("upgrade: %w", err)
+		}
+
+		if err := st.record(host, phaseInstalled); err != nil {
+			return err
+		}
 	}
 
 	// Drain the node after the upgrade artifacts are installed but before
 	// the reboot, so pods are evicted gracefully.
-	if opts.Drain {
+	if opts.Drain && u.from.before(phaseDrained) {
+		opts.Progress.Set(node, progress.PhaseDraining)
+
+		// record the cordon before it happens: a failed drain leaves the node cordoned
+		if err := st.update(host, func(ns *nodeState) {
+			ns.K8sNodeName = k8sNodeName
+			ns.Cordoned = true
+		}); err != nil {
+			return err
+		}
+
 		if err := drainNode(ctx, t, opts, k8sNodeName, logger); err != nil {
 			return err
 		}
+
+		if err := st.record(host, phaseDrained); err != nil {
+			return err
+		}
 	}
 
-	logger.Info("upgrade artifacts installed, rebooting node", "reboot_mode", opts.RebootMode.String())
+	if u.from.before(phaseRebooted) {
+		opts.Progress.Set(node, progress.PhaseRebooting)
+		logger.Info("upgrade artifacts installed, rebooting node", "reboot_mode", opts.RebootMode.String())
+
+		// Reboot returns once the reboot is initiated. If the node starts
+		// shutting down before the gRPC response arrives, the stream is torn
+		// down and we get Unavailable or Canceled. Both are expected here.
+		if err := nodeClient.Reboot(ctx, client.WithRebootMode(opts.RebootMode)); err != nil &&
+			client.StatusCode(err) != codes.Unavailable &&
+			!errors.Is(err, context.Canceled) {
+			return fmt.Errorf("reboot: %w", err)
+		}
+
+		if err := st.record(host, phaseRebooted); err != nil {
+			return err
+		}
 
-	// Reboot returns once the reboot is initiated. If the node starts
-	// shutting down before the gRPC response arrives, the stream is torn
-	// down and we get Unavailable or Canceled. Both are expected here.
-	if err := nodeClient.Reboot(ctx, client.WithRebootMode(opts.RebootMode)); err != nil &&
-		client.StatusCode(err) != codes.Unavailable &&
-		!errors.Is(err, context.Canceled) {
-		return fmt.Errorf("reboot: %w", err)
+		logger.Info("reboot initiated")
 	}
 
 	// The node is rebooting; the per-node client is dead. Close it now
@@ -323,18 +676,24 @@ func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opt
 		logger.Debug("closing per-node client after reboot", "error", cerr)
 	}
 
-	logger.Info("reboot initiated")
+	if u.from.before(phaseStabilized) {
+		if err = stabilize(ctx, node, opts, logger); err != nil {
+			return err
+		}
 
-	if err = node.Stabilize(ctx, logger, time.Second*30); err != nil {
-		return fmt.Errorf("node didn't stabilize: %w", err)
+		if err := st.record(host, phaseStabilized); err != nil {
+			return err
+		}
 	}
 
 	// After the node has stabilized, uncordon it so that the Kubernetes
 	// scheduler can place pods on it again. The clientset is re-fetched
 	// because the reboot may have rotated credentials or invalidated the
 	// in-memory kubeconfig obtained before the reboot.
-	if opts.Drain {
-		uncordonClientset, err := newK8sClientset(ctx, t, logger)
+	if uncordon {
+		opts.Progress.Set(node, progress.PhaseUncordoning)
+
+		uncordonClientset, err := k8s.NewClientset(ctx, t, logger)
 		if err != nil {
 			return fmt.Errorf("creating kubernetes client for uncordon: %w", err)
 		}
@@ -350,7 +709,13 @@ func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opt
 		logger.Info("kubernetes node uncordoned", "k8s_node", k8sNodeName)
 	}
 
-	return nil
+	opts.Progress.Set(node, progress.PhaseHealthGates)
+
+	if err := opts.HealthGates.Wait(ctx, node, logger); err != nil {
+		return err
+	}
+
+	return st.done(host)
 }
 
 // upgradeNodeLegacy performs a Talos OS upgrade on a node running Talos < 1.13
@@ -366,33 +731,63 @@ func upgradeNodeLifecycle(ctx context.Context, t topf.Topf, node *topf.Node, opt
 // The provided logger is expected to already carry the node's attributes.
 //
 //nolint:staticcheck // the non-deprecated replacement (LifecycleClient.Upgrade) requires Talos >= 1.13
-func upgradeNodeLegacy(ctx context.Context, _ topf.Topf, node *topf.Node, opts Options, logger *slog.Logger) error {
-	installerImage := node.ConfigProvider().Machine().Install().Image()
+func upgradeNodeLegacy(ctx context.Context, _ topf.Topf, node *topf.Node, opts Options, st *state, u nodeUpgrade, logger *slog.Logger) error {
+	host := node.Node.Host
 
-	nodeClient, err := node.Client(ctx)
-	if err != nil {
-		return err
+	if u.from.before(phaseRebooted) {
+		nodeClient, err := node.Client(ctx)
+		if err != nil {
+			return err
+		}
+		defer nodeClient.Close()
+
+		opts.Progress.Set(node, progress.PhaseInstalling)
+		logger.Info("issuing legacy upgrade", "installer", u.installer, "force", opts.Force)
+
+		_, err = nodeClient.MachineClient.Upgrade(ctx, &machine.UpgradeRequest{
+			Image:      u.installer,
+			Preserve:   true, // talos default since v1.8+
+			Force:      opts.Force,
+			RebootMode: toLegacyRebootMode(opts.RebootMode),
+		})
+		if err != nil {
+			return fmt.Errorf("legacy upgrade: %w", err)
+		}
+
+		if err := st.record(host, phaseRebooted); err != nil {
+			return err
+		}
+
+		logger.Info("upgrade initiated")
 	}
-	defer nodeClient.Close()
 
-	logger.Info("issuing legacy upgrade", "installer", installerImage, "force", opts.Force)
+	if u.from.before(phaseStabilized) {
+		if err := stabilize(ctx, node, opts, logger); err != nil {
+			return err
+		}
+	}
 
-	_, err = nodeClient.MachineClient.Upgrade(ctx, &machine.UpgradeRequest{
-		Image:      installerImage,
-		Preserve:   true, // talos default since v1.8+
-		Force:      opts.Force,
-		RebootMode: toLegacyRebootMode(opts.RebootMode),
-	})
-	if err != nil {
-		return fmt.Errorf("legacy upgrade: %w", err)
+	opts.Progress.Set(node, progress.PhaseHealthGates)
+
+	if err := opts.HealthGates.Wait(ctx, node, logger); err != nil {
+		return err
 	}
 
-	logger.Info("upgrade initiated")
+	return st.done(host)
+}
 
-	if err = node.Stabilize(ctx, logger, time.Second*30); err != nil {
+// stabilize waits for the rebooted node to stabilize and records how long it took.
+func stabilize(ctx context.Context, node *topf.Node, opts Options, logger *slog.Logger) error {
+	opts.Progress.Set(node, progress.PhaseStabilizing)
+
+	start := time.Now()
+
+	if err := node.Stabilize(ctx, logger, time.Second*30); err != nil {
 		return fmt.Errorf("node didn't stabilize: %w", err)
 	}
 
+	opts.Report.Update(node, func(n *report.Node) { n.SetStabilization(time.Since(start)) })
+
 	return nil
 }
 
@@ -409,155 +804,31 @@ func toLegacyRebootMode(mode machine.RebootRequest_Mode) machine.UpgradeRequest_
 	}
 }
 
-// newK8sClientset obtains an in-memory Kubernetes clientset by fetching the
-// admin kubeconfig through a control-plane node's Talos API. The control-plane
-// client is closed after the kubeconfig is retrieved; the returned clientset
-// is independent of it.
-func newK8sClientset(ctx context.Context, t topf.Topf, logger *slog.Logger) (kubernetes.Interface, error) {
-	cpClient, err := t.ControlPlaneClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("creating control-plane client: %w", err)
-	}
-
-	clientset, err := taloskubeclient.FromTalosClient(ctx, cpClient)
-
-	if cerr := cpClient.Close(); cerr != nil {
-		logger.Debug("closing control-plane client", "error", cerr)
+// kubernetesNodeName returns the Kubernetes node name recorded by an
+// interrupted upgrade, such that a node cordoned by it is uncordoned under
+// the same name even if its Talos API isn't back yet. Otherwise the name is
+// resolved on the node.
+func kubernetesNodeName(ctx context.Context, nodeClient *client.Client, recorded nodeState) (string, error) {
+	if recorded.K8sNodeName != "" {
+		return recorded.K8sNodeName, nil
 	}
 
+	name, err := talosnodedrain.GetKubernetesNodeName(ctx, nodeClient)
 	if err != nil {
-		return nil, fmt.Errorf("creating kubernetes client: %w", err)
+		return "", fmt.Errorf("resolving kubernetes node name: %w", err)
 	}
 
-	return clientset, nil
+	return name, nil
 }
 
-// drainNode cordons the node, then drains its pods. If the graceful drain
-// fails and DeleteIfEvictionFails is set, retries with direct pod deletion
-// (bypassing PDBs). The node is cordoned once; the fallback does not re-cordon.
+// drainNode cordons and drains the Kubernetes node, see k8s.Drain.
 func drainNode(ctx context.Context, t topf.Topf, opts Options, k8sNodeName string, logger *slog.Logger) error {
-	clientset, err := newK8sClientset(ctx, t, logger)
+	clientset, err := k8s.NewClientset(ctx, t, logger)
 	if err != nil {
 		return fmt.Errorf("creating kubernetes client for drain: %w", err)
 	}
 
-	logger.Info("draining kubernetes node", "k8s_node", k8sNodeName)
-
-	drainCtx, cancel := context.WithTimeout(ctx, opts.DrainTimeout)
-	defer cancel()
-
-	if err := cordonNode(drainCtx, clientset, k8sNodeName, logger); err != nil {
-		return err
-	}
-
-	gracefulErr := drainPods(drainCtx, clientset, k8sNodeName, opts.DrainTimeout, false, logger)
-	if gracefulErr == nil {
-		logger.Info("kubernetes node drained", "k8s_node", k8sNodeName)
-
-		return nil
-	}
-
-	if !opts.DeleteIfEvictionFails {
-		return fmt.Errorf("draining node: %w", gracefulErr)
-	}
-
-	logger.Warn("graceful drain failed, retrying with forced pod deletion", "k8s_node", k8sNodeName, "error", gracefulErr)
-
-	forcedErr := drainPods(ctx, clientset, k8sNodeName, opts.DrainTimeout, true, logger)
-	if forcedErr != nil {
-		return fmt.Errorf("draining node: %w", errors.Join(gracefulErr, forcedErr))
-	}
-
-	logger.Info("kubernetes node drained (forced)", "k8s_node", k8sNodeName)
-
-	return nil
-}
-
-// cordonNode marks the Kubernetes node unschedulable.
-func cordonNode(ctx context.Context, clientset kubernetes.Interface, nodeName string, logger *slog.Logger) error {
-	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("getting node %q: %w", nodeName, err)
-	}
-
-	drainer := &drain.Helper{
-		Ctx:    ctx,
-		Client: clientset,
-		Out:    io.Discard,
-		ErrOut: io.Discard,
-	}
-
-	logger.Info("drain", "k8s_node", nodeName, "message", "cordoning node")
-
-	if err := drain.RunCordonOrUncordon(drainer, node, true); err != nil {
-		return fmt.Errorf("cordoning node %q: %w", nodeName, err)
-	}
-
-	logger.Info("drain", "k8s_node", nodeName, "message", "node cordoned")
-
-	return nil
-}
-
-// drainPods drains the node's pods via the kubectl drain library. Force is
-// always on (unmanaged pods deleted, emptyDir data removed). When
-// deleteIfEvictionFails is true, pods are DELETEd instead of EVICTed, bypassing
-// PDBs (kubectl drain --disable-eviction).
-func drainPods(ctx context.Context, clientset kubernetes.Interface, nodeName string, timeout time.Duration, deleteIfEvictionFails bool, logger *slog.Logger) error {
-	drainCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	action := "evicting"
-	if deleteIfEvictionFails {
-		action = "deleting"
-	}
-
-	// The drain helper fires the {Deletion,Eviction}Started callback on every
-	// retry attempt; we track announced pods in this map and report the outcome
-	// in Finished.
-	var announced sync.Map
-
-	drainer := &drain.Helper{
-		Ctx:                 drainCtx,
-		Client:              clientset,
-		Force:               true,
-		GracePeriodSeconds:  -1,
-		IgnoreAllDaemonSets: true,
-		DeleteEmptyDirData:  true,
-		DisableEviction:     deleteIfEvictionFails,
-		Timeout:             timeout,
-		Out:                 io.Discard,
-		ErrOut:              io.Discard,
-		OnPodDeletionOrEvictionStarted: func(pod *corev1.Pod, usingEviction bool) {
-			key := pod.Namespace + "/" + pod.Name
-			if _, dup := announced.LoadOrStore(key, struct{}{}); dup {
-				return
-			}
-
-			verb := "deleting"
-			if usingEviction {
-				verb = "evicting"
-			}
-
-			logger.Info("drain", "k8s_node", nodeName, "message", fmt.Sprintf("%s pod %s/%s", verb, pod.Namespace, pod.Name))
-		},
-		OnPodDeletionOrEvictionFinished: func(pod *corev1.Pod, _ bool, err error) {
-			announced.Delete(pod.Namespace + "/" + pod.Name)
-
-			if err != nil {
-				logger.Warn("drain", "k8s_node", nodeName, "message", fmt.Sprintf("failed to %s pod %s/%s: %v", action, pod.Namespace, pod.Name, err))
-
-				return
-			}
-
-			logger.Info("drain", "k8s_node", nodeName, "message", fmt.Sprintf("%s pod %s/%s", action, pod.Namespace, pod.Name))
-		},
-	}
-
-	if err := drain.RunNodeDrain(drainer, nodeName); err != nil {
-		return fmt.Errorf("draining node %q: %w", nodeName, err)
-	}
-
-	return nil
+	return k8s.Drain(ctx, clientset, k8sNodeName, opts.DrainTimeout, opts.DeleteIfEvictionFails, logger)
 }
 
 // systemContainerdInstance returns the containerd instance used for pulling
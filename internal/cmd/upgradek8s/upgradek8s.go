@@ -0,0 +1,582 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package upgradek8s contains the logic to upgrade the Kubernetes components
+// of a Talos cluster to the version configured in topf.yaml
+package upgradek8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/go-retry/retry"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/compatibility"
+	"github.com/siderolabs/talos/pkg/machinery/config/encoder"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+	"github.com/siderolabs/talos/pkg/machinery/resources/k8s"
+	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
+)
+
+// Options contains the options for the Kubernetes upgrade execution
+type Options struct {
+	// Only show which components would be upgraded without actually upgrading
+	DryRun bool
+	// Progress tracks the current phase of each node, if set
+	Progress *progress.Tracker
+}
+
+// component describes a Kubernetes component managed by Talos, how to read
+// the image it currently runs and how to patch the machine config to change it.
+type component struct {
+	name             string
+	controlPlaneOnly bool
+	runningImage     func(ctx context.Context, c *client.Client) (string, error)
+	patch            func(cfg *v1alpha1.Config, image string)
+}
+
+// components lists the Kubernetes components in the order they are upgraded:
+// the control-plane static pods first (API server before the components that
+// talk to it), then the kubelet on every node.
+func components() []component {
+	return []component{
+		{
+			name:             "kube-apiserver",
+			controlPlaneOnly: true,
+			runningImage: func(ctx context.Context, c *client.Client) (string, error) {
+				res, err := safe.StateGetResource(ctx, c.COSI, k8s.NewAPIServerConfig())
+				if err != nil {
+					return "", err
+				}
+
+				return res.TypedSpec().Image, nil
+			},
+			patch: func(cfg *v1alpha1.Config, image string) {
+				if cfg.ClusterConfig.APIServerConfig == nil {
+					cfg.ClusterConfig.APIServerConfig = &v1alpha1.APIServerConfig{}
+				}
+
+				cfg.ClusterConfig.APIServerConfig.ContainerImage = image
+			},
+		},
+		{
+			name:             "kube-controller-manager",
+			controlPlaneOnly: true,
+			runningImage: func(ctx context.Context, c *client.Client) (string, error) {
+				res, err := safe.StateGetResource(ctx, c.COSI, k8s.NewControllerManagerConfig())
+				if err != nil {
+					return "", err
+				}
+
+				return res.TypedSpec().Image, nil
+			},
+			patch: func(cfg *v1alpha1.Config, image string) {
+				if cfg.ClusterConfig.ControllerManagerConfig == nil {
+					cfg.ClusterConfig.ControllerManagerConfig = &v1alpha1.ControllerManagerConfig{}
+				}
+
+				cfg.ClusterConfig.ControllerManagerConfig.ContainerImage = image
+			},
+		},
+		{
+			name:             "kube-scheduler",
+			controlPlaneOnly: true,
+			runningImage: func(ctx context.Context, c *client.Client) (string, error) {
+				res, err := safe.StateGetResource(ctx, c.COSI, k8s.NewSchedulerConfig())
+				if err != nil {
+					return "", err
+				}
+
+				return res.TypedSpec().Image, nil
+			},
+			patch: func(cfg *v1alpha1.Config, image string) {
+				if cfg.ClusterConfig.SchedulerConfig == nil {
+					cfg.ClusterConfig.SchedulerConfig = &v1alpha1.SchedulerConfig{}
+				}
+
+				cfg.ClusterConfig.SchedulerConfig.ContainerImage = image
+			},
+		},
+		{
+			name: "kubelet",
+			runningImage: func(ctx context.Context, c *client.Client) (string, error) {
+				res, err := safe.StateGetResource(ctx, c.COSI, k8s.NewKubeletSpec(k8s.NamespaceName, k8s.KubeletID))
+				if err != nil {
+					return "", err
+				}
+
+				return res.TypedSpec().Image, nil
+			},
+			patch: func(cfg *v1alpha1.Config, image string) {
+				if cfg.MachineConfig.MachineKubelet == nil {
+					cfg.MachineConfig.MachineKubelet = &v1alpha1.KubeletConfig{}
+				}
+
+				cfg.MachineConfig.MachineKubelet.KubeletImage = image
+			},
+		},
+	}
+}
+
+// step is a single component upgrade on a single node
+type step struct {
+	node        *topf.Node
+	component   component
+	fromImage   string
+	toImage     string
+	fromVersion semver.Version
+}
+
+// Execute upgrades the Kubernetes components on all filtered nodes to the
+// kubernetesVersion configured in topf.yaml
+func Execute(ctx context.Context, t topf.Topf, opts Options) error {
+	logger := t.Logger().With("command", "upgrade-k8s")
+
+	target, err := semver.ParseTolerant(t.Config().KubernetesVersion)
+	if err != nil {
+		return fmt.Errorf("invalid kubernetesVersion %q: %w", t.Config().KubernetesVersion, err)
+	}
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(nodes) == 0 {
+		logger.Warn("no nodes to process. exiting")
+		return nil
+	}
+
+	opts.Progress.AddNodes(nodes)
+
+	if err := preChecks(logger, nodes); err != nil {
+		return err
+	}
+
+	steps, err := plan(ctx, logger, nodes, t.ControlPlaneNodes(), target)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		switch {
+		case !slices.ContainsFunc(steps, func(s step) bool { return s.node == node }):
+			opts.Progress.Set(node, progress.PhaseUnchanged)
+		case opts.DryRun:
+			opts.Progress.Finish(node, nil)
+		}
+	}
+
+	if len(steps) == 0 {
+		logger.Info("all kubernetes components are up to date", "version", target.String())
+		return nil
+	}
+
+	if opts.DryRun {
+		return topf.ErrDryRunChangesDetected
+	}
+
+	confirm := func(comp string, nodes int) bool {
+		return !t.Confirm() ||
+			interactive.ConfirmPrompt(fmt.Sprintf("Do you want to upgrade %s to v%s on %d node(s)?", comp, target, nodes)) == 'y'
+	}
+
+	return rollout(ctx, steps, confirm, upgradeComponent, opts.Progress, logger)
+}
+
+// ErrDeclined is returned when the operator declines the upgrade of a
+// component, which leaves it and all later components untouched
+var ErrDeclined = errors.New("upgrade declined")
+
+// rollout performs the steps component by component, in the order of
+// components, asking confirm before each. As the later components must not
+// be newer than the API server, a declined component stops the rollout. A
+// node is done once all of its components are upgraded, and skipped if the
+// rollout stopped before.
+func rollout(ctx context.Context, steps []step, confirm func(comp string, nodes int) bool,
+	upgrade func(context.Context, step, *slog.Logger) error, tracker *progress.Tracker, logger *slog.Logger,
+) error {
+	// remaining counts the steps of every node still to be performed
+	remaining := make(map[*topf.Node]int)
+	for _, s := range steps {
+		remaining[s.node]++
+	}
+
+	var pending []string
+
+	for _, comp := range components() {
+		if slices.ContainsFunc(steps, func(s step) bool { return s.component.name == comp.name }) {
+			pending = append(pending, comp.name)
+		}
+	}
+
+	for i, name := range pending {
+		compSteps := slices.DeleteFunc(slices.Clone(steps), func(s step) bool { return s.component.name != name })
+
+		if !confirm(name, len(compSteps)) {
+			for node := range remaining {
+				tracker.Set(node, progress.PhaseSkipped)
+			}
+
+			return fmt.Errorf("%w for %s, left untouched: %s", ErrDeclined, name, strings.Join(pending[i:], ", "))
+		}
+
+		for _, s := range compSteps {
+			tracker.Set(s.node, progress.PhaseApplying)
+
+			if err := upgrade(ctx, s, logger.With(s.node.Attrs(), "component", name)); err != nil {
+				tracker.Finish(s.node, err)
+				return err
+			}
+
+			if remaining[s.node]--; remaining[s.node] == 0 {
+				delete(remaining, s.node)
+				tracker.Finish(s.node, nil)
+			} else {
+				tracker.Set(s.node, progress.PhasePending)
+			}
+		}
+	}
+
+	return nil
+}
+
+// preChecks verifies that every node is reachable and running before any
+// component is touched, reporting all problems at once.
+func preChecks(logger *slog.Logger, nodes []*topf.Node) error {
+	abort := false
+
+	for _, node := range nodes {
+		logger := logger.With(node.Attrs())
+
+		if node.Error != nil {
+			logger.Error("node pre-checks", "error", node.Error)
+
+			abort = true
+
+			continue
+		}
+
+		if node.MachineStatus.Stage != runtime.MachineStageRunning {
+			logger.Error("node must be 'running' for kubernetes upgrade", "stage", node.MachineStatus.Stage.String())
+
+			abort = true
+		}
+	}
+
+	if abort {
+		return errors.New("aborting due to errors with some nodes")
+	}
+
+	return nil
+}
+
+// plan reads the running image of every component on every node, validates
+// the version skew towards the target version and returns the list of
+// component upgrades to perform, in rollout order. controlPlanes are all
+// control-plane nodes of the cluster, whose API servers the kubelets must not
+// get ahead of, also if they aren't selected.
+func plan(ctx context.Context, logger *slog.Logger, nodes, controlPlanes []*topf.Node, target semver.Version) ([]step, error) {
+	targetK8s, err := compatibility.ParseKubernetesVersion(target.String())
+	if err != nil {
+		return nil, err
+	}
+
+	controlPlane, workers := nodepool.PartitionByRole(nodes)
+
+	var (
+		steps []step
+		errs  []error
+	)
+
+	// the API servers are only read once a kubelet is to be upgraded
+	apiServer := sync.OnceValues(func() (semver.Version, error) {
+		return apiServerVersion(ctx, nodes, controlPlanes, target)
+	})
+
+	for _, comp := range components() {
+		targets := controlPlane
+		if !comp.controlPlaneOnly {
+			targets = append(slices.Clone(controlPlane), workers...)
+		}
+
+		for _, node := range targets {
+			logger := logger.With(node.Attrs(), "component", comp.name)
+
+			s, err := planStep(ctx, node, comp, target)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s on %s: %w", comp.name, node.Node.Host, err))
+				continue
+			}
+
+			if s.fromImage == s.toImage {
+				logger.Info("no upgrade required", "version", s.fromVersion.String())
+				continue
+			}
+
+			lowestAPIServer := target
+
+			if comp.name == "kubelet" {
+				lowestAPIServer, err = apiServer()
+				if err != nil {
+					errs = append(errs, err)
+					break
+				}
+			}
+
+			if err := checkVersionSkew(comp.name, s.fromVersion, target, lowestAPIServer); err != nil {
+				errs = append(errs, fmt.Errorf("%s on %s: %w", comp.name, node.Node.Host, err))
+				continue
+			}
+
+			if err := checkTalosCompatibility(targetK8s, node.RunningVersion()); err != nil {
+				errs = append(errs, fmt.Errorf("%s on %s: %w", comp.name, node.Node.Host, err))
+				continue
+			}
+
+			logger.Info("upgrade required",
+				"version_actual", s.fromVersion.String(),
+				"version_desired", target.String(),
+				"image", s.toImage)
+
+			steps = append(steps, s)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// planStep determines the running and desired image of a component on a node
+func planStep(ctx context.Context, node *topf.Node, comp component, target semver.Version) (step, error) {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return step{}, err
+	}
+	defer nodeClient.Close()
+
+	image, err := comp.runningImage(ctx, nodeClient)
+	if err != nil {
+		return step{}, fmt.Errorf("couldn't read running image: %w", err)
+	}
+
+	from, err := imageVersion(image)
+	if err != nil {
+		return step{}, err
+	}
+
+	return step{
+		node:        node,
+		component:   comp,
+		fromImage:   image,
+		toImage:     replaceImageTag(image, "v"+target.String()),
+		fromVersion: from,
+	}, nil
+}
+
+// apiServerVersion returns the lowest version of the API servers of
+// controlPlanes once the rollout upgraded the selected ones to target: the
+// running version of the control-plane nodes that aren't selected, target
+// for the others.
+func apiServerVersion(ctx context.Context, nodes, controlPlanes []*topf.Node, target semver.Version) (semver.Version, error) {
+	lowest := target
+
+	for _, cp := range controlPlanes {
+		if slices.ContainsFunc(nodes, func(n *topf.Node) bool { return n.Node.Host == cp.Node.Host }) {
+			continue
+		}
+
+		// the kube-apiserver is the first component
+		s, err := planStep(ctx, cp, components()[0], target)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("kube-apiserver on %s: %w", cp.Node.Host, err)
+		}
+
+		if s.fromVersion.LT(lowest) {
+			lowest = s.fromVersion
+		}
+	}
+
+	return lowest, nil
+}
+
+// checkVersionSkew validates the Kubernetes version skew policy for an upgrade
+// of a single component: downgrades across minor versions are rejected, as
+// are control-plane upgrades skipping a minor version. The kubelet may be
+// upgraded across several minor versions at once, as long as it doesn't get
+// ahead of apiServer, the lowest API server version after the control-plane
+// components of the rollout are upgraded.
+func checkVersionSkew(componentName string, from, to, apiServer semver.Version) error {
+	if from.Major != to.Major {
+		return fmt.Errorf("upgrade from %s to %s crosses a major version", from, to)
+	}
+
+	if to.Minor < from.Minor {
+		return fmt.Errorf("downgrade from %s to %s is not supported", from, to)
+	}
+
+	if componentName != "kubelet" && to.Minor > from.Minor+1 {
+		return fmt.Errorf("upgrade from %s to %s skips a minor version, upgrade to 1.%d first", from, to, from.Minor+1)
+	}
+
+	if componentName == "kubelet" &&
+		(to.Major > apiServer.Major || to.Major == apiServer.Major && to.Minor > apiServer.Minor) {
+		return fmt.Errorf("upgrade to %s gets ahead of kube-apiserver %s, upgrade the control plane first", to, apiServer)
+	}
+
+	return nil
+}
+
+// checkTalosCompatibility verifies the target Kubernetes version is supported
+// by the Talos version running on the node. Nodes with an unknown version are
+// not checked.
+func checkTalosCompatibility(target *compatibility.KubernetesVersion, runningTalos string) error {
+	if runningTalos == "" {
+		return nil
+	}
+
+	talosVersion, err := compatibility.ParseTalosVersion(&machine.VersionInfo{Tag: "v" + strings.TrimPrefix(runningTalos, "v")})
+	if err != nil {
+		return fmt.Errorf("couldn't parse running Talos version %q: %w", runningTalos, err)
+	}
+
+	return target.SupportedWith(talosVersion)
+}
+
+// upgradeComponent patches the node's active machine config with the new
+// component image, waits for Talos to pick it up and then for the node to
+// stabilize. The provided logger is expected to already carry the node's
+// and component's attributes.
+func upgradeComponent(ctx context.Context, s step, logger *slog.Logger) error {
+	logger.Info("upgrading component", "from", s.fromImage, "to", s.toImage)
+
+	nodeClient, err := s.node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	currentConfig, err := topf.ActiveConfig(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	patched, err := currentConfig.PatchV1Alpha1(func(cfg *v1alpha1.Config) error {
+		if cfg.ClusterConfig == nil || cfg.MachineConfig == nil {
+			return errors.New("machine config has no cluster or machine section")
+		}
+
+		s.component.patch(cfg, s.toImage)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't patch machine config: %w", err)
+	}
+
+	configBytes, err := patched.EncodeBytes(encoder.WithComments(encoder.CommentsDisabled), encoder.WithOmitEmpty(true))
+	if err != nil {
+		return err
+	}
+
+	if _, err = nodeClient.MachineClient.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
+		Data: configBytes,
+		Mode: machine.ApplyConfigurationRequest_NO_REBOOT,
+	}); err != nil {
+		return fmt.Errorf("failed to apply machine config: %w", err)
+	}
+
+	logger.Info("machine config patched, waiting for component update")
+
+	err = retry.Constant(time.Minute*5,
+		retry.WithUnits(time.Second*2),
+		retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug)),
+	).RetryWithContext(ctx, telemetry.Retried("wait-for-component", func(ctx context.Context) error {
+		image, err := s.component.runningImage(ctx, nodeClient)
+		if err != nil {
+			return retry.ExpectedErrorf("couldn't read running image: %w", err)
+		}
+
+		if image != s.toImage {
+			return retry.ExpectedErrorf("component still running %s", image)
+		}
+
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("%s on %s wasn't updated: %w", s.component.name, s.node.Node.Host, err)
+	}
+
+	if err = s.node.Stabilize(ctx, logger, time.Second*30); err != nil {
+		return fmt.Errorf("node didn't stabilize: %w", err)
+	}
+
+	logger.Info("component upgraded")
+
+	return nil
+}
+
+// imageVersion extracts the semantic version from an image reference tag
+// (e.g. registry.k8s.io/kube-apiserver:v1.33.0 or
+// ghcr.io/siderolabs/kubelet:v1.33.0-fat).
+func imageVersion(image string) (semver.Version, error) {
+	_, tag := splitImageTag(image)
+	if tag == "" {
+		return semver.Version{}, fmt.Errorf("image %q has no tag", image)
+	}
+
+	v, err := semver.ParseTolerant(tag)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("couldn't parse version from image %q: %w", image, err)
+	}
+
+	// drop image flavours such as -fat/-slim, which are not pre-releases
+	v.Pre = nil
+
+	return v, nil
+}
+
+// replaceImageTag returns the image with its tag replaced by the given
+// version, keeping the repository and any flavour suffix (e.g. -fat) of the
+// current tag. A digest, if present, is dropped.
+func replaceImageTag(image, version string) string {
+	repo, tag := splitImageTag(image)
+
+	for _, suffix := range []string{"-fat", "-slim"} {
+		if strings.HasSuffix(tag, suffix) {
+			version += suffix
+		}
+	}
+
+	return repo + ":" + version
+}
+
+// splitImageTag splits an image reference into repository and tag, ignoring
+// a trailing digest and registry ports.
+func splitImageTag(image string) (repo, tag string) {
+	image, _, _ = strings.Cut(image, "@")
+
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, ""
+	}
+
+	return image[:i], image[i+1:]
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package upgradek8s
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	tests := []struct {
+		name      string
+		component string
+		from, to  string
+		apiServer string
+		wantErr   bool
+	}{
+		{name: "patch", component: "kube-apiserver", from: "1.33.0", to: "1.33.2"},
+		{name: "oneMinor", component: "kube-apiserver", from: "1.32.4", to: "1.33.0"},
+		{name: "skipMinor", component: "kube-apiserver", from: "1.31.0", to: "1.33.0", wantErr: true},
+		{name: "kubeletSkipMinor", component: "kubelet", from: "1.31.0", to: "1.33.0"},
+		{name: "downgrade", component: "kube-scheduler", from: "1.33.0", to: "1.32.0", wantErr: true},
+		{name: "kubeletDowngrade", component: "kubelet", from: "1.33.0", to: "1.32.0", wantErr: true},
+		{name: "major", component: "kube-apiserver", from: "1.33.0", to: "2.0.0", wantErr: true},
+		{name: "kubeletWithAPIServer", component: "kubelet", from: "1.33.0", to: "1.34.0", apiServer: "1.34.1"},
+		{name: "kubeletAheadOfAPIServer", component: "kubelet", from: "1.33.0", to: "1.34.0", apiServer: "1.33.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiServer := semver.MustParse(cmp.Or(tt.apiServer, tt.to))
+
+			err := checkVersionSkew(tt.component, semver.MustParse(tt.from), semver.MustParse(tt.to), apiServer)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkVersionSkew() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplaceImageTag(t *testing.T) {
+	tests := []struct {
+		image, version, want string
+	}{
+		{"registry.k8s.io/kube-apiserver:v1.32.0", "v1.33.0", "registry.k8s.io/kube-apiserver:v1.33.0"},
+		{"ghcr.io/siderolabs/kubelet:v1.32.0-fat", "v1.33.0", "ghcr.io/siderolabs/kubelet:v1.33.0-fat"},
+		{"mirror.local:5000/kube-scheduler:v1.32.0", "v1.33.0", "mirror.local:5000/kube-scheduler:v1.33.0"},
+		{"registry.k8s.io/kube-apiserver:v1.32.0@sha256:abcd", "v1.33.0", "registry.k8s.io/kube-apiserver:v1.33.0"},
+	}
+
+	for _, tt := range tests {
+		if got := replaceImageTag(tt.image, tt.version); got != tt.want {
+			t.Errorf("replaceImageTag(%q, %q) = %q, want %q", tt.image, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestImageVersion(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{image: "registry.k8s.io/kube-apiserver:v1.33.1", want: "1.33.1"},
+		{image: "ghcr.io/siderolabs/kubelet:v1.33.1-fat", want: "1.33.1"},
+		{image: "mirror.local:5000/kube-apiserver", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := imageVersion(tt.image)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("imageVersion(%q) error = %v, wantErr %v", tt.image, err, tt.wantErr)
+		}
+
+		if err == nil && got.String() != tt.want {
+			t.Errorf("imageVersion(%q) = %s, want %s", tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestRollout(t *testing.T) {
+	cp := &topf.Node{Node: &config.Node{Host: "cp1", Role: config.RoleControlPlane}}
+	worker := &topf.Node{Node: &config.Node{Host: "worker1", Role: config.RoleWorker}}
+
+	var steps []step
+
+	for _, comp := range components() {
+		steps = append(steps, step{node: cp, component: comp})
+		if !comp.controlPlaneOnly {
+			steps = append(steps, step{node: worker, component: comp})
+		}
+	}
+
+	tests := []struct {
+		name         string
+		decline      string
+		wantUpgraded []string
+		wantErr      string
+		wantPhases   map[string]progress.Phase
+	}{
+		{
+			name:         "all confirmed",
+			wantUpgraded: []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "kubelet", "kubelet"},
+			wantPhases:   map[string]progress.Phase{"cp1": progress.PhaseDone, "worker1": progress.PhaseDone},
+		},
+		{
+			name:       "apiserver declined",
+			decline:    "kube-apiserver",
+			wantErr:    "left untouched: kube-apiserver, kube-controller-manager, kube-scheduler, kubelet",
+			wantPhases: map[string]progress.Phase{"cp1": progress.PhaseSkipped, "worker1": progress.PhaseSkipped},
+		},
+		{
+			name:         "scheduler declined",
+			decline:      "kube-scheduler",
+			wantUpgraded: []string{"kube-apiserver", "kube-controller-manager"},
+			wantErr:      "left untouched: kube-scheduler, kubelet",
+			wantPhases:   map[string]progress.Phase{"cp1": progress.PhaseSkipped, "worker1": progress.PhaseSkipped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upgraded []string
+
+			confirm := func(comp string, _ int) bool { return comp != tt.decline }
+			upgrade := func(_ context.Context, s step, _ *slog.Logger) error {
+				upgraded = append(upgraded, s.component.name)
+				return nil
+			}
+
+			tracker := progress.New()
+			tracker.AddNodes([]*topf.Node{cp, worker})
+
+			err := rollout(context.Background(), steps, confirm, upgrade, tracker, slog.New(slog.DiscardHandler))
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (!errors.Is(err, ErrDeclined) || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected declined error containing %q, got: %v", tt.wantErr, err)
+			}
+
+			if !slices.Equal(upgraded, tt.wantUpgraded) {
+				t.Errorf("upgraded %v, want %v", upgraded, tt.wantUpgraded)
+			}
+
+			for _, n := range tracker.Nodes() {
+				if n.Phase != tt.wantPhases[n.Host] {
+					t.Errorf("%s is %s, want %s", n.Host, n.Phase, tt.wantPhases[n.Host])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package configdiff computes structured, document-aware diffs between two
+// Talos machine configs and renders them for humans or machines.
+package configdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	talosconfig "github.com/siderolabs/talos/pkg/machinery/config"
+	configconfig "github.com/siderolabs/talos/pkg/machinery/config/config"
+	"github.com/siderolabs/talos/pkg/machinery/config/encoder"
+	"github.com/siderolabs/talos/pkg/machinery/textdiff"
+)
+
+// Format is the output format of a rendered diff
+type Format string
+
+const (
+	// FormatUnified renders a per-document summary followed by unified diffs
+	FormatUnified Format = "unified"
+	// FormatJSON renders the structured diff as a single JSON object
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates a user-facing diff format name
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case FormatUnified, FormatJSON:
+		return Format(format), nil
+	default:
+		return "", fmt.Errorf("invalid diff format %q, valid values: %s, %s", format, FormatUnified, FormatJSON)
+	}
+}
+
+// Status describes how a document changed between two configs
+type Status string
+
+const (
+	// StatusAdded means the document only exists in the desired config
+	StatusAdded Status = "added"
+	// StatusRemoved means the document only exists in the current config
+	StatusRemoved Status = "removed"
+	// StatusModified means the document exists in both configs, with changes
+	StatusModified Status = "modified"
+	// StatusUnchanged means the document is identical in both configs
+	StatusUnchanged Status = "unchanged"
+)
+
+// Document is the diff of a single machine config document, identified by
+// its kind and, for named documents, its name.
+type Document struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name,omitempty"`
+	Status  Status `json:"status"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	// Diff contains the unified diff hunks of the document (without file headers)
+	Diff string `json:"diff,omitempty"`
+}
+
+// Diff is the structured diff between a current and a desired machine config
+type Diff struct {
+	Documents []Document `json:"documents"`
+}
+
+// Changed reports whether any document was added, removed or modified
+func (d *Diff) Changed() bool {
+	for _, doc := range d.Documents {
+		if doc.Status != StatusUnchanged {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Compute diffs the documents of the current and desired configs. Documents
+// are matched by kind and name, and listed in the order of the desired
+// config, followed by removed documents. current may be nil (e.g. for nodes
+// in maintenance mode), in which case all documents are reported as added.
+func Compute(current, desired talosconfig.Provider) (*Diff, error) {
+	currentDocs, err := encodeDocuments(current)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode current config: %w", err)
+	}
+
+	desiredDocs, err := encodeDocuments(desired)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode desired config: %w", err)
+	}
+
+	diff := &Diff{}
+
+	for _, want := range desiredDocs {
+		var have string
+
+		status := StatusAdded
+
+		if i := indexOf(currentDocs, want.ID); i >= 0 {
+			have = currentDocs[i].yaml
+			status = StatusModified
+			currentDocs = append(currentDocs[:i], currentDocs[i+1:]...)
+		}
+
+		doc, err := diffDocument(want.Document, status, have, want.yaml)
+		if err != nil {
+			return nil, err
+		}
+
+		diff.Documents = append(diff.Documents, doc)
+	}
+
+	for _, removed := range currentDocs {
+		doc, err := diffDocument(removed.Document, StatusRemoved, removed.yaml, "")
+		if err != nil {
+			return nil, err
+		}
+
+		diff.Documents = append(diff.Documents, doc)
+	}
+
+	return diff, nil
+}
+
+// WriteUnified writes a per-document summary followed by the unified diff of
+// every changed document. Added and removed lines are colored when color is set.
+func (d *Diff) WriteUnified(w io.Writer, color bool) error {
+	var sb strings.Builder
+
+	for _, doc := range d.Documents {
+		if doc.Status == StatusUnchanged {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%s %-40s +%d -%d\n", statusSymbol(doc.Status), doc.ID, doc.Added, doc.Removed)
+	}
+
+	for _, doc := range d.Documents {
+		if doc.Status == StatusUnchanged {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n--- current/%s\n+++ desired/%s\n", doc.ID, doc.ID)
+
+		for line := range strings.Lines(doc.Diff) {
+			sb.WriteString(colorize(line, color))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// WriteJSON writes the structured diff as JSON
+func (d *Diff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(d)
+}
+
+type encodedDocument struct {
+	Document
+
+	yaml string
+}
+
+// encodeDocuments encodes every document of cfg on its own, using the same
+// encoder options used when applying configs.
+func encodeDocuments(cfg talosconfig.Provider) ([]encodedDocument, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	var docs []encodedDocument //nolint:prealloc // documents may be skipped
+
+	for _, doc := range cfg.Documents() {
+		b, err := encoder.NewEncoder(doc, encoder.WithComments(encoder.CommentsDisabled), encoder.WithOmitEmpty(true)).Encode()
+		if err != nil {
+			return nil, err
+		}
+
+		d := Document{Kind: doc.Kind(), ID: doc.Kind()}
+
+		if named, ok := doc.(configconfig.NamedDocument); ok && named.Name() != "" {
+			d.Name = named.Name()
+			d.ID = d.Kind + "/" + d.Name
+		}
+
+		docs = append(docs, encodedDocument{Document: d, yaml: string(b)})
+	}
+
+	return docs, nil
+}
+
+func indexOf(docs []encodedDocument, id string) int {
+	for i, doc := range docs {
+		if doc.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// diffDocument computes the unified diff between two encoded versions of a
+// document and counts the added and removed lines.
+func diffDocument(doc Document, status Status, current, desired string) (Document, error) {
+	raw, err := textdiff.Diff(current, desired)
+	if err != nil {
+		return Document{}, fmt.Errorf("couldn't diff document %s: %w", doc.ID, err)
+	}
+
+	if raw == "" {
+		doc.Status = StatusUnchanged
+		return doc, nil
+	}
+
+	doc.Status = status
+	doc.Diff, _ = strings.CutPrefix(raw, "--- a\n+++ b\n")
+
+	for line := range strings.Lines(doc.Diff) {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			doc.Added++
+		case strings.HasPrefix(line, "-"):
+			doc.Removed++
+		}
+	}
+
+	return doc, nil
+}
+
+func statusSymbol(status Status) string {
+	switch status {
+	case StatusAdded:
+		return "+"
+	case StatusRemoved:
+		return "-"
+	case StatusModified:
+		return "~"
+	default:
+		return " "
+	}
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiReset = "\x1b[0m"
+)
+
+func colorize(line string, color bool) string {
+	if !color {
+		return line
+	}
+
+	text, nl := strings.CutSuffix(line, "\n")
+
+	var code string
+
+	switch {
+	case strings.HasPrefix(text, "@@"):
+		code = ansiCyan
+	case strings.HasPrefix(text, "+"):
+		code = ansiGreen
+	case strings.HasPrefix(text, "-"):
+		code = ansiRed
+	default:
+		return line
+	}
+
+	text = code + text + ansiReset
+
+	if nl {
+		text += "\n"
+	}
+
+	return text
+}
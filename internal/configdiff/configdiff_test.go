@@ -0,0 +1,223 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package configdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	talosconfig "github.com/siderolabs/talos/pkg/machinery/config"
+	configconfig "github.com/siderolabs/talos/pkg/machinery/config/config"
+	"github.com/siderolabs/talos/pkg/machinery/config/container"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/network"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/runtime/extensions"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+)
+
+func newConfig(t *testing.T, hostname string, services ...string) talosconfig.Provider {
+	t.Helper()
+
+	v1 := &v1alpha1.Config{
+		ConfigVersion: "v1alpha1",
+		MachineConfig: &v1alpha1.MachineConfig{MachineType: "worker"},
+	}
+
+	docs := []configconfig.Document{v1}
+
+	if hostname != "" {
+		h := network.NewHostnameConfigV1Alpha1()
+		h.ConfigHostname = hostname
+		docs = append(docs, h)
+	}
+
+	for _, name := range services {
+		s := extensions.NewServicesConfigV1Alpha1()
+		s.ServiceName = name
+		docs = append(docs, s)
+	}
+
+	cfg, err := container.New(docs...)
+	if err != nil {
+		t.Fatalf("failed to create config container: %v", err)
+	}
+
+	return cfg
+}
+
+func documentsByID(d *Diff) map[string]Document {
+	docs := make(map[string]Document, len(d.Documents))
+	for _, doc := range d.Documents {
+		docs[doc.ID] = doc
+	}
+
+	return docs
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name    string
+		current talosconfig.Provider
+		desired talosconfig.Provider
+		want    map[string]Status
+		changed bool
+	}{
+		{
+			name:    "identical configs",
+			current: newConfig(t, "node1", "nut"),
+			desired: newConfig(t, "node1", "nut"),
+			want:    map[string]Status{"v1alpha1": StatusUnchanged, "HostnameConfig": StatusUnchanged, "ExtensionServiceConfig/nut": StatusUnchanged},
+			changed: false,
+		},
+		{
+			name:    "modified document",
+			current: newConfig(t, "node1"),
+			desired: newConfig(t, "node2"),
+			want:    map[string]Status{"v1alpha1": StatusUnchanged, "HostnameConfig": StatusModified},
+			changed: true,
+		},
+		{
+			name:    "added and removed documents",
+			current: newConfig(t, "node1", "nut"),
+			desired: newConfig(t, "", "tailscale"),
+			want: map[string]Status{
+				"v1alpha1":                         StatusUnchanged,
+				"HostnameConfig":                   StatusRemoved,
+				"ExtensionServiceConfig/nut":       StatusRemoved,
+				"ExtensionServiceConfig/tailscale": StatusAdded,
+			},
+			changed: true,
+		},
+		{
+			name:    "no current config",
+			current: nil,
+			desired: newConfig(t, "node1"),
+			want:    map[string]Status{"v1alpha1": StatusAdded, "HostnameConfig": StatusAdded},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Compute(tt.current, tt.desired)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+
+			if diff.Changed() != tt.changed {
+				t.Errorf("Changed() = %v, want %v", diff.Changed(), tt.changed)
+			}
+
+			docs := documentsByID(diff)
+			if len(docs) != len(tt.want) {
+				t.Errorf("got %d documents, want %d", len(docs), len(tt.want))
+			}
+
+			for id, status := range tt.want {
+				doc, ok := docs[id]
+				if !ok {
+					t.Errorf("document %s missing from diff", id)
+					continue
+				}
+
+				if doc.Status != status {
+					t.Errorf("document %s status = %s, want %s", id, doc.Status, status)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeLineCounts(t *testing.T) {
+	diff, err := Compute(newConfig(t, "node1"), newConfig(t, "node2"))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	doc := documentsByID(diff)["HostnameConfig"]
+	if doc.Added != 1 || doc.Removed != 1 {
+		t.Errorf("HostnameConfig lines = +%d -%d, want +1 -1", doc.Added, doc.Removed)
+	}
+
+	if !strings.Contains(doc.Diff, "-hostname: node1\n") || !strings.Contains(doc.Diff, "+hostname: node2\n") {
+		t.Errorf("unexpected diff:\n%s", doc.Diff)
+	}
+
+	if strings.HasPrefix(doc.Diff, "---") {
+		t.Errorf("diff should not contain file headers:\n%s", doc.Diff)
+	}
+}
+
+func TestWriteUnified(t *testing.T) {
+	diff, err := Compute(newConfig(t, "node1"), newConfig(t, "node2"))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	var plain bytes.Buffer
+	if err := diff.WriteUnified(&plain, false); err != nil {
+		t.Fatalf("WriteUnified() error = %v", err)
+	}
+
+	out := plain.String()
+
+	if !strings.HasPrefix(out, "~ HostnameConfig") {
+		t.Errorf("expected summary line for HostnameConfig, got:\n%s", out)
+	}
+
+	if strings.Contains(out, "current/v1alpha1") {
+		t.Errorf("unchanged documents should be omitted, got:\n%s", out)
+	}
+
+	if !strings.Contains(out, "--- current/HostnameConfig\n+++ desired/HostnameConfig\n") {
+		t.Errorf("expected document headers, got:\n%s", out)
+	}
+
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no color codes, got:\n%q", out)
+	}
+
+	var colored bytes.Buffer
+	if err := diff.WriteUnified(&colored, true); err != nil {
+		t.Fatalf("WriteUnified() error = %v", err)
+	}
+
+	if !strings.Contains(colored.String(), ansiGreen+"+hostname: node2"+ansiReset+"\n") {
+		t.Errorf("expected colored added line, got:\n%q", colored.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	diff, err := Compute(newConfig(t, "node1"), newConfig(t, "node2"))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := diff.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var got Diff
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if len(got.Documents) != len(diff.Documents) {
+		t.Errorf("got %d documents, want %d", len(got.Documents), len(diff.Documents))
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, valid := range []string{"unified", "json"} {
+		if _, err := ParseFormat(valid); err != nil {
+			t.Errorf("ParseFormat(%q) error = %v", valid, err)
+		}
+	}
+
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(\"yaml\") expected error")
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package control pauses rolling operations between steps until an operator
+// lets them continue, skips a step or aborts the rollout. Commands are read
+// from the terminal, a control file or a local Unix socket.
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// PauseAfter is the kind of step after which a rollout pauses
+type PauseAfter string
+
+const (
+	// PauseAfterNone only pauses when an operator requests it
+	PauseAfterNone PauseAfter = "none"
+	// PauseAfterNode pauses before every node but the first
+	PauseAfterNode PauseAfter = "node"
+	// PauseAfterWave pauses before every wave of worker nodes but the first
+	PauseAfterWave PauseAfter = "wave"
+)
+
+// ParsePauseAfter parses a pause-after flag value
+func ParsePauseAfter(value string) (PauseAfter, error) {
+	switch p := PauseAfter(value); p {
+	case PauseAfterNone, PauseAfterNode, PauseAfterWave:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid pause-after %q, valid values: %s, %s, %s", value, PauseAfterNone, PauseAfterNode, PauseAfterWave)
+	}
+}
+
+// Command is an operator command
+type Command string
+
+const (
+	// CommandContinue starts the paused step
+	CommandContinue Command = "continue"
+	// CommandSkip skips the paused step: the node, or all nodes of the wave
+	CommandSkip Command = "skip"
+	// CommandAbort stops the rollout; operations in flight are completed
+	CommandAbort Command = "abort"
+	// CommandContinueAll starts the paused step and stops pausing
+	CommandContinueAll Command = "continue-all"
+	// CommandPause pauses the rollout before the next step
+	CommandPause Command = "pause"
+	// CommandStatus reports the state of the rollout, without changing it
+	CommandStatus Command = "status"
+)
+
+var (
+	// ErrSkipped is returned for a step skipped by the operator
+	ErrSkipped = errors.New("skipped by operator")
+	// ErrAborted is returned for the steps of a rollout aborted by the operator
+	ErrAborted = errors.New("rollout aborted by operator")
+)
+
+// Controller pauses a rollout between steps until an operator sends a
+// command. It is safe for concurrent use, and a nil Controller never pauses.
+type Controller struct {
+	pauseAfter PauseAfter
+	logger     *slog.Logger
+
+	// input and output prompt for commands on a terminal while paused, if
+	// input is set
+	input  *bufio.Reader
+	output io.Writer
+
+	// step serializes the steps, so that a pause holds back all workers
+	step sync.Mutex
+
+	mu             sync.Mutex
+	pauseRequested bool
+	continueAll    bool
+	aborted        bool
+	nodeStarted    bool
+	skipHosts      map[string]bool
+	// paused describes the step waiting for a command, empty if none is
+	paused string
+	resume chan Command
+}
+
+// New returns a controller pausing after the given kind of step
+func New(pauseAfter PauseAfter, logger *slog.Logger) *Controller {
+	return &Controller{
+		pauseAfter: pauseAfter,
+		logger:     logger,
+		skipHosts:  make(map[string]bool),
+	}
+}
+
+// Prompt makes the controller ask for commands on output and read them from
+// input while paused. Input must not be read by anything else meanwhile.
+func (c *Controller) Prompt(input io.Reader, output io.Writer) {
+	c.input = bufio.NewReader(input)
+	c.output = output
+}
+
+// BeforeNode waits until the node may be started. It returns ErrSkipped if
+// the operator skipped the node, and ErrAborted once the rollout is aborted.
+func (c *Controller) BeforeNode(ctx context.Context, host string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.step.Lock()
+	defer c.step.Unlock()
+
+	c.mu.Lock()
+
+	switch {
+	case c.aborted:
+		c.mu.Unlock()
+		return ErrAborted
+	case c.skipHosts[host]:
+		delete(c.skipHosts, host)
+		c.mu.Unlock()
+
+		return ErrSkipped
+	}
+
+	pause := c.pauseRequested || (c.pauseAfter == PauseAfterNode && c.nodeStarted && !c.continueAll)
+	c.nodeStarted = true
+	c.mu.Unlock()
+
+	if !pause {
+		return nil
+	}
+
+	return c.wait(ctx, "node "+host, "node", host)
+}
+
+// BeforeWave waits until a wave of nodes may be started. If the operator
+// skips the wave, it returns ErrSkipped and BeforeNode reports every node of
+// the wave as skipped. It returns ErrAborted once the rollout is aborted.
+func (c *Controller) BeforeWave(ctx context.Context, wave, waves int, hosts []string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.step.Lock()
+	defer c.step.Unlock()
+
+	c.mu.Lock()
+
+	if c.aborted {
+		c.mu.Unlock()
+		return ErrAborted
+	}
+
+	pause := c.pauseRequested || (c.pauseAfter == PauseAfterWave && wave > 1 && !c.continueAll)
+	c.mu.Unlock()
+
+	if !pause {
+		return nil
+	}
+
+	err := c.wait(ctx, fmt.Sprintf("wave %d/%d (%d nodes)", wave, waves, len(hosts)), "wave", fmt.Sprintf("%d/%d", wave, waves))
+	if errors.Is(err, ErrSkipped) {
+		c.mu.Lock()
+		for _, host := range hosts {
+			c.skipHosts[host] = true
+		}
+		c.mu.Unlock()
+	}
+
+	return err
+}
+
+// Aborted reports whether the operator aborted the rollout
+func (c *Controller) Aborted() bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.aborted
+}
+
+// Status describes the state of the rollout
+func (c *Controller) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.aborted:
+		return "aborted"
+	case c.paused != "":
+		return "paused before " + c.paused
+	case c.pauseRequested:
+		return "pausing before the next step"
+	default:
+		return "running"
+	}
+}
+
+// Send applies an operator command. Skip is only valid while paused.
+func (c *Controller) Send(cmd Command) error {
+	_, err := c.send(cmd)
+	return err
+}
+
+// send applies cmd and reports whether it resumed a paused step
+func (c *Controller) send(cmd Command) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch cmd {
+	case CommandStatus:
+		return false, nil
+	case CommandPause:
+		if !c.pauseRequested && c.paused == "" {
+			c.logger.Info("rollout pause requested, pausing before the next step")
+		}
+
+		c.pauseRequested = true
+		c.continueAll = false
+
+		return false, nil
+	case CommandContinue:
+		c.pauseRequested = false
+	case CommandContinueAll:
+		c.pauseRequested = false
+		c.continueAll = true
+	case CommandAbort:
+		if !c.aborted {
+			c.logger.Warn("rollout aborted by operator, waiting for the operations in flight")
+		}
+
+		c.aborted = true
+	case CommandSkip:
+		if c.resume == nil {
+			return false, errors.New("the rollout is not paused, there is nothing to skip")
+		}
+	default:
+		return false, fmt.Errorf("unknown command %q, valid commands: %s, %s, %s, %s, %s, %s", cmd,
+			CommandContinue, CommandSkip, CommandAbort, CommandContinueAll, CommandPause, CommandStatus)
+	}
+
+	if c.resume == nil {
+		return false, nil
+	}
+
+	// the first command resolves the pause, later ones only change the state
+	select {
+	case c.resume <- cmd:
+	default:
+	}
+
+	return true, nil
+}
+
+// ParseCommand parses a command as sent by an operator, accepting the first
+// letter of continue, skip and abort as a shorthand
+func ParseCommand(value string) Command {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch value {
+	case "c":
+		return CommandContinue
+	case "s":
+		return CommandSkip
+	case "a":
+		return CommandAbort
+	default:
+		return Command(value)
+	}
+}
+
+// wait pauses until a command resolves the pause of step
+func (c *Controller) wait(ctx context.Context, step string, attrs ...any) error {
+	resume := make(chan Command, 1)
+
+	c.mu.Lock()
+	c.paused = step
+	c.resume = resume
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.paused = ""
+		c.resume = nil
+		c.pauseRequested = false
+		c.mu.Unlock()
+	}()
+
+	c.logger.Warn("rollout paused, waiting for continue, skip, abort or continue-all", append([]any{"next", step}, attrs...)...)
+
+	if c.input != nil {
+		go c.prompt(ctx, step)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case cmd := <-resume:
+		c.logger.Info("rollout resumed", append([]any{"next", step, "command", cmd}, attrs...)...)
+
+		switch cmd {
+		case CommandSkip:
+			return ErrSkipped
+		case CommandAbort:
+			return ErrAborted
+		default:
+			return nil
+		}
+	}
+}
+
+// prompt reads commands from the terminal until one resumes the paused step
+func (c *Controller) prompt(ctx context.Context, step string) {
+	for ctx.Err() == nil {
+		fmt.Fprintf(c.output, "Rollout paused before %s. [c]ontinue, [s]kip, [a]bort or continue-all? ", step)
+
+		line, err := c.input.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := ParseCommand(line)
+
+		resumed, err := c.send(cmd)
+		if err != nil {
+			fmt.Fprintln(c.output, err)
+			continue
+		}
+
+		if cmd == CommandStatus {
+			fmt.Fprintln(c.output, c.Status())
+		}
+
+		if resumed {
+			return
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestController(pauseAfter PauseAfter) *Controller {
+	return New(pauseAfter, slog.New(slog.DiscardHandler))
+}
+
+// beforeNode runs BeforeNode in the background and returns its result
+func beforeNode(c *Controller, host string) <-chan error {
+	result := make(chan error, 1)
+
+	go func() { result <- c.BeforeNode(context.Background(), host) }()
+
+	return result
+}
+
+// waitPaused waits until the controller is paused
+func waitPaused(t *testing.T, c *Controller) {
+	t.Helper()
+	waitStatus(t, c, "paused")
+}
+
+// waitStatus waits until the status of the controller starts with prefix
+func waitStatus(t *testing.T, c *Controller, prefix string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.HasPrefix(c.Status(), prefix) {
+		if time.Now().After(deadline) {
+			t.Fatalf("status = %q, want prefix %q", c.Status(), prefix)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNilController(t *testing.T) {
+	var c *Controller
+
+	if err := c.BeforeNode(context.Background(), "node1"); err != nil {
+		t.Errorf("BeforeNode() = %v", err)
+	}
+
+	if err := c.BeforeWave(context.Background(), 2, 3, nil); err != nil {
+		t.Errorf("BeforeWave() = %v", err)
+	}
+
+	if c.Aborted() {
+		t.Error("nil controller aborted")
+	}
+}
+
+func TestPauseAfterNode(t *testing.T) {
+	c := newTestController(PauseAfterNode)
+
+	// the first node starts right away
+	if err := c.BeforeNode(context.Background(), "node1"); err != nil {
+		t.Fatalf("BeforeNode(node1) = %v", err)
+	}
+
+	for _, tt := range []struct {
+		cmd  Command
+		want error
+	}{
+		{cmd: CommandContinue},
+		{cmd: CommandSkip, want: ErrSkipped},
+		{cmd: CommandContinueAll},
+	} {
+		result := beforeNode(c, "node2")
+		waitPaused(t, c)
+
+		if got := c.Status(); got != "paused before node node2" {
+			t.Errorf("status = %q", got)
+		}
+
+		if err := c.Send(tt.cmd); err != nil {
+			t.Fatalf("Send(%s) = %v", tt.cmd, err)
+		}
+
+		if err := <-result; !errors.Is(err, tt.want) {
+			t.Errorf("BeforeNode after %s = %v, want %v", tt.cmd, err, tt.want)
+		}
+	}
+
+	// no more pauses after continue-all
+	if err := c.BeforeNode(context.Background(), "node3"); err != nil {
+		t.Errorf("BeforeNode after continue-all = %v", err)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	c := newTestController(PauseAfterNode)
+
+	_ = c.BeforeNode(context.Background(), "node1")
+	result := beforeNode(c, "node2")
+	waitPaused(t, c)
+
+	if err := c.Send(CommandAbort); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-result; !errors.Is(err, ErrAborted) {
+		t.Errorf("BeforeNode = %v, want ErrAborted", err)
+	}
+
+	if !c.Aborted() {
+		t.Error("controller not aborted")
+	}
+
+	// later steps don't start either
+	if err := c.BeforeWave(context.Background(), 2, 2, nil); !errors.Is(err, ErrAborted) {
+		t.Errorf("BeforeWave = %v, want ErrAborted", err)
+	}
+}
+
+func TestPauseRequest(t *testing.T) {
+	c := newTestController(PauseAfterNone)
+
+	if err := c.Send(CommandSkip); err == nil {
+		t.Error("skip accepted while not paused")
+	}
+
+	if err := c.Send("reboot"); err == nil {
+		t.Error("unknown command accepted")
+	}
+
+	if err := c.Send(CommandPause); err != nil {
+		t.Fatal(err)
+	}
+
+	result := beforeNode(c, "node1")
+	waitPaused(t, c)
+
+	_ = c.Send(CommandContinue)
+
+	if err := <-result; err != nil {
+		t.Fatalf("BeforeNode = %v", err)
+	}
+
+	// the pause was for a single step only
+	if err := c.BeforeNode(context.Background(), "node2"); err != nil {
+		t.Errorf("BeforeNode = %v", err)
+	}
+}
+
+func TestSkipWave(t *testing.T) {
+	c := newTestController(PauseAfterWave)
+
+	if err := c.BeforeWave(context.Background(), 1, 2, []string{"node1"}); err != nil {
+		t.Fatalf("BeforeWave(1) = %v", err)
+	}
+
+	result := make(chan error, 1)
+
+	go func() { result <- c.BeforeWave(context.Background(), 2, 2, []string{"node2", "node3"}) }()
+
+	waitPaused(t, c)
+	_ = c.Send(CommandSkip)
+
+	if err := <-result; !errors.Is(err, ErrSkipped) {
+		t.Fatalf("BeforeWave(2) = %v, want ErrSkipped", err)
+	}
+
+	for _, host := range []string{"node2", "node3"} {
+		if err := c.BeforeNode(context.Background(), host); !errors.Is(err, ErrSkipped) {
+			t.Errorf("BeforeNode(%s) = %v, want ErrSkipped", host, err)
+		}
+	}
+}
+
+func TestPausedContextCancelled(t *testing.T) {
+	c := newTestController(PauseAfterNone)
+	_ = c.Send(CommandPause)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.BeforeNode(ctx, "node1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("BeforeNode = %v, want context.Canceled", err)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	c := newTestController(PauseAfterNode)
+
+	input, w := net.Pipe()
+	defer w.Close()
+
+	c.Prompt(input, io.Discard)
+
+	_ = c.BeforeNode(context.Background(), "node1")
+	result := beforeNode(c, "node2")
+
+	// an invalid command is answered with the valid ones
+	if _, err := w.Write([]byte("retry\ns\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-result; !errors.Is(err, ErrSkipped) {
+		t.Errorf("BeforeNode = %v, want ErrSkipped", err)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	c := newTestController(PauseAfterNone)
+	path := filepath.Join(t.TempDir(), "topf.control")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c.WatchFile(ctx, path, 5*time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("pause\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	waitStatus(t, c, "pausing")
+
+	result := beforeNode(c, "node1")
+	waitPaused(t, c)
+
+	if err := os.WriteFile(path, []byte("abort\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-result; !errors.Is(err, ErrAborted) {
+		t.Errorf("BeforeNode = %v, want ErrAborted", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	c := newTestController(PauseAfterNone)
+
+	// socket paths are limited to about 100 characters, more than a temp
+	// dir of the test may take
+	dir, err := os.MkdirTemp("", "topf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "control.sock")
+
+	closeSocket, err := c.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeSocket()
+
+	if _, err := c.Listen(path); err == nil {
+		t.Error("second listener on the same socket succeeded")
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	replies := bufio.NewScanner(conn)
+
+	for _, tt := range []struct {
+		cmd, want string
+	}{
+		{cmd: "status", want: "ok: running"},
+		{cmd: "skip", want: "error: the rollout is not paused, there is nothing to skip"},
+		{cmd: "pause", want: "ok: pausing before the next step"},
+	} {
+		if _, err := conn.Write([]byte(tt.cmd + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		if !replies.Scan() || replies.Text() != tt.want {
+			t.Errorf("reply to %s = %q, want %q", tt.cmd, replies.Text(), tt.want)
+		}
+	}
+}
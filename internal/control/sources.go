@@ -0,0 +1,121 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package control
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// WatchFile polls path for commands until ctx is done. Each line of the file
+// is a command; the file is removed once its commands are applied, so that
+// e.g. `echo pause > topf.control` can be repeated.
+func (c *Controller) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			c.readFile(path)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (c *Controller) readFile(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Warn("failed to read the control file", "path", path, "error", err)
+		}
+
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		c.logger.Warn("failed to remove the control file, ignoring its commands", "path", path, "error", err)
+		return
+	}
+
+	for line := range strings.Lines(string(content)) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if err := c.Send(ParseCommand(line)); err != nil {
+			c.logger.Warn("invalid command in the control file", "path", path, "error", err)
+		}
+	}
+}
+
+// Listen accepts commands on a Unix socket at path until the returned
+// function is called. Each line a client writes is a command, answered with
+// "ok: " and the status of the rollout, or "error: " and the reason.
+func (c *Controller) Listen(path string) (func(), error) {
+	// a socket left behind by a previous run can be replaced, unless another
+	// process still listens on it
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("control socket %s is in use by another process", path)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("removing stale control socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listening on control socket: %w", err)
+	}
+
+	// only the owner may control the rollout
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("restricting control socket permissions: %w", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go c.serve(conn)
+		}
+	}()
+
+	return func() { _ = listener.Close() }, nil
+}
+
+func (c *Controller) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		if err := c.Send(ParseCommand(scanner.Text())); err != nil {
+			fmt.Fprintf(conn, "error: %v\n", err)
+			continue
+		}
+
+		fmt.Fprintf(conn, "ok: %s\n", c.Status())
+	}
+}
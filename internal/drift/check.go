@@ -0,0 +1,195 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package drift
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/postfinance/topf/internal/k8s"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Node is the drift of a single node. Checks that couldn't be performed are
+// recorded in Errors, checks whose running value couldn't be read in Unknown;
+// both leave the node unchecked.
+type Node struct {
+	Host       string   `json:"host"                 yaml:"host"`
+	Role       string   `json:"role"                 yaml:"role"`
+	Config     *Config  `json:"config,omitempty"     yaml:"config,omitempty"`
+	Talos      Drift    `json:"talos"                yaml:"talos"`
+	Schematic  Drift    `json:"schematic"            yaml:"schematic"`
+	Kubernetes Drift    `json:"kubernetes"           yaml:"kubernetes"`
+	Unknown    []string `json:"unknown,omitempty"    yaml:"unknown,omitempty"`
+	Errors     []string `json:"errors,omitempty"     yaml:"errors,omitempty"`
+}
+
+// Drifts describes every drift found on the node
+func (n *Node) Drifts() []string {
+	var drifts []string
+
+	if n.Config != nil && n.Config.Drifted {
+		drifts = append(drifts, "config: "+n.Config.Changes())
+	}
+
+	for _, d := range []struct {
+		name  string
+		drift Drift
+	}{{"talos", n.Talos}, {"schematic", n.Schematic}, {"kubernetes", n.Kubernetes}} {
+		if d.drift.Drifted() {
+			drifts = append(drifts, fmt.Sprintf("%s: running %s, desired %s", d.name, d.drift.Running, d.drift.Desired))
+		}
+	}
+
+	return drifts
+}
+
+// Drifted reports whether any drift was found on the node
+func (n *Node) Drifted() bool {
+	return len(n.Drifts()) > 0
+}
+
+// Unchecked reports whether any check of the node couldn't be performed or
+// its running value couldn't be read
+func (n *Node) Unchecked() bool {
+	return len(n.Errors) > 0 || len(n.Unknown) > 0
+}
+
+func (n *Node) fail(check string, err error) {
+	n.Errors = append(n.Errors, check+": "+err.Error())
+}
+
+// check records the check as unknown if the running value of d couldn't be
+// read, reason tells why if known
+func (n *Node) check(check string, d Drift, reason string) {
+	if d.Unknown() {
+		n.Unknown = append(n.Unknown, check+": "+cmp.Or(reason, "running value unknown"))
+	}
+}
+
+// Report is the drift of the selected nodes of a cluster
+type Report struct {
+	Cluster string  `json:"cluster" yaml:"cluster"`
+	Nodes   []*Node `json:"nodes"   yaml:"nodes"`
+}
+
+// Drifted reports whether any drift was found
+func (r *Report) Drifted() bool {
+	for _, n := range r.Nodes {
+		if n.Drifted() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unchecked reports whether any node couldn't be fully checked
+func (r *Report) Unchecked() bool {
+	for _, n := range r.Nodes {
+		if n.Unchecked() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check compares the config, the Talos version, the schematic and the kubelet
+// version of the selected nodes with topf.yaml. Nodes are checked
+// concurrently and an unreachable node or component doesn't stop the check of
+// the others: the failure is recorded on the node instead.
+func Check(ctx context.Context, t topf.Topf) (*Report, error) {
+	logger := t.Logger().With("command", "drift")
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Cluster: t.Config().ClusterName, Nodes: make([]*Node, len(nodes))}
+	desiredKubernetes := strings.TrimPrefix(t.Config().KubernetesVersion, "v")
+
+	var (
+		clientset    kubernetes.Interface
+		clientsetErr error
+	)
+
+	for _, node := range nodes {
+		if node.Error == nil && node.MachineStatus.Stage == runtime.MachineStageRunning {
+			clientset, clientsetErr = k8s.NewClientset(ctx, t, logger)
+			break
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	for i, node := range nodes {
+		n := &Node{Host: node.Node.Host, Role: string(node.Node.Role), Kubernetes: Drift{Desired: desiredKubernetes}}
+		report.Nodes[i] = n
+
+		if node.Error != nil {
+			n.fail("node", node.Error)
+			continue
+		}
+
+		n.Talos = Talos(node)
+		n.check("talos", n.Talos, "")
+		n.Schematic = Schematic(node)
+		n.check("schematic", n.Schematic, "")
+
+		wg.Go(func() {
+			n.Config = CheckConfig(ctx, node)
+			if n.Config.Error != "" {
+				n.Errors = append(n.Errors, "config: "+n.Config.Error)
+			}
+
+			// a node outside of the running stage has no kubelet to compare
+			if node.MachineStatus.Stage != runtime.MachineStageRunning {
+				n.check("kubernetes", n.Kubernetes, "kubelet not checked, stage is "+node.MachineStatus.Stage.String())
+				return
+			}
+
+			if clientsetErr != nil {
+				n.fail("kubernetes", clientsetErr)
+				return
+			}
+
+			running, err := kubeletVersion(ctx, clientset, node)
+			if err != nil {
+				n.fail("kubernetes", err)
+				return
+			}
+
+			n.Kubernetes.Running = running
+			n.check("kubernetes", n.Kubernetes, "kubelet reports no version")
+		})
+	}
+
+	wg.Wait()
+
+	return report, nil
+}
+
+// kubeletVersion returns the kubelet version the Kubernetes node of a node
+// reports, without the "v" prefix
+func kubeletVersion(ctx context.Context, clientset kubernetes.Interface, node *topf.Node) (string, error) {
+	name, err := k8s.NodeName(ctx, node)
+	if err != nil {
+		return "", err
+	}
+
+	k8sNode, err := clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("getting node %q: %w", name, err)
+	}
+
+	return strings.TrimPrefix(k8sNode.Status.NodeInfo.KubeletVersion, "v"), nil
+}
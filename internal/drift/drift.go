@@ -0,0 +1,91 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package drift compares the running state of nodes with the state declared
+// in topf.yaml
+package drift
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/postfinance/topf/internal/configdiff"
+	"github.com/postfinance/topf/internal/topf"
+)
+
+// Drift compares the running value of a version or schematic with the
+// desired one
+type Drift struct {
+	Running string `json:"running" yaml:"running"`
+	Desired string `json:"desired" yaml:"desired"`
+}
+
+// Drifted reports whether the running value differs from the desired one.
+// An unknown running value or an unset desired value is not a drift.
+func (d Drift) Drifted() bool {
+	return d.Running != "" && d.Desired != "" && d.Running != d.Desired
+}
+
+// Unknown reports whether the running value couldn't be read although a
+// desired value is set, such that neither a drift nor its absence is known
+func (d Drift) Unknown() bool {
+	return d.Running == "" && d.Desired != ""
+}
+
+// Talos returns the Talos version drift of a node
+func Talos(node *topf.Node) Drift {
+	return Drift{Running: node.RunningVersion(), Desired: node.DesiredVersion()}
+}
+
+// Schematic returns the schematic drift of a node
+func Schematic(node *topf.Node) Drift {
+	return Drift{Running: node.RunningSchematic(), Desired: node.DesiredSchematic()}
+}
+
+// Config is the result of the dry-run apply of a node
+type Config struct {
+	Drifted bool `json:"drifted" yaml:"drifted"`
+	// Mode is how Talos would apply the changes (e.g. REBOOT)
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Documents are the IDs of the changed configuration documents, as far as
+	// the local diff finds them
+	Documents []string `json:"documents,omitempty" yaml:"documents,omitempty"`
+	Error     string   `json:"error,omitempty"     yaml:"error,omitempty"`
+}
+
+// Changes describes the changed documents and the apply mode of a drifted
+// config
+func (c *Config) Changes() string {
+	if len(c.Documents) == 0 {
+		return fmt.Sprintf("changed (%s)", c.Mode)
+	}
+
+	return fmt.Sprintf("%s changed (%s)", strings.Join(c.Documents, ", "), c.Mode)
+}
+
+// CheckConfig tells whether applying the rendered configuration would change
+// the node, as decided by the dry-run apply of topf.Node.CheckDrift. Errors
+// are recorded in the result.
+func CheckConfig(ctx context.Context, node *topf.Node) *Config {
+	result, err := node.CheckDrift(ctx)
+	if err != nil {
+		return &Config{Error: err.Error()}
+	}
+
+	cfg := &Config{Drifted: result.Changed}
+
+	if !cfg.Drifted {
+		return cfg
+	}
+
+	cfg.Mode = result.Mode.String()
+
+	for _, doc := range result.Diff.Documents {
+		if doc.Status != configdiff.StatusUnchanged {
+			cfg.Documents = append(cfg.Documents, doc.ID)
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package drift
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDrifted(t *testing.T) {
+	tests := []struct {
+		drift Drift
+		want  bool
+	}{
+		{Drift{Running: "1.13.0", Desired: "1.13.0"}, false},
+		{Drift{Running: "1.12.0", Desired: "1.13.0"}, true},
+		{Drift{Running: "", Desired: "1.13.0"}, false},
+		{Drift{Running: "1.12.0", Desired: ""}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.drift.Drifted(); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.drift, tt.want, got)
+		}
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	tests := []struct {
+		drift Drift
+		want  bool
+	}{
+		{Drift{Running: "1.13.0", Desired: "1.13.0"}, false},
+		{Drift{Running: "", Desired: "1.13.0"}, true},
+		{Drift{Running: "", Desired: ""}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.drift.Unknown(); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.drift, tt.want, got)
+		}
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	if got := (&Config{Drifted: true, Mode: "REBOOT", Documents: []string{"v1alpha1"}}).Changes(); got != "v1alpha1 changed (REBOOT)" {
+		t.Errorf("unexpected changes: %q", got)
+	}
+
+	// Talos may report changes the local diff misses
+	if got := (&Config{Drifted: true, Mode: "NO_REBOOT"}).Changes(); got != "changed (NO_REBOOT)" {
+		t.Errorf("unexpected changes without documents: %q", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	inSync := &Node{Host: "cp1", Role: "control-plane", Config: &Config{}, Talos: Drift{Running: "1.13.0", Desired: "1.13.0"}}
+	drifted := &Node{
+		Host:       "w1",
+		Role:       "worker",
+		Config:     &Config{Drifted: true, Mode: "NO_REBOOT", Documents: []string{"v1alpha1"}},
+		Kubernetes: Drift{Running: "1.34.0", Desired: "1.35.0"},
+	}
+	unchecked := &Node{Host: "w2", Role: "worker", Errors: []string{"node: connection refused"}}
+	unknown := &Node{Host: "w3", Role: "worker", Config: &Config{}, Talos: Drift{Desired: "1.13.0"}}
+	unknown.check("talos", unknown.Talos, "")
+
+	report := &Report{Cluster: "test", Nodes: []*Node{inSync}}
+	if report.Drifted() || report.Unchecked() {
+		t.Errorf("expected no drift, got %+v", inSync)
+	}
+
+	if !unknown.Unchecked() || unknown.Drifted() {
+		t.Errorf("expected an unchecked node without drift, got %+v", unknown)
+	}
+
+	report.Nodes = append(report.Nodes, drifted, unchecked, unknown)
+	if !report.Drifted() || !report.Unchecked() {
+		t.Error("expected drift and unchecked nodes")
+	}
+
+	if got := drifted.Drifts(); len(got) != 2 || got[0] != "config: v1alpha1 changed (NO_REBOOT)" || got[1] != "kubernetes: running 1.34.0, desired 1.35.0" {
+		t.Errorf("unexpected drifts: %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid junit XML: %v\n%s", err, buf.String())
+	}
+
+	suite := suites.Suites[0]
+	if suite.Tests != 4 || suite.Failures != 2 || suite.Errors != 1 {
+		t.Errorf("expected 4 tests, 2 failures and 1 error, got %d, %d and %d", suite.Tests, suite.Failures, suite.Errors)
+	}
+
+	if suite.Cases[0].Failure != nil || suite.Cases[0].Error != nil {
+		t.Error("expected cp1 to pass")
+	}
+
+	if suite.Cases[1].Failure == nil || !strings.Contains(suite.Cases[1].Failure.Text, "kubernetes: running 1.34.0") {
+		t.Errorf("expected w1 to fail, got %+v", suite.Cases[1])
+	}
+
+	if suite.Cases[2].Error == nil || suite.Cases[2].Error.Text != "node: connection refused" {
+		t.Errorf("expected w2 to error, got %+v", suite.Cases[2])
+	}
+
+	if suite.Cases[3].Failure == nil || suite.Cases[3].Failure.Text != "talos: running value unknown" {
+		t.Errorf("expected w3 to fail, got %+v", suite.Cases[3])
+	}
+}
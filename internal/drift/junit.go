@@ -0,0 +1,95 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package drift
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	Failure   *junitResult `xml:"failure,omitempty"`
+	Error     *junitResult `xml:"error,omitempty"`
+	SystemErr string       `xml:"system-err,omitempty"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite with one test case
+// per node. A drifted node or one with unknown running values fails, a node
+// that couldn't be checked errors; the errors of a failed node are kept in its
+// system-err.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{Name: "topf drift " + r.Cluster, Tests: len(r.Nodes)}
+
+	for _, n := range r.Nodes {
+		tc := junitTestCase{Name: n.Host, ClassName: r.Cluster + "." + n.Role}
+
+		drifts := n.Drifts()
+
+		switch {
+		case len(drifts) > 0:
+			suite.Failures++
+			tc.Failure = &junitResult{
+				Message: fmt.Sprintf("%d drift(s) found", len(drifts)),
+				Type:    "drift",
+				Text:    strings.Join(append(drifts, n.Unknown...), "\n"),
+			}
+			tc.SystemErr = strings.Join(n.Errors, "\n")
+		case len(n.Unknown) > 0:
+			suite.Failures++
+			tc.Failure = &junitResult{
+				Message: fmt.Sprintf("%d check(s) unknown", len(n.Unknown)),
+				Type:    "unknown",
+				Text:    strings.Join(n.Unknown, "\n"),
+			}
+			tc.SystemErr = strings.Join(n.Errors, "\n")
+		case len(n.Errors) > 0:
+			suite.Errors++
+			tc.Error = &junitResult{
+				Message: "could not check drift",
+				Type:    "unchecked",
+				Text:    strings.Join(n.Errors, "\n"),
+			}
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return fmt.Errorf("encoding junit report: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/go-retry/retry"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/client"
+)
+
+// recovery describes the snapshot etcd is bootstrapped from
+type recovery struct {
+	snapshotPath  string
+	skipHashCheck bool
+}
+
+// Bootstrap initiates the ETCD bootstrap process on a control-plane node
+func Bootstrap(ctx context.Context, logger *slog.Logger, node *topf.Node) error {
+	logger.Info("starting bootstrap process", "timeout", "10 minutes")
+
+	alreadyBootstrapped, err := bootstrap(ctx, logger, node, nil)
+	if err != nil {
+		return fmt.Errorf("bootstrap failed: %w", err)
+	}
+
+	if !alreadyBootstrapped {
+		logger.Info("etcd bootstrap completed successfully")
+	}
+
+	return nil
+}
+
+// bootstrap retries tryBootstrap for up to 10 minutes. With a recovery, each
+// attempt uploads the snapshot first, which may take longer than a plain
+// bootstrap request.
+func bootstrap(ctx context.Context, logger *slog.Logger, node *topf.Node, rec *recovery) (alreadyBootstrapped bool, err error) {
+	attemptTimeout := 15 * time.Second
+	if rec != nil {
+		attemptTimeout = 5 * time.Minute
+	}
+
+	err = retry.Constant(time.Minute*10,
+		retry.WithUnits(time.Second*5),
+		retry.WithAttemptTimeout(attemptTimeout),
+		retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug)),
+	).RetryWithContext(ctx, telemetry.Retried("etcd-bootstrap", func(ctx context.Context) error {
+		bootstrapped, err := tryBootstrap(ctx, logger, node, rec)
+		if err != nil {
+			return err
+		}
+
+		alreadyBootstrapped = bootstrapped
+
+		return nil
+	}))
+
+	return alreadyBootstrapped, err
+}
+
+// tryBootstrap attempts a single bootstrap operation, recovering from a
+// snapshot if rec is set.
+// Returns (true, nil) if already bootstrapped, (false, nil) if bootstrap succeeded,
+// or a retryable error if bootstrap should be retried.
+func tryBootstrap(ctx context.Context, logger *slog.Logger, node *topf.Node, rec *recovery) (alreadyBootstrapped bool, err error) {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return false, retry.ExpectedErrorf("couldn't get client for bootstrap: %w", err)
+	}
+	defer nodeClient.Close()
+
+	etcdState, err := getEtcdState(ctx, nodeClient)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Debug("etcd service state", "state", etcdState)
+
+	switch etcdState {
+	case "Preparing", "Waiting":
+		logger.Info("etcd is not yet running, attempting bootstrap", "state", etcdState)
+
+		req := &machine.BootstrapRequest{}
+
+		if rec != nil {
+			if err := uploadSnapshot(ctx, logger, nodeClient, rec.snapshotPath); err != nil {
+				return false, retry.ExpectedError(err)
+			}
+
+			req.RecoverEtcd = true
+			req.RecoverSkipHashCheck = rec.skipHashCheck
+		}
+
+		if _, err = nodeClient.MachineClient.Bootstrap(ctx, req); err != nil {
+			return false, retry.ExpectedError(err)
+		}
+
+		return false, nil
+
+	case "Running":
+		if memberCount := getEtcdMemberCount(ctx, nodeClient); memberCount > 0 {
+			logger.Info("etcd already bootstrapped", "member_count", memberCount)
+			return true, nil
+		}
+	}
+
+	return false, retry.ExpectedErrorf("etcd not ready for bootstrap, state: %s", etcdState)
+}
+
+// uploadSnapshot uploads a snapshot to the node for a subsequent recovery
+// bootstrap.
+func uploadSnapshot(ctx context.Context, logger *slog.Logger, c *client.Client, path string) error {
+	f, err := os.Open(path) //nolint:gosec // reading a user-provided snapshot is by design
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	logger.Info("uploading etcd snapshot", "path", path)
+
+	resp, err := c.EtcdRecover(ctx, f)
+	if err != nil {
+		return fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+
+	logger.Debug("etcd snapshot uploaded", "response", resp)
+
+	return nil
+}
+
+func getEtcdState(ctx context.Context, c *client.Client) (string, error) {
+	etcdSvc, err := c.ServiceInfo(ctx, "etcd")
+	if err != nil {
+		return "", retry.ExpectedErrorf("couldn't get etcd service info: %w", err)
+	}
+
+	if len(etcdSvc) > 0 && etcdSvc[0].Service != nil {
+		return etcdSvc[0].Service.GetState(), nil
+	}
+
+	return "", nil
+}
+
+func getEtcdMemberCount(ctx context.Context, c *client.Client) int {
+	resp, err := c.MachineClient.EtcdMemberList(ctx, &machine.EtcdMemberListRequest{})
+	if err != nil {
+		return 0
+	}
+
+	for _, msg := range resp.GetMessages() {
+		if count := len(msg.GetMembers()); count > 0 {
+			return count
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/nodepool"
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/go-retry/retry"
+	"github.com/siderolabs/talos/pkg/machinery/client"
+)
+
+// DefragOptions contains the options to defragment etcd
+type DefragOptions struct {
+	// Rolling defragments one member at a time and waits for it to report a
+	// healthy status before moving on to the next one
+	Rolling bool
+}
+
+// Defrag defragments the etcd members of the selected control-plane nodes to
+// reclaim the space freed by compaction. A member blocks reads and writes
+// while it is defragmented, which is why the rolling mode is the default of
+// the command.
+func Defrag(ctx context.Context, t topf.Topf, opts DefragOptions) error {
+	logger := t.Logger().With("command", "etcd defrag")
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	var controlPlanes []*topf.Node
+
+	for _, node := range nodes {
+		if node.Node.Role != config.RoleControlPlane {
+			continue
+		}
+
+		if node.Error != nil {
+			return fmt.Errorf("node %s: %w", node.Node.Host, node.Error)
+		}
+
+		controlPlanes = append(controlPlanes, node)
+	}
+
+	if len(controlPlanes) == 0 {
+		return errors.New("no control-plane node selected to defragment")
+	}
+
+	if t.Confirm() {
+		if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to defragment etcd on %d control-plane node(s)? Each member blocks reads and writes while it is defragmented.", len(controlPlanes))) == 'n' {
+			logger.Info("skipping defragmentation")
+			return nil
+		}
+	}
+
+	concurrency := len(controlPlanes)
+	if opts.Rolling {
+		concurrency = 1
+	}
+
+	return nodepool.RunConcurrent(ctx, controlPlanes, concurrency, func(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
+		if err := defragNode(ctx, node, opts.Rolling, logger); err != nil {
+			return fmt.Errorf("node %s: %w", node.Node.Host, err)
+		}
+
+		return nil
+	}, logger)
+}
+
+func defragNode(ctx context.Context, node *topf.Node, waitHealthy bool, logger *slog.Logger) error {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	before, _, err := dbSize(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("defragmenting etcd member", "db_size", before)
+
+	if _, err := nodeClient.EtcdDefragment(ctx); err != nil {
+		return fmt.Errorf("failed to defragment etcd: %w", err)
+	}
+
+	if waitHealthy {
+		err := retry.Constant(time.Minute,
+			retry.WithUnits(time.Second*2),
+			retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug)),
+		).RetryWithContext(ctx, telemetry.Retried("etcd-defrag", func(ctx context.Context) error {
+			if _, _, err := dbSize(ctx, nodeClient); err != nil {
+				return retry.ExpectedError(err)
+			}
+
+			return nil
+		}))
+		if err != nil {
+			return fmt.Errorf("etcd member didn't become healthy after defragmentation: %w", err)
+		}
+	}
+
+	after, inUse, err := dbSize(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("etcd member defragmented", "db_size_before", before, "db_size", after, "db_size_in_use", inUse)
+
+	return nil
+}
+
+// dbSize returns the database size and the size in use reported by the etcd
+// member of a node, failing if the member reports errors
+func dbSize(ctx context.Context, c *client.Client) (size, inUse int64, err error) {
+	status, err := c.EtcdStatus(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get etcd status: %w", err)
+	}
+
+	for _, msg := range status.GetMessages() {
+		memberStatus := msg.GetMemberStatus()
+		if errs := memberStatus.GetErrors(); len(errs) > 0 {
+			return 0, 0, fmt.Errorf("etcd member errors: %s", strings.Join(errs, ", "))
+		}
+
+		size, inUse = memberStatus.GetDbSize(), memberStatus.GetDbSizeInUse()
+	}
+
+	return size, inUse, nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/client"
+)
+
+// Member is an etcd member, mapped to the topf node it runs on
+type Member struct {
+	ID         string   `yaml:"id"`
+	Name       string   `yaml:"name"`
+	Host       string   `yaml:"host,omitempty"`
+	PeerURLs   []string `yaml:"peerUrls"`
+	ClientURLs []string `yaml:"clientUrls"`
+	Learner    bool     `yaml:"learner"`
+	Leader     bool     `yaml:"leader"`
+
+	id uint64
+}
+
+// FormatMemberID formats a member ID the way etcd and talosctl display it
+func FormatMemberID(id uint64) string {
+	return strconv.FormatUint(id, 16)
+}
+
+// Members lists the etcd members as seen by the first reachable control-plane
+// node, mapping each member to the topf host it runs on.
+func Members(ctx context.Context, t topf.Topf) ([]Member, error) {
+	cpClient, err := t.ControlPlaneClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cpClient.Close()
+
+	return listMembers(ctx, cpClient, t.Config().Nodes)
+}
+
+func listMembers(ctx context.Context, c *client.Client, nodes []config.Node) ([]Member, error) {
+	resp, err := c.EtcdMemberList(ctx, &machine.EtcdMemberListRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd members: %w", err)
+	}
+
+	var leader uint64
+
+	// the leader is optional information, the member list works without it
+	if status, err := c.EtcdStatus(ctx); err == nil {
+		for _, msg := range status.GetMessages() {
+			leader = msg.GetMemberStatus().GetLeader()
+		}
+	}
+
+	var members []Member
+
+	for _, msg := range resp.GetMessages() {
+		for _, m := range msg.GetMembers() {
+			members = append(members, Member{
+				ID:         FormatMemberID(m.GetId()),
+				Name:       m.GetHostname(),
+				Host:       matchHost(m, nodes),
+				PeerURLs:   m.GetPeerUrls(),
+				ClientURLs: m.GetClientUrls(),
+				Learner:    m.GetIsLearner(),
+				Leader:     m.GetId() == leader,
+				id:         m.GetId(),
+			})
+		}
+	}
+
+	return members, nil
+}
+
+// matchHost returns the topf host of the control-plane node an etcd member
+// runs on, matching the member name against the host (or its short name) and
+// the peer URLs against the host and IP. It returns an empty string for
+// members unknown to topf.yaml.
+func matchHost(member *machine.EtcdMember, nodes []config.Node) string {
+	peerHosts := map[string]bool{}
+
+	for _, peerURL := range member.GetPeerUrls() {
+		if u, err := url.Parse(peerURL); err == nil {
+			peerHosts[u.Hostname()] = true
+		}
+	}
+
+	for _, node := range nodes {
+		if node.Role != config.RoleControlPlane {
+			continue
+		}
+
+		shortName, _, _ := strings.Cut(node.Host, ".")
+
+		switch {
+		case member.GetHostname() == node.Host, member.GetHostname() == shortName:
+			return node.Host
+		case peerHosts[node.Host], node.IP != nil && peerHosts[node.IP.String()]:
+			return node.Host
+		}
+	}
+
+	return ""
+}
+
+// findMember returns the member identified by a topf host, a member name or
+// a hexadecimal member ID.
+func findMember(members []Member, target string) (Member, error) {
+	for _, m := range members {
+		if m.Host == target || m.Name == target || m.ID == strings.ToLower(target) {
+			return m, nil
+		}
+	}
+
+	return Member{}, fmt.Errorf("no etcd member found for %q", target)
+}
+
+// RemoveMember removes the etcd member identified by a topf host, member name
+// or member ID, typically of a control-plane node that was lost or replaced.
+// The removal is issued through another control-plane node, since a member
+// can't remove itself.
+func RemoveMember(ctx context.Context, t topf.Topf, target string) error {
+	logger := t.Logger().With("command", "etcd remove-member")
+
+	members, err := Members(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	member, err := findMember(members, target)
+	if err != nil {
+		return err
+	}
+
+	logger = logger.With("member_id", member.ID, "member_name", member.Name)
+
+	if t.Confirm() {
+		if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to remove etcd member %s (%s)? If its node still runs etcd, reset it first or use \"etcd leave\".", member.Name, member.ID)) == 'n' {
+			logger.Info("skipping removal")
+			return nil
+		}
+	}
+
+	if err := removeMember(ctx, t, member, logger); err != nil {
+		return err
+	}
+
+	logger.Info("etcd member removed")
+
+	return nil
+}
+
+// removeMember removes a member through a control-plane node other than the
+// one it runs on
+func removeMember(ctx context.Context, t topf.Topf, member Member, logger *slog.Logger) error {
+	c, err := clientExcluding(ctx, t, member.Host, logger)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.EtcdRemoveMemberByID(ctx, &machine.EtcdRemoveMemberByIDRequest{MemberId: member.id}); err != nil {
+		return fmt.Errorf("failed to remove etcd member %s: %w", member.ID, err)
+	}
+
+	return nil
+}
+
+// clientExcluding returns a client connected to a reachable control-plane node
+// other than host.
+func clientExcluding(ctx context.Context, t topf.Topf, host string, logger *slog.Logger) (*client.Client, error) {
+	for _, node := range t.ControlPlaneNodes() {
+		if node.Node.Host == host {
+			continue
+		}
+
+		c, err := node.Client(ctx)
+		if err != nil {
+			logger.Warn("failed to connect to control-plane node, trying next", "node", node.Node.Host, "error", err)
+			continue
+		}
+
+		return c, nil
+	}
+
+	return nil, errors.New("no other reachable control-plane node available")
+}
+
+// Leave makes the etcd member of a control-plane node leave the cluster
+// gracefully, e.g. before the node is decommissioned. etcd stops on the node
+// afterwards.
+func Leave(ctx context.Context, t topf.Topf, host string) error {
+	logger := t.Logger().With("command", "etcd leave", "node", host)
+
+	node, err := controlPlaneNode(t, host)
+	if err != nil {
+		return err
+	}
+
+	if t.Confirm() {
+		if interactive.ConfirmPrompt(fmt.Sprintf("Do you want node %s to leave the etcd cluster? etcd stops on the node until it is reset.", host)) == 'n' {
+			logger.Info("skipping leave")
+			return nil
+		}
+	}
+
+	if err := leaveCluster(ctx, node); err != nil {
+		return err
+	}
+
+	logger.Info("node left the etcd cluster")
+
+	return nil
+}
+
+func leaveCluster(ctx context.Context, node *topf.Node) error {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	if err := nodeClient.EtcdLeaveCluster(ctx, &machine.EtcdLeaveClusterRequest{}); err != nil {
+		return fmt.Errorf("failed to leave etcd cluster: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveNode removes the etcd member of a control-plane node that is being
+// decommissioned, and refuses to if the remaining members couldn't keep
+// quorum, unless allowQuorumLoss is set. The member leaves the cluster
+// gracefully through its own node; if that fails, it is removed through
+// another control-plane node. It returns the ID of the removed member, or an
+// empty string if the node doesn't run an etcd member.
+func RemoveNode(ctx context.Context, t topf.Topf, node *topf.Node, allowQuorumLoss bool, logger *slog.Logger) (string, error) {
+	health, err := ClusterHealth(ctx, t)
+	if err != nil {
+		return "", err
+	}
+
+	idx := slices.IndexFunc(health.Members, func(m Member) bool { return m.Host == node.Node.Host })
+	if idx < 0 {
+		logger.Info("node doesn't run an etcd member")
+		return "", nil
+	}
+
+	member := health.Members[idx]
+
+	if err := health.CheckQuorum([]string{node.Node.Host}, true); err != nil {
+		if !allowQuorumLoss {
+			return "", fmt.Errorf("refusing to remove etcd member %s: %w (use --i-know-quorum-will-be-lost to proceed anyway)", member.ID, err)
+		}
+
+		logger.Warn("removing etcd member although etcd loses quorum", "member_id", member.ID, "error", err)
+	}
+
+	logger.Info("removing etcd member", "member_id", member.ID)
+
+	if err := leaveCluster(ctx, node); err != nil {
+		logger.Warn("etcd member couldn't leave the cluster, removing it through another control-plane node", "error", err)
+
+		if err := removeMember(ctx, t, member, logger); err != nil {
+			return "", err
+		}
+	}
+
+	logger.Info("etcd member removed", "member_id", member.ID)
+
+	return member.ID, nil
+}
+
+// controlPlaneNode returns the control-plane node with the given host
+func controlPlaneNode(t topf.Topf, host string) (*topf.Node, error) {
+	for _, node := range t.ControlPlaneNodes() {
+		if node.Node.Host == host {
+			return node, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no control-plane node %q in topf.yaml", host)
+}
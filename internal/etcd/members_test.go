@@ -0,0 +1,63 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+)
+
+func TestMatchHost(t *testing.T) {
+	cp2IP := netip.MustParseAddr("10.0.0.2")
+
+	nodes := []config.Node{
+		{Host: "cp1.example.com", Role: config.RoleControlPlane},
+		{Host: "cp2.example.com", IP: &cp2IP, Role: config.RoleControlPlane},
+		{Host: "worker1", Role: config.RoleWorker},
+	}
+
+	tests := []struct {
+		name   string
+		member *machine.EtcdMember
+		want   string
+	}{
+		{"hostname", &machine.EtcdMember{Hostname: "cp1.example.com"}, "cp1.example.com"},
+		{"short name", &machine.EtcdMember{Hostname: "cp1"}, "cp1.example.com"},
+		{"peer ip", &machine.EtcdMember{Hostname: "talos-abc", PeerUrls: []string{"https://10.0.0.2:2380"}}, "cp2.example.com"},
+		{"worker", &machine.EtcdMember{Hostname: "worker1"}, ""},
+		{"unknown", &machine.EtcdMember{Hostname: "cp9", PeerUrls: []string{"https://10.0.0.9:2380"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchHost(tt.member, nodes); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFindMember(t *testing.T) {
+	members := []Member{
+		{ID: FormatMemberID(0xabc), Name: "cp1", Host: "cp1.example.com"},
+		{ID: FormatMemberID(0xdef), Name: "talos-xyz"},
+	}
+
+	for _, target := range []string{"cp1.example.com", "cp1", "ABC"} {
+		if m, err := findMember(members, target); err != nil || m.ID != "abc" {
+			t.Errorf("%s: expected member abc, got %+v, %v", target, m, err)
+		}
+	}
+
+	if m, err := findMember(members, "talos-xyz"); err != nil || m.ID != "def" {
+		t.Errorf("expected member def, got %+v, %v", m, err)
+	}
+
+	if _, err := findMember(members, "cp2"); err == nil {
+		t.Error("expected an error for an unknown member")
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/postfinance/topf/internal/topf"
+)
+
+// ErrQuorumLoss is returned when an operation would leave etcd without quorum
+var ErrQuorumLoss = errors.New("etcd would lose quorum")
+
+// Quorum returns the number of healthy voting members an etcd cluster of the
+// given number of voting members needs to make progress
+func Quorum(voters int) int {
+	return voters/2 + 1
+}
+
+// Health is a point-in-time view of the etcd cluster: its members and the IDs
+// of those reporting a healthy status
+type Health struct {
+	Members []Member
+	Healthy map[string]bool
+}
+
+// ClusterHealth lists the etcd members and queries the status of the member
+// of every control-plane node. Members whose node is unreachable or reports
+// errors are unhealthy.
+func ClusterHealth(ctx context.Context, t topf.Topf) (*Health, error) {
+	members, err := Members(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	health := &Health{Members: members, Healthy: map[string]bool{}}
+
+	for _, node := range t.ControlPlaneNodes() {
+		if id, err := healthyMemberID(ctx, node); err == nil {
+			health.Healthy[id] = true
+		}
+	}
+
+	return health, nil
+}
+
+// healthyMemberID returns the ID of the etcd member of a node, if it reports
+// a healthy status with a leader
+func healthyMemberID(ctx context.Context, node *topf.Node) (string, error) {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer nodeClient.Close()
+
+	status, err := nodeClient.EtcdStatus(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, msg := range status.GetMessages() {
+		memberStatus := msg.GetMemberStatus()
+		if errs := memberStatus.GetErrors(); len(errs) > 0 {
+			return "", fmt.Errorf("etcd member errors: %s", strings.Join(errs, ", "))
+		}
+
+		if memberStatus.GetLeader() == 0 {
+			return "", errors.New("etcd member has no leader")
+		}
+
+		return FormatMemberID(memberStatus.GetMemberId()), nil
+	}
+
+	return "", errors.New("no etcd status received")
+}
+
+// CheckQuorum returns an error wrapping ErrQuorumLoss if etcd would lose
+// quorum while the members running on hosts are down. With remove, the
+// members are removed from the cluster instead, which also lowers the quorum.
+// Learners don't vote and are ignored.
+func (h *Health) CheckQuorum(hosts []string, remove bool) error {
+	var voters, healthy int
+
+	for _, m := range h.Members {
+		if m.Learner {
+			continue
+		}
+
+		if m.Host != "" && slices.Contains(hosts, m.Host) {
+			if !remove {
+				voters++
+			}
+
+			continue
+		}
+
+		voters++
+
+		if h.Healthy[m.ID] {
+			healthy++
+		}
+	}
+
+	if need := Quorum(voters); healthy < need {
+		return fmt.Errorf("%w: %d of %d voting members would remain healthy, %d are required", ErrQuorumLoss, healthy, voters, need)
+	}
+
+	return nil
+}
+
+// GuardQuorum refuses a destructive operation on the control-plane nodes on
+// hosts if etcd would lose quorum while they are down, or once their members
+// are removed with remove. It also refuses if the etcd health can't be
+// determined, as that is when the operation is most likely to break quorum.
+// With allowLoss, both are only logged.
+func GuardQuorum(ctx context.Context, t topf.Topf, hosts []string, remove, allowLoss bool, logger *slog.Logger) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	health, err := ClusterHealth(ctx, t)
+
+	return guard(health, err, hosts, remove, allowLoss, logger)
+}
+
+// guard decides on the operation of GuardQuorum given the etcd health, or
+// the error determining it
+func guard(health *Health, healthErr error, hosts []string, remove, allowLoss bool, logger *slog.Logger) error {
+	var err error
+	if healthErr != nil {
+		err = fmt.Errorf("cannot verify etcd quorum: %w", healthErr)
+	} else {
+		err = health.CheckQuorum(hosts, remove)
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case allowLoss:
+		logger.Warn("proceeding although etcd may lose quorum", "nodes", hosts, "error", err)
+		return nil
+	default:
+		return fmt.Errorf("refusing to touch %s: %w (use --i-know-quorum-will-be-lost to proceed anyway)", strings.Join(hosts, ", "), err)
+	}
+}
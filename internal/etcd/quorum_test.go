@@ -0,0 +1,97 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCheckQuorum(t *testing.T) {
+	members := []Member{
+		{ID: "1", Host: "cp1"},
+		{ID: "2", Host: "cp2"},
+		{ID: "3", Host: "cp3"},
+		{ID: "4", Host: "cp4", Learner: true},
+	}
+
+	tests := []struct {
+		name    string
+		healthy []string
+		hosts   []string
+		remove  bool
+		wantErr bool
+	}{
+		{name: "one down of three", healthy: []string{"1", "2", "3"}, hosts: []string{"cp1"}},
+		{name: "two down of three", healthy: []string{"1", "2", "3"}, hosts: []string{"cp1", "cp2"}, wantErr: true},
+		{name: "one down with an unhealthy member", healthy: []string{"1", "2"}, hosts: []string{"cp1"}, wantErr: true},
+		{name: "remove one of three", healthy: []string{"1", "2", "3"}, hosts: []string{"cp1"}, remove: true},
+		{name: "remove with an unhealthy member", healthy: []string{"1", "2"}, hosts: []string{"cp1"}, remove: true, wantErr: true},
+		{name: "learner is ignored", healthy: []string{"1", "2", "3"}, hosts: []string{"cp4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := &Health{Members: members, Healthy: map[string]bool{}}
+			for _, id := range tt.healthy {
+				health.Healthy[id] = true
+			}
+
+			err := health.CheckQuorum(tt.hosts, tt.remove)
+
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrQuorumLoss):
+				t.Errorf("expected quorum loss, got: %v", err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	for voters, want := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 3} {
+		if got := Quorum(voters); got != want {
+			t.Errorf("Quorum(%d) = %d, want %d", voters, got, want)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	healthy := &Health{
+		Members: []Member{{ID: "1", Host: "cp1"}, {ID: "2", Host: "cp2"}, {ID: "3", Host: "cp3"}},
+		Healthy: map[string]bool{"1": true, "2": true, "3": true},
+	}
+	unreachable := errors.New("context deadline exceeded")
+
+	tests := []struct {
+		name      string
+		health    *Health
+		healthErr error
+		hosts     []string
+		allowLoss bool
+		want      string
+	}{
+		{name: "quorum kept", health: healthy, hosts: []string{"cp1"}},
+		{name: "quorum lost", health: healthy, hosts: []string{"cp1", "cp2"}, want: "etcd would lose quorum"},
+		{name: "quorum loss allowed", health: healthy, hosts: []string{"cp1", "cp2"}, allowLoss: true},
+		{name: "health unknown", healthErr: unreachable, hosts: []string{"cp1"}, want: "cannot verify etcd quorum: context deadline exceeded"},
+		{name: "health unknown allowed", healthErr: unreachable, hosts: []string{"cp1"}, allowLoss: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := guard(tt.health, tt.healthErr, tt.hosts, false, tt.allowLoss, slog.New(slog.DiscardHandler))
+
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
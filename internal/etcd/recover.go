@@ -0,0 +1,156 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/postfinance/topf/internal/interactive"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// RecoverOptions contains the options to recover etcd from a snapshot
+type RecoverOptions struct {
+	// SnapshotPath is the snapshot to recover from
+	SnapshotPath string
+
+	// SkipHashCheck disables the integrity check of the snapshot by etcd. It
+	// is required for a database file copied from an etcd data directory, as
+	// opposed to a snapshot taken with the snapshot API.
+	SkipHashCheck bool
+}
+
+// Recover bootstraps etcd from a snapshot on the first selected control-plane
+// node: the snapshot is uploaded through the EtcdRecover API, then etcd is
+// bootstrapped with RecoverEtcd set. etcd must not be running on the node,
+// i.e. the node must wait for a bootstrap like a fresh control-plane node.
+func Recover(ctx context.Context, t topf.Topf, opts RecoverOptions) error {
+	logger := t.Logger().With("command", "etcd recover")
+
+	verified, err := VerifyChecksum(opts.SnapshotPath)
+	if err != nil {
+		return err
+	}
+
+	if verified {
+		logger.Info("snapshot checksum verified", "path", opts.SnapshotPath)
+	} else {
+		logger.Warn("no checksum file found next to the snapshot, skipping verification", "path", opts.SnapshotPath)
+	}
+
+	nodes, err := t.FilteredNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(nodes, func(n *topf.Node) bool { return n.Node.Role == config.RoleControlPlane })
+	if idx < 0 {
+		return errors.New("no control-plane node selected to recover etcd on")
+	}
+
+	node := nodes[idx]
+	logger = logger.With(node.Attrs())
+
+	if node.Error != nil {
+		return fmt.Errorf("node %s: %w", node.Node.Host, node.Error)
+	}
+
+	if err := checkEtcdNotRunning(ctx, node); err != nil {
+		return err
+	}
+
+	if t.Confirm() {
+		if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to recover etcd on node %s from snapshot %s? This bootstraps a new etcd cluster with the snapshot's data.", node.Node.Host, opts.SnapshotPath)) == 'n' {
+			logger.Info("skipping recovery")
+			return nil
+		}
+	}
+
+	logger.Info("starting recovery", "snapshot", opts.SnapshotPath, "timeout", "10 minutes")
+
+	if _, err := bootstrap(ctx, logger, node, &recovery{snapshotPath: opts.SnapshotPath, skipHashCheck: opts.SkipHashCheck}); err != nil {
+		return fmt.Errorf("recovery failed: %w", err)
+	}
+
+	logger.Info("etcd recovered from snapshot, waiting for the node to stabilize")
+
+	if err := node.Stabilize(ctx, logger, time.Second*30); err != nil {
+		return fmt.Errorf("node didn't stabilize: %w", err)
+	}
+
+	logger.Info("etcd recovery completed, the other control-plane nodes join the new cluster once their etcd is reset")
+
+	return nil
+}
+
+// checkEtcdNotRunning refuses to recover on a node that already runs an etcd
+// member, which would otherwise be reported as "already bootstrapped".
+func checkEtcdNotRunning(ctx context.Context, node *topf.Node) error {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	etcdState, err := getEtcdState(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	if etcdState == "Running" && getEtcdMemberCount(ctx, nodeClient) > 0 {
+		return fmt.Errorf("etcd is already running on node %s: reset its EPHEMERAL partition before recovering", node.Node.Host)
+	}
+
+	return nil
+}
+
+// VerifyChecksum compares the SHA-256 checksum of a snapshot with its
+// checksum file (path + ChecksumSuffix), if present. It returns whether a
+// checksum file was found.
+func VerifyChecksum(path string) (bool, error) {
+	checksumFile, err := os.Open(path + ChecksumSuffix) //nolint:gosec // the checksum file lives next to the user-provided snapshot
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to read snapshot checksum: %w", err)
+	}
+	defer checksumFile.Close()
+
+	line, err := bufio.NewReader(checksumFile).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read snapshot checksum: %w", err)
+	}
+
+	expected, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+	snapshot, err := os.Open(path) //nolint:gosec // reading a user-provided snapshot is by design
+	if err != nil {
+		return false, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer snapshot.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, snapshot); err != nil {
+		return false, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return false, fmt.Errorf("snapshot checksum mismatch: expected %s, got %s", expected, actual)
+	}
+
+	return true, nil
+}
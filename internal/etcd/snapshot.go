@@ -0,0 +1,125 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package etcd contains the logic to manage the etcd cluster formed by the
+// control-plane nodes
+package etcd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+)
+
+// ChecksumSuffix is appended to the snapshot file name to name its checksum
+// file, which uses the sha256sum format
+const ChecksumSuffix = ".sha256"
+
+// SnapshotResult describes an etcd snapshot written to disk
+type SnapshotResult struct {
+	Path   string
+	SHA256 string
+	Size   int64
+}
+
+// SnapshotFileName returns the timestamped name of a snapshot file, e.g.
+// mycluster-etcd-20260102T150405Z.snapshot
+func SnapshotFileName(clusterName string, now time.Time) string {
+	return fmt.Sprintf("%s-etcd-%s.snapshot", clusterName, now.UTC().Format("20060102T150405Z"))
+}
+
+// Snapshot streams an etcd snapshot from a control-plane node into a
+// timestamped file in dir, and writes its SHA-256 checksum next to it. The
+// snapshot only becomes visible under its final name once it was fully
+// received.
+func Snapshot(ctx context.Context, t topf.Topf, dir string, logger *slog.Logger) (*SnapshotResult, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	cpClient, err := t.ControlPlaneClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cpClient.Close()
+
+	path := filepath.Join(dir, SnapshotFileName(t.Config().ClusterName, time.Now()))
+
+	logger.Info("taking etcd snapshot", "path", path)
+
+	stream, err := cpClient.EtcdSnapshot(ctx, &machine.EtcdSnapshotRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start etcd snapshot: %w", err)
+	}
+	defer stream.Close()
+
+	result, err := writeSnapshot(stream, path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("etcd snapshot saved", "path", result.Path, "size", result.Size, "sha256", result.SHA256)
+
+	return result, nil
+}
+
+// writeSnapshot writes the snapshot read from r to path and its checksum to
+// path + ChecksumSuffix. Snapshots contain all cluster secrets, so both files
+// are only readable by the owner.
+func writeSnapshot(r io.Reader, path string) (*SnapshotResult, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".part*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op once renamed
+
+	hash := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		tmp.Close() //nolint:errcheck,gosec // the read error takes precedence
+		return nil, fmt.Errorf("failed to receive etcd snapshot: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	if size == 0 {
+		return nil, errors.New("received an empty etcd snapshot")
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	result := &SnapshotResult{Path: path, SHA256: hex.EncodeToString(hash.Sum(nil)), Size: size}
+
+	checksum := fmt.Sprintf("%s  %s\n", result.SHA256, filepath.Base(path))
+	if err := os.WriteFile(path+ChecksumSuffix, []byte(checksum), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot checksum: %w", err)
+	}
+
+	return result, nil
+}
+
+// Backup takes an etcd snapshot into dir before control-plane nodes are
+// changed. Its error is meant to abort the operation.
+func Backup(ctx context.Context, t topf.Topf, dir string, logger *slog.Logger) error {
+	if _, err := Snapshot(ctx, t, dir, logger); err != nil {
+		return fmt.Errorf("etcd backup failed, aborting before touching control-plane nodes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package etcd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotFileName(t *testing.T) {
+	now := time.Date(2026, 1, 2, 16, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	if got := SnapshotFileName("mycluster", now); got != "mycluster-etcd-20260102T150405Z.snapshot" {
+		t.Errorf("unexpected file name: %s", got)
+	}
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.snapshot")
+
+	result, err := writeSnapshot(strings.NewReader("etcd data"), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sha256 of "etcd data"
+	const sum = "b16f1ec50761cc174d6f21e201c59bd3019d41101b2e18bae4e54e1f482fe382"
+
+	if result.Size != 9 || result.Path != path || result.SHA256 != sum {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "etcd data" {
+		t.Errorf("unexpected snapshot content %q: %v", content, err)
+	}
+
+	checksum, err := os.ReadFile(path + ChecksumSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(checksum) != sum+"  test.snapshot\n" {
+		t.Errorf("unexpected checksum file: %q", checksum)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected only the snapshot and its checksum, got %d files", len(entries))
+	}
+}
+
+func TestWriteSnapshotEmpty(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := writeSnapshot(strings.NewReader(""), filepath.Join(dir, "empty.snapshot")); err == nil {
+		t.Fatal("expected an error for an empty snapshot")
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no files to be left behind, got %d", len(entries))
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.snapshot")
+
+	if _, err := writeSnapshot(strings.NewReader("etcd data"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := VerifyChecksum(path)
+	if err != nil || !verified {
+		t.Fatalf("expected checksum to be verified, got %v, %v", verified, err)
+	}
+
+	if err := os.WriteFile(path, []byte("tampered"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyChecksum(path); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+
+	if err := os.Remove(path + ChecksumSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err = VerifyChecksum(path)
+	if err != nil || verified {
+		t.Errorf("expected no verification without checksum file, got %v, %v", verified, err)
+	}
+}
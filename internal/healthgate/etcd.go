@@ -0,0 +1,108 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package healthgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+)
+
+// etcdGate waits for every control-plane node to report a healthy etcd
+// member with a member list of one voting member per control-plane node
+type etcdGate struct {
+	timeout time.Duration
+}
+
+func (g *etcdGate) Name() string           { return "etcd" }
+func (g *etcdGate) Timeout() time.Duration { return g.timeout }
+
+func (g *etcdGate) Check(ctx context.Context, env *Env) error {
+	controlPlanes := env.ControlPlaneNodes()
+
+	var errs []error
+
+	for _, node := range controlPlanes {
+		if err := checkEtcdNode(ctx, node, len(controlPlanes)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", node.Node.Host, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkEtcdNode(ctx context.Context, node *topf.Node, expectedMembers int) error {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	status, err := nodeClient.EtcdStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("getting etcd status: %w", err)
+	}
+
+	for _, msg := range status.GetMessages() {
+		memberStatus := msg.GetMemberStatus()
+		if errs := memberStatus.GetErrors(); len(errs) > 0 {
+			return fmt.Errorf("etcd member errors: %s", strings.Join(errs, ", "))
+		}
+
+		if memberStatus.GetLeader() == 0 {
+			return errors.New("etcd member has no leader")
+		}
+	}
+
+	alarms, err := nodeClient.EtcdAlarmList(ctx)
+	if err != nil {
+		return fmt.Errorf("listing etcd alarms: %w", err)
+	}
+
+	for _, msg := range alarms.GetMessages() {
+		if memberAlarms := msg.GetMemberAlarms(); len(memberAlarms) > 0 {
+			return fmt.Errorf("etcd alarm %s raised on member %x", memberAlarms[0].GetAlarm(), memberAlarms[0].GetMemberId())
+		}
+	}
+
+	members, err := nodeClient.EtcdMemberList(ctx, &machine.EtcdMemberListRequest{})
+	if err != nil {
+		return fmt.Errorf("listing etcd members: %w", err)
+	}
+
+	for _, msg := range members.GetMessages() {
+		if err := checkEtcdMembers(msg.GetMembers(), expectedMembers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkEtcdMembers verifies that a member list contains the expected number of
+// voting members and no learners.
+func checkEtcdMembers(members []*machine.EtcdMember, expected int) error {
+	var learners []string
+
+	for _, member := range members {
+		if member.GetIsLearner() {
+			learners = append(learners, member.GetHostname())
+		}
+	}
+
+	if len(learners) > 0 {
+		return fmt.Errorf("etcd members still learning: %s", strings.Join(learners, ", "))
+	}
+
+	if len(members) != expected {
+		return fmt.Errorf("etcd has %d members, expected %d", len(members), expected)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package healthgate contains the health checks that must pass after each
+// node of a rolling operation before moving on to the next node
+package healthgate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/postfinance/topf/internal/k8s"
+	"github.com/postfinance/topf/internal/telemetry"
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/go-retry/retry"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkInterval is the delay between two attempts of a failing gate
+const checkInterval = 5 * time.Second
+
+// Gate is a health check evaluated after a node was changed
+type Gate interface {
+	// Name identifies the gate in logs and errors
+	Name() string
+
+	// Timeout is how long a failing check is retried
+	Timeout() time.Duration
+
+	// Check returns nil if the gate passes for the node of env
+	Check(ctx context.Context, env *Env) error
+}
+
+// Env gives gates access to the node being checked and the cluster. The
+// Kubernetes client and node name are resolved lazily, so that gates which
+// don't need them work while the Kubernetes API is unavailable.
+type Env struct {
+	t      topf.Topf
+	node   *topf.Node
+	logger *slog.Logger
+
+	clientset   kubernetes.Interface
+	k8sNodeName string
+}
+
+// Node returns the node being checked
+func (e *Env) Node() *topf.Node {
+	return e.node
+}
+
+// ControlPlaneNodes returns all control-plane nodes of the cluster
+func (e *Env) ControlPlaneNodes() []*topf.Node {
+	return e.t.ControlPlaneNodes()
+}
+
+// Clientset returns a Kubernetes clientset, created on first use
+func (e *Env) Clientset(ctx context.Context) (kubernetes.Interface, error) {
+	if e.clientset == nil {
+		clientset, err := k8s.NewClientset(ctx, e.t, e.logger)
+		if err != nil {
+			return nil, err
+		}
+
+		e.clientset = clientset
+	}
+
+	return e.clientset, nil
+}
+
+// K8sNodeName returns the Kubernetes node name of the node, resolved on first use
+func (e *Env) K8sNodeName(ctx context.Context) (string, error) {
+	if e.k8sNodeName == "" {
+		name, err := k8s.NodeName(ctx, e.node)
+		if err != nil {
+			return "", err
+		}
+
+		e.k8sNodeName = name
+	}
+
+	return e.k8sNodeName, nil
+}
+
+// Runner runs the health gates configured in topf.yaml. A nil *Runner has no
+// gates and passes immediately.
+type Runner struct {
+	t     topf.Topf
+	gates []Gate
+}
+
+// New returns a runner for the health gates configured in topf.yaml, or nil
+// if none are enabled.
+func New(t topf.Topf) *Runner {
+	gates := fromConfig(t.Config().HealthGates)
+	if len(gates) == 0 {
+		return nil
+	}
+
+	return &Runner{t: t, gates: gates}
+}
+
+// fromConfig builds the enabled gates, in the order they are run
+func fromConfig(cfg *config.HealthGates) []Gate {
+	if cfg == nil {
+		return nil
+	}
+
+	var gates []Gate
+
+	if cfg.NodeReady != nil && cfg.NodeReady.Enabled {
+		gates = append(gates, &nodeReadyGate{timeout: cfg.GateTimeout(cfg.NodeReady.Timeout)})
+	}
+
+	if cfg.DaemonSets != nil && cfg.DaemonSets.Enabled {
+		gates = append(gates, &daemonSetsGate{timeout: cfg.GateTimeout(cfg.DaemonSets.Timeout)})
+	}
+
+	if cfg.Etcd != nil && cfg.Etcd.Enabled {
+		gates = append(gates, &etcdGate{timeout: cfg.GateTimeout(cfg.Etcd.Timeout)})
+	}
+
+	for _, w := range cfg.Workloads {
+		gates = append(gates, &workloadGate{workload: w, timeout: cfg.GateTimeout(w.Timeout)})
+	}
+
+	return gates
+}
+
+// Wait runs all gates for a node one after the other. A failing gate is
+// retried until it passes or its timeout expires, which fails the node.
+// The provided logger is expected to already carry the node's attributes.
+func (r *Runner) Wait(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
+	if r == nil {
+		return nil
+	}
+
+	env := &Env{t: r.t, node: node, logger: logger}
+
+	for _, gate := range r.gates {
+		logger := logger.With("gate", gate.Name())
+		logger.Info("waiting for health gate", "timeout", gate.Timeout())
+
+		err := retry.Constant(gate.Timeout(),
+			retry.WithUnits(checkInterval),
+			retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug)),
+		).RetryWithContext(ctx, telemetry.Retried("health-gate/"+gate.Name(), func(ctx context.Context) error {
+			if err := gate.Check(ctx, env); err != nil {
+				return retry.ExpectedError(err)
+			}
+
+			return nil
+		}))
+		if err != nil {
+			return fmt.Errorf("health gate %s failed: %w", gate.Name(), err)
+		}
+
+		logger.Info("health gate passed")
+	}
+
+	return nil
+}
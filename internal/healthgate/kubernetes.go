@@ -0,0 +1,235 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package healthgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/postfinance/topf/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeReadyGate waits for the Kubernetes node to report Ready
+type nodeReadyGate struct {
+	timeout time.Duration
+}
+
+func (g *nodeReadyGate) Name() string           { return "nodeReady" }
+func (g *nodeReadyGate) Timeout() time.Duration { return g.timeout }
+
+func (g *nodeReadyGate) Check(ctx context.Context, env *Env) error {
+	clientset, err := env.Clientset(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodeName, err := env.K8sNodeName(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkNodeReady(ctx, clientset, nodeName)
+}
+
+func checkNodeReady(ctx context.Context, clientset kubernetes.Interface, nodeName string) error {
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting node %s: %w", nodeName, err)
+	}
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			if cond.Status != corev1.ConditionTrue {
+				return fmt.Errorf("node %s not ready: %s", nodeName, cond.Message)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("node %s has no Ready condition", nodeName)
+}
+
+// daemonSetsGate waits for every DaemonSet pod on the node to run the current
+// revision and be ready
+type daemonSetsGate struct {
+	timeout time.Duration
+}
+
+func (g *daemonSetsGate) Name() string           { return "daemonSets" }
+func (g *daemonSetsGate) Timeout() time.Duration { return g.timeout }
+
+func (g *daemonSetsGate) Check(ctx context.Context, env *Env) error {
+	clientset, err := env.Clientset(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodeName, err := env.K8sNodeName(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkDaemonSets(ctx, clientset, nodeName)
+}
+
+func checkDaemonSets(ctx context.Context, clientset kubernetes.Interface, nodeName string) error {
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("listing pods on node %s: %w", nodeName, err)
+	}
+
+	daemonSets := map[string]*appsv1.DaemonSet{}
+
+	var pending []string
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != "DaemonSet" || pod.Spec.NodeName != nodeName {
+			continue
+		}
+
+		key := pod.Namespace + "/" + owner.Name
+
+		ds, ok := daemonSets[key]
+		if !ok {
+			ds, err = clientset.AppsV1().DaemonSets(pod.Namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("getting daemonset %s: %w", key, err)
+			}
+
+			daemonSets[key] = ds
+		}
+
+		switch {
+		case !podOnCurrentRevision(ds, pod):
+			pending = append(pending, fmt.Sprintf("%s (outdated)", key))
+		case !podReady(pod):
+			pending = append(pending, fmt.Sprintf("%s (not ready)", key))
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("daemonset pods on node %s not rolled out: %s", nodeName, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+// podOnCurrentRevision reports whether a DaemonSet pod was created from the
+// current template of the DaemonSet. DaemonSets with the OnDelete strategy
+// never replace their pods, so any revision is current.
+func podOnCurrentRevision(ds *appsv1.DaemonSet, pod *corev1.Pod) bool {
+	if ds.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType {
+		return true
+	}
+
+	current, ok := ds.Annotations[appsv1.DeprecatedTemplateGeneration]
+	if !ok {
+		return true
+	}
+
+	return pod.Labels["pod-template-generation"] == current
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// workloadGate waits for a user-defined workload to have enough available
+// replicas
+type workloadGate struct {
+	workload config.WorkloadHealthGate
+	timeout  time.Duration
+}
+
+func (g *workloadGate) Name() string           { return g.workload.String() }
+func (g *workloadGate) Timeout() time.Duration { return g.timeout }
+
+func (g *workloadGate) Check(ctx context.Context, env *Env) error {
+	clientset, err := env.Clientset(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkWorkload(ctx, clientset, g.workload)
+}
+
+func checkWorkload(ctx context.Context, clientset kubernetes.Interface, w config.WorkloadHealthGate) error {
+	var (
+		desired, available   int32
+		generation, observed int64
+	)
+
+	switch w.Kind {
+	case config.WorkloadDeployment:
+		d, err := clientset.AppsV1().Deployments(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		desired, available = replicas(d.Spec.Replicas), d.Status.AvailableReplicas
+		generation, observed = d.Generation, d.Status.ObservedGeneration
+	case config.WorkloadStatefulSet:
+		s, err := clientset.AppsV1().StatefulSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		desired, available = replicas(s.Spec.Replicas), s.Status.AvailableReplicas
+		generation, observed = s.Generation, s.Status.ObservedGeneration
+	case config.WorkloadDaemonSet:
+		ds, err := clientset.AppsV1().DaemonSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		desired, available = ds.Status.DesiredNumberScheduled, ds.Status.NumberAvailable
+		generation, observed = ds.Generation, ds.Status.ObservedGeneration
+	default:
+		return errors.New("unsupported workload kind " + w.Kind)
+	}
+
+	if observed < generation {
+		return fmt.Errorf("%s: latest generation not yet observed", w.String())
+	}
+
+	minAvailable := desired
+	if w.MinAvailable != nil {
+		minAvailable = *w.MinAvailable
+	}
+
+	if available < minAvailable {
+		return fmt.Errorf("%s: %d of %d required replicas available", w.String(), available, minAvailable)
+	}
+
+	return nil
+}
+
+// replicas returns the desired replicas of a workload, which default to 1
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+
+	return *r
+}
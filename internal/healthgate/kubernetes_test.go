@@ -0,0 +1,190 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package healthgate
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func readyNode(name string, status corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: status, Message: "kubelet stopped posting node status"},
+		}},
+	}
+}
+
+func daemonSetPod(name, node, ds, generation string, ready bool) *corev1.Pod {
+	controller := true
+	status := corev1.ConditionFalse
+
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "kube-system",
+			Labels:          map[string]string{"pod-template-generation": generation},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: ds, Controller: &controller}},
+		},
+		Spec:   corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func daemonSet(name, generation string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "kube-system",
+			Annotations: map[string]string{appsv1.DeprecatedTemplateGeneration: generation},
+		},
+		Spec: appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+	}
+}
+
+func TestCheckNodeReady(t *testing.T) {
+	clientset := fake.NewClientset(readyNode("ready", corev1.ConditionTrue), readyNode("notready", corev1.ConditionUnknown))
+
+	if err := checkNodeReady(context.Background(), clientset, "ready"); err != nil {
+		t.Errorf("expected ready node to pass, got: %v", err)
+	}
+
+	if err := checkNodeReady(context.Background(), clientset, "notready"); err == nil {
+		t.Error("expected not ready node to fail")
+	}
+
+	if err := checkNodeReady(context.Background(), clientset, "missing"); err == nil {
+		t.Error("expected missing node to fail")
+	}
+}
+
+func TestCheckDaemonSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantErr string
+	}{
+		{
+			name: "rolled out",
+			objects: []runtime.Object{
+				daemonSet("cilium", "3"),
+				daemonSetPod("cilium-a", "node1", "cilium", "3", true),
+				daemonSetPod("cilium-b", "node2", "cilium", "2", false), // other node
+			},
+		},
+		{
+			name: "not ready",
+			objects: []runtime.Object{
+				daemonSet("cilium", "3"),
+				daemonSetPod("cilium-a", "node1", "cilium", "3", false),
+			},
+			wantErr: "kube-system/cilium (not ready)",
+		},
+		{
+			name: "outdated",
+			objects: []runtime.Object{
+				daemonSet("cilium", "3"),
+				daemonSetPod("cilium-a", "node1", "cilium", "2", true),
+			},
+			wantErr: "kube-system/cilium (outdated)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDaemonSets(context.Background(), fake.NewClientset(tt.objects...), "node1")
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckWorkload(t *testing.T) {
+	replicas := int32(3)
+	two := int32(2)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "ingress", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, AvailableReplicas: 2},
+	}
+
+	clientset := fake.NewClientset(deployment)
+	gate := config.WorkloadHealthGate{Kind: config.WorkloadDeployment, Namespace: "ingress", Name: "ingress"}
+
+	if err := checkWorkload(context.Background(), clientset, gate); err == nil || !strings.Contains(err.Error(), "2 of 3") {
+		t.Errorf("expected 2 of 3 replicas error, got: %v", err)
+	}
+
+	gate.MinAvailable = &two
+
+	if err := checkWorkload(context.Background(), clientset, gate); err != nil {
+		t.Errorf("expected minAvailable 2 to pass, got: %v", err)
+	}
+
+	gate.Name = "missing"
+
+	if err := checkWorkload(context.Background(), clientset, gate); err == nil {
+		t.Error("expected missing deployment to fail")
+	}
+}
+
+func TestCheckEtcdMembers(t *testing.T) {
+	members := []*machine.EtcdMember{{Hostname: "cp1"}, {Hostname: "cp2"}, {Hostname: "cp3", IsLearner: true}}
+
+	if err := checkEtcdMembers(members, 3); err == nil || !strings.Contains(err.Error(), "cp3") {
+		t.Errorf("expected learner error, got: %v", err)
+	}
+
+	if err := checkEtcdMembers(members[:2], 3); err == nil || !strings.Contains(err.Error(), "2 members") {
+		t.Errorf("expected member count error, got: %v", err)
+	}
+
+	if err := checkEtcdMembers(members[:2], 2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	if gates := fromConfig(nil); gates != nil {
+		t.Errorf("expected no gates without config, got %v", gates)
+	}
+
+	gates := fromConfig(&config.HealthGates{
+		Timeout:    time.Minute,
+		NodeReady:  &config.HealthGate{Enabled: true},
+		DaemonSets: &config.HealthGate{Enabled: false},
+		Etcd:       &config.HealthGate{Enabled: true, Timeout: time.Second},
+		Workloads:  []config.WorkloadHealthGate{{Kind: config.WorkloadStatefulSet, Namespace: "db", Name: "pg"}},
+	})
+
+	var names []string
+	for _, g := range gates {
+		names = append(names, g.Name()+"="+g.Timeout().String())
+	}
+
+	if got := strings.Join(names, ","); got != "nodeReady=1m0s,etcd=1s,StatefulSet/db/pg=1m0s" {
+		t.Errorf("unexpected gates: %s", got)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package hooks runs the executables configured in topf.yaml before and after
+// the rolling operations and each of their nodes
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// Phase is the point of a rolling operation a hook runs at
+type Phase string
+
+const (
+	// PhasePreCluster runs once before the first node is changed
+	PhasePreCluster Phase = "pre-cluster"
+	// PhasePostCluster runs once after the last node
+	PhasePostCluster Phase = "post-cluster"
+	// PhasePreNode runs before each node is changed
+	PhasePreNode Phase = "pre-node"
+	// PhasePostNode runs after each node
+	PhasePostNode Phase = "post-node"
+)
+
+// Results passed to post hooks
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// waitDelay is how long the output of a killed hook is waited for
+const waitDelay = 5 * time.Second
+
+// ErrSkipNode is returned by PreNode when a failed hook skips the node
+var ErrSkipNode = errors.New("skipped by pre-node hook")
+
+// Node describes a node to a hook
+type Node struct {
+	Host string         `json:"host"`
+	IP   string         `json:"ip,omitempty"`
+	Role string         `json:"role"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Payload is passed to a hook as JSON on stdin
+type Payload struct {
+	Phase   Phase  `json:"phase"`
+	Command string `json:"command"`
+	Cluster string `json:"cluster"`
+	// Node is the node of node hooks
+	Node *Node `json:"node,omitempty"`
+	// Nodes are all nodes of the command, for cluster hooks
+	Nodes []Node `json:"nodes,omitempty"`
+	// Result is the outcome for post hooks, "succeeded" or "failed"
+	Result string `json:"result,omitempty"`
+	// Error is the error of a failed node or command, for post hooks
+	Error string `json:"error,omitempty"`
+}
+
+// Runner runs the hooks configured in topf.yaml. A nil *Runner has no hooks.
+type Runner struct {
+	cfg     *config.Hooks
+	cluster string
+	command string
+}
+
+// New returns a runner for the hooks of command, or nil if none are
+// configured.
+func New(cfg *config.Hooks, cluster, command string) *Runner {
+	if cfg.Empty() {
+		return nil
+	}
+
+	return &Runner{cfg: cfg, cluster: cluster, command: command}
+}
+
+// PreCluster runs the pre-cluster hooks for the nodes of the command
+func (r *Runner) PreCluster(ctx context.Context, nodes []*topf.Node, logger *slog.Logger) error {
+	if r == nil {
+		return nil
+	}
+
+	return r.run(ctx, r.cfg.PreCluster, &Payload{Phase: PhasePreCluster, Nodes: describeNodes(nodes)}, logger)
+}
+
+// PostCluster runs the post-cluster hooks with the outcome of the command,
+// cmdErr. It returns the error of a failed hook whose policy is to abort.
+func (r *Runner) PostCluster(ctx context.Context, nodes []*topf.Node, cmdErr error, logger *slog.Logger) error {
+	if r == nil {
+		return nil
+	}
+
+	payload := &Payload{Phase: PhasePostCluster, Nodes: describeNodes(nodes)}
+	setResult(payload, cmdErr)
+
+	return r.run(ctx, r.cfg.PostCluster, payload, logger)
+}
+
+// PreNode runs the pre-node hooks of a node. It returns an error wrapping
+// ErrSkipNode if a failed hook skips the node. The provided logger is
+// expected to already carry the node's attributes.
+func (r *Runner) PreNode(ctx context.Context, node *topf.Node, logger *slog.Logger) error {
+	if r == nil {
+		return nil
+	}
+
+	return r.run(ctx, r.cfg.PreNode, &Payload{Phase: PhasePreNode, Node: describeNode(node)}, logger)
+}
+
+// PostNode runs the post-node hooks with the outcome of a node, nodeErr. It
+// returns the error of a failed hook whose policy is to abort. The provided
+// logger is expected to already carry the node's attributes.
+func (r *Runner) PostNode(ctx context.Context, node *topf.Node, nodeErr error, logger *slog.Logger) error {
+	if r == nil {
+		return nil
+	}
+
+	payload := &Payload{Phase: PhasePostNode, Node: describeNode(node)}
+	setResult(payload, nodeErr)
+
+	return r.run(ctx, r.cfg.PostNode, payload, logger)
+}
+
+func setResult(payload *Payload, err error) {
+	payload.Result = ResultSucceeded
+
+	if err != nil {
+		payload.Result = ResultFailed
+		payload.Error = err.Error()
+	}
+}
+
+// run runs hooks one after the other. A failed hook stops the others unless
+// its policy is to ignore failures.
+func (r *Runner) run(ctx context.Context, hooks []config.Hook, payload *Payload, logger *slog.Logger) error {
+	payload.Command = r.command
+	payload.Cluster = r.cluster
+
+	for _, hook := range hooks {
+		logger := logger.With("hook", hook.Command, "phase", payload.Phase)
+
+		err := runHook(ctx, hook, payload, logger)
+		if err == nil {
+			continue
+		}
+
+		switch hook.OnFailure {
+		case config.HookIgnore:
+			logger.Warn("hook failed, ignoring", "error", err)
+		case config.HookSkip:
+			return fmt.Errorf("%w: %s: %w", ErrSkipNode, hook.Command, err)
+		default:
+			return fmt.Errorf("%s hook %s: %w", payload.Phase, hook.Command, err)
+		}
+	}
+
+	return nil
+}
+
+// runHook invokes `<command> <phase> <clusterName>` with the payload as JSON
+// on stdin and in environment variables. The output of the hook is logged.
+func runHook(ctx context.Context, hook config.Hook, payload *Payload, logger *slog.Logger) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding hook payload: %w", err)
+	}
+
+	env, err := environ(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hook.HookTimeout())
+	defer cancel()
+
+	output := &lineLogger{logger: logger}
+
+	//nolint:gosec // launching arbitrary binary is part of the design
+	cmd := exec.CommandContext(ctx, hook.Command, string(payload.Phase), payload.Cluster)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Env = append(os.Environ(), env...)
+	// children of the hook may keep its output open after it was killed
+	cmd.WaitDelay = waitDelay
+
+	logger.Debug("running hook")
+
+	err = cmd.Run()
+
+	output.flush()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", hook.HookTimeout())
+	}
+
+	return err
+}
+
+// environ returns the payload as TOPF_* environment variables
+func environ(payload *Payload) ([]string, error) {
+	env := []string{
+		"TOPF_PHASE=" + string(payload.Phase),
+		"TOPF_COMMAND=" + payload.Command,
+		"TOPF_CLUSTER=" + payload.Cluster,
+	}
+
+	if payload.Result != "" {
+		env = append(env, "TOPF_RESULT="+payload.Result, "TOPF_ERROR="+payload.Error)
+	}
+
+	if node := payload.Node; node != nil {
+		data, err := json.Marshal(node.Data)
+		if err != nil {
+			return nil, fmt.Errorf("encoding node data: %w", err)
+		}
+
+		env = append(env,
+			"TOPF_NODE_HOST="+node.Host,
+			"TOPF_NODE_IP="+node.IP,
+			"TOPF_NODE_ROLE="+node.Role,
+			"TOPF_NODE_DATA="+string(data),
+		)
+	}
+
+	if payload.Nodes != nil {
+		hosts := make([]string, len(payload.Nodes))
+		for i, node := range payload.Nodes {
+			hosts[i] = node.Host
+		}
+
+		env = append(env, "TOPF_NODES="+strings.Join(hosts, ","))
+	}
+
+	return env, nil
+}
+
+func describeNode(node *topf.Node) *Node {
+	n := &Node{
+		Host: node.Node.Host,
+		Role: string(node.Node.Role),
+		Data: node.Node.Data,
+	}
+
+	if node.Node.IP != nil {
+		n.IP = node.Node.IP.String()
+	}
+
+	return n
+}
+
+func describeNodes(nodes []*topf.Node) []Node {
+	described := make([]Node, len(nodes))
+	for i, node := range nodes {
+		described[i] = *describeNode(node)
+	}
+
+	return described
+}
+
+// lineLogger logs every line written to it
+type lineLogger struct {
+	mu      sync.Mutex
+	logger  *slog.Logger
+	partial []byte
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, p...)
+
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		l.log(string(l.partial[:i]))
+		l.partial = l.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush logs an incomplete last line
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.log(string(l.partial))
+	l.partial = nil
+}
+
+func (l *lineLogger) log(line string) {
+	if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+		l.logger.Info(line)
+	}
+}
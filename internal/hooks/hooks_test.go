@@ -0,0 +1,145 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+var discard = slog.New(slog.DiscardHandler) //nolint:gochecknoglobals // shared test logger
+
+// writeHook writes a shell script hook running body to dir
+func writeHook(t *testing.T, dir, name, body string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o700); err != nil { //nolint:gosec // the hook has to be executable
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func testNode() *topf.Node {
+	ip := netip.MustParseAddr("192.0.2.10")
+
+	return &topf.Node{Node: &config.Node{
+		Host: "node1",
+		IP:   &ip,
+		Role: config.RoleWorker,
+		Data: map[string]any{"rack": "r1"},
+	}}
+}
+
+func TestNilRunner(t *testing.T) {
+	r := New(&config.Hooks{}, "test", "apply")
+	if r != nil {
+		t.Fatal("New() without hooks returned a runner")
+	}
+
+	if err := r.PreNode(context.Background(), testNode(), discard); err != nil {
+		t.Errorf("PreNode() = %v", err)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	hook := writeHook(t, dir, "hook", `cat > `+out+`.json
+echo "$1 $2 $TOPF_COMMAND $TOPF_NODE_HOST $TOPF_NODE_IP $TOPF_NODE_ROLE $TOPF_NODE_DATA $TOPF_RESULT $TOPF_ERROR" > `+out+`.env`)
+
+	r := New(&config.Hooks{PostNode: []config.Hook{{Command: hook, OnFailure: config.HookIgnore}}}, "test", "upgrade")
+
+	if err := r.PostNode(context.Background(), testNode(), errors.New("boom"), discard); err != nil {
+		t.Fatalf("PostNode() = %v", err)
+	}
+
+	env, err := os.ReadFile(out + ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `post-node test upgrade node1 192.0.2.10 worker {"rack":"r1"} failed boom`; strings.TrimSpace(string(env)) != want {
+		t.Errorf("environment = %q, want %q", env, want)
+	}
+
+	stdin, err := os.ReadFile(out + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(stdin, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Phase != PhasePostNode || payload.Node == nil || payload.Node.Host != "node1" || payload.Node.Data["rack"] != "r1" || payload.Result != ResultFailed {
+		t.Errorf("unexpected payload: %s", stdin)
+	}
+}
+
+func TestFailurePolicies(t *testing.T) {
+	dir := t.TempDir()
+	fail := writeHook(t, dir, "fail", "echo failing >&2; exit 3")
+	marker := filepath.Join(dir, "ran")
+	next := writeHook(t, dir, "next", "touch "+marker)
+
+	for _, tt := range []struct {
+		policy   string
+		wantSkip bool
+		wantErr  bool
+		wantNext bool
+	}{
+		{policy: config.HookIgnore, wantNext: true},
+		{policy: config.HookSkip, wantSkip: true, wantErr: true},
+		{policy: config.HookAbort, wantErr: true},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			_ = os.Remove(marker)
+
+			r := New(&config.Hooks{PreNode: []config.Hook{
+				{Command: fail, OnFailure: tt.policy},
+				{Command: next, OnFailure: config.HookAbort},
+			}}, "test", "apply")
+
+			err := r.PreNode(context.Background(), testNode(), discard)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrSkipNode) != tt.wantSkip {
+				t.Errorf("PreNode() = %v", err)
+			}
+
+			if _, statErr := os.Stat(marker); (statErr == nil) != tt.wantNext {
+				t.Errorf("next hook ran = %t, want %t", statErr == nil, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	hook := writeHook(t, t.TempDir(), "slow", "exec sleep 10")
+
+	r := New(&config.Hooks{PreCluster: []config.Hook{{Command: hook, Timeout: 50 * time.Millisecond, OnFailure: config.HookAbort}}}, "test", "reset")
+
+	start := time.Now()
+
+	err := r.PreCluster(context.Background(), []*topf.Node{testNode()}, discard)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("PreCluster() = %v, want timeout", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hook ran for %s after its timeout", elapsed)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// Drain cordons the node, then drains its pods. If the graceful drain fails
+// and deleteIfEvictionFails is set, retries with direct pod deletion
+// (bypassing PDBs). The node is cordoned once; the fallback does not re-cordon.
+func Drain(ctx context.Context, clientset kubernetes.Interface, nodeName string, timeout time.Duration, deleteIfEvictionFails bool, logger *slog.Logger) error {
+	logger.Info("draining kubernetes node", "k8s_node", nodeName)
+
+	drainCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := Cordon(drainCtx, clientset, nodeName, logger); err != nil {
+		return err
+	}
+
+	gracefulErr := drainPods(drainCtx, clientset, nodeName, timeout, false, logger)
+	if gracefulErr == nil {
+		logger.Info("kubernetes node drained", "k8s_node", nodeName)
+
+		return nil
+	}
+
+	if !deleteIfEvictionFails {
+		return fmt.Errorf("draining node: %w", gracefulErr)
+	}
+
+	logger.Warn("graceful drain failed, retrying with forced pod deletion", "k8s_node", nodeName, "error", gracefulErr)
+
+	forcedErr := drainPods(ctx, clientset, nodeName, timeout, true, logger)
+	if forcedErr != nil {
+		return fmt.Errorf("draining node: %w", errors.Join(gracefulErr, forcedErr))
+	}
+
+	logger.Info("kubernetes node drained (forced)", "k8s_node", nodeName)
+
+	return nil
+}
+
+// Cordon marks the Kubernetes node unschedulable.
+func Cordon(ctx context.Context, clientset kubernetes.Interface, nodeName string, logger *slog.Logger) error {
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", nodeName, err)
+	}
+
+	drainer := &drain.Helper{
+		Ctx:    ctx,
+		Client: clientset,
+		Out:    io.Discard,
+		ErrOut: io.Discard,
+	}
+
+	logger.Info("drain", "k8s_node", nodeName, "message", "cordoning node")
+
+	if err := drain.RunCordonOrUncordon(drainer, node, true); err != nil {
+		return fmt.Errorf("cordoning node %q: %w", nodeName, err)
+	}
+
+	logger.Info("drain", "k8s_node", nodeName, "message", "node cordoned")
+
+	return nil
+}
+
+// DeleteNode deletes the Kubernetes Node object. A node that doesn't exist
+// (anymore) is not an error.
+func DeleteNode(ctx context.Context, clientset kubernetes.Interface, nodeName string) error {
+	err := clientset.CoreV1().Nodes().Delete(ctx, nodeName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting node %q: %w", nodeName, err)
+	}
+
+	return nil
+}
+
+// drainPods drains the node's pods via the kubectl drain library. Force is
+// always on (unmanaged pods deleted, emptyDir data removed). When
+// deleteIfEvictionFails is true, pods are DELETEd instead of EVICTed, bypassing
+// PDBs (kubectl drain --disable-eviction).
+func drainPods(ctx context.Context, clientset kubernetes.Interface, nodeName string, timeout time.Duration, deleteIfEvictionFails bool, logger *slog.Logger) error {
+	drainCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	action := "evicting"
+	if deleteIfEvictionFails {
+		action = "deleting"
+	}
+
+	// The drain helper fires the {Deletion,Eviction}Started callback on every
+	// retry attempt; we track announced pods in this map and report the outcome
+	// in Finished.
+	var announced sync.Map
+
+	drainer := &drain.Helper{
+		Ctx:                 drainCtx,
+		Client:              clientset,
+		Force:               true,
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  true,
+		DisableEviction:     deleteIfEvictionFails,
+		Timeout:             timeout,
+		Out:                 io.Discard,
+		ErrOut:              io.Discard,
+		OnPodDeletionOrEvictionStarted: func(pod *corev1.Pod, usingEviction bool) {
+			key := pod.Namespace + "/" + pod.Name
+			if _, dup := announced.LoadOrStore(key, struct{}{}); dup {
+				return
+			}
+
+			verb := "deleting"
+			if usingEviction {
+				verb = "evicting"
+			}
+
+			logger.Info("drain", "k8s_node", nodeName, "message", fmt.Sprintf("%s pod %s/%s", verb, pod.Namespace, pod.Name))
+		},
+		OnPodDeletionOrEvictionFinished: func(pod *corev1.Pod, _ bool, err error) {
+			announced.Delete(pod.Namespace + "/" + pod.Name)
+
+			if err != nil {
+				logger.Warn("drain", "k8s_node", nodeName, "message", fmt.Sprintf("failed to %s pod %s/%s: %v", action, pod.Namespace, pod.Name, err))
+
+				return
+			}
+
+			logger.Info("drain", "k8s_node", nodeName, "message", fmt.Sprintf("%s pod %s/%s", action, pod.Namespace, pod.Name))
+		},
+	}
+
+	if err := drain.RunNodeDrain(drainer, nodeName); err != nil {
+		return fmt.Errorf("draining node %q: %w", nodeName, err)
+	}
+
+	return nil
+}
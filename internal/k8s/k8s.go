@@ -0,0 +1,56 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package k8s contains helpers to interact with the Kubernetes API of a cluster
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/postfinance/topf/internal/topf"
+	taloskubeclient "github.com/siderolabs/talos/cmd/talosctl/pkg/talos/kubeclient"
+	talosnodedrain "github.com/siderolabs/talos/cmd/talosctl/pkg/talos/nodedrain"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewClientset obtains an in-memory Kubernetes clientset by fetching the
+// admin kubeconfig through a control-plane node's Talos API. The control-plane
+// client is closed after the kubeconfig is retrieved; the returned clientset
+// is independent of it.
+func NewClientset(ctx context.Context, t topf.Topf, logger *slog.Logger) (kubernetes.Interface, error) {
+	cpClient, err := t.ControlPlaneClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating control-plane client: %w", err)
+	}
+
+	clientset, err := taloskubeclient.FromTalosClient(ctx, cpClient)
+
+	if cerr := cpClient.Close(); cerr != nil {
+		logger.Debug("closing control-plane client", "error", cerr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("creating kubernetes client: %w", err)
+	}
+
+	return clientset, nil
+}
+
+// NodeName resolves the name of the Kubernetes node running on a Talos node,
+// which may differ from the host configured in topf.yaml.
+func NodeName(ctx context.Context, node *topf.Node) (string, error) {
+	nodeClient, err := node.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer nodeClient.Close()
+
+	name, err := talosnodedrain.GetKubernetesNodeName(ctx, nodeClient)
+	if err != nil {
+		return "", fmt.Errorf("resolving kubernetes node name: %w", err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{input: "", want: FormatText},
+		{input: "text", want: FormatText},
+		{input: "JSON", want: FormatJSON},
+		{input: "logfmt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{input: "", want: slog.LevelInfo},
+		{input: "debug", want: slog.LevelDebug},
+		{input: "WARNING", want: slog.LevelWarn},
+		{input: "error", want: slog.LevelError},
+		{input: "trace", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNewHandlerJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(NewHandler(FormatJSON, &buf, slog.LevelInfo))
+	logger.Info("hello", "node", "node1")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", buf.String(), err)
+	}
+
+	if record["msg"] != "hello" || record["node"] != "node1" {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
+
+func TestFanoutHandler(t *testing.T) {
+	var terminal, file bytes.Buffer
+
+	logger := slog.New(NewFanoutHandler(
+		NewHandler(FormatText, &terminal, slog.LevelInfo),
+		NewHandler(FormatJSON, &file, slog.LevelDebug),
+	)).With("command", "apply").WithGroup("node")
+
+	logger.Debug("debug message", "host", "node1")
+	logger.Info("info message", "host", "node2")
+
+	if strings.Contains(terminal.String(), "debug message") {
+		t.Errorf("terminal should not contain debug records:\n%s", terminal.String())
+	}
+
+	if !strings.Contains(terminal.String(), "info message") || !strings.Contains(terminal.String(), "node.host=node2") {
+		t.Errorf("terminal should contain info record with grouped attrs:\n%s", terminal.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file should contain 2 records, got %d:\n%s", len(lines), file.String())
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("invalid JSON record %q: %v", lines[0], err)
+	}
+
+	if record["command"] != "apply" {
+		t.Errorf("expected command attr in file record: %v", record)
+	}
+}
+
+func TestFanoutHandlerEnabled(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := NewFanoutHandler(
+		NewHandler(FormatText, &buf, slog.LevelWarn),
+		NewHandler(FormatText, &buf, slog.LevelError),
+	)
+
+	if h.Enabled(t.Context(), slog.LevelInfo) {
+		t.Error("info should be disabled when all handlers are warn or above")
+	}
+
+	if !h.Enabled(t.Context(), slog.LevelWarn) {
+		t.Error("warn should be enabled")
+	}
+}
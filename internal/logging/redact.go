@@ -0,0 +1,130 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// Redactor replaces sensitive values in a string
+type Redactor interface {
+	Redact(s string) string
+}
+
+// redactingHandler scrubs the message and all attribute values of a record
+// before passing it on to the wrapped handler.
+type redactingHandler struct {
+	inner    slog.Handler
+	redactor Redactor
+	// bound are the attributes and groups of WithAttrs and WithGroup. They are
+	// kept raw and only redacted in Handle, such that secrets added to the
+	// redactor later are redacted from them as well.
+	bound []bound
+}
+
+// bound is either a group or attributes bound to a handler
+type bound struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewRedactingHandler wraps inner so that every message and attribute value
+// is redacted by r before it reaches any sink. Values that are neither
+// strings nor errors are only replaced (by their redacted string form) when
+// their formatted representation contains a secret.
+func NewRedactingHandler(inner slog.Handler, r Redactor) slog.Handler {
+	return &redactingHandler{inner: inner, redactor: r}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+//nolint:gocritic // slog.Handler interface requires passing Record by value
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactor.Redact(r.Message), r.PC)
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.redactAttr(a))
+		return true
+	})
+
+	// nest the attributes of the record in the bound groups, from the
+	// innermost outwards
+	for i := len(h.bound) - 1; i >= 0; i-- {
+		if b := h.bound[i]; b.group != "" {
+			attrs = []slog.Attr{{Key: b.group, Value: slog.GroupValue(attrs...)}}
+		} else {
+			attrs = append(h.redactAttrs(b.attrs), attrs...)
+		}
+	}
+
+	redacted.AddAttrs(attrs...)
+
+	return h.inner.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	return h.with(bound{attrs: slices.Clone(attrs)})
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return h.with(bound{group: name})
+}
+
+func (h *redactingHandler) with(b bound) *redactingHandler {
+	return &redactingHandler{inner: h.inner, redactor: h.redactor, bound: append(slices.Clip(h.bound), b)}
+}
+
+func (h *redactingHandler) redactAttrs(attrs []slog.Attr) []slog.Attr {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, h.redactAttr(a))
+	}
+
+	return redacted
+}
+
+func (h *redactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = h.redactValue(a.Value)
+	return a
+}
+
+func (h *redactingHandler) redactValue(v slog.Value) slog.Value {
+	v = v.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(h.redactor.Redact(v.String()))
+	case slog.KindGroup:
+		return slog.GroupValue(h.redactAttrs(v.Group())...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.StringValue(h.redactor.Redact(err.Error()))
+		}
+
+		formatted := fmt.Sprintf("%+v", v.Any())
+		if redacted := h.redactor.Redact(formatted); redacted != formatted {
+			return slog.StringValue(redacted)
+		}
+
+		return v
+	default:
+		// numbers, booleans, durations and times can't contain secrets
+		return v
+	}
+}
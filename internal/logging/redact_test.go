@@ -0,0 +1,100 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/maskedwriter"
+)
+
+type event struct {
+	Node    string
+	Payload string
+}
+
+func TestRedactingHandler(t *testing.T) {
+	secrets := maskedwriter.NewSecrets([]string{"s3cr3t"})
+
+	var buf bytes.Buffer
+
+	logger := slog.New(NewRedactingHandler(NewHandler(FormatJSON, &buf, slog.LevelDebug), secrets))
+
+	logger.With("bound", "s3cr3t").WithGroup("g").Info("message with s3cr3t",
+		"string", "value s3cr3t",
+		"error", fmt.Errorf("decrypt: %w", errors.New("bad s3cr3t")),
+		"event", event{Node: "node1", Payload: "s3cr3t"},
+		"harmless", event{Node: "node1", Payload: "nothing"},
+		slog.Group("nested", "inner", "s3cr3t"),
+		"count", 42,
+		"duration", time.Second,
+	)
+
+	out := buf.String()
+
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("secret leaked into log output:\n%s", out)
+	}
+
+	for _, want := range []string{
+		`"msg":"message with *** redacted ***"`,
+		`"bound":"*** redacted ***"`,
+		`"string":"value *** redacted ***"`,
+		`"error":"decrypt: bad *** redacted ***"`,
+		`"nested":{"inner":"*** redacted ***"}`,
+		`"harmless":{"Node":"node1","Payload":"nothing"}`,
+		`"count":42`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in log output:\n%s", want, out)
+		}
+	}
+}
+
+func TestRedactingHandlerLateSecrets(t *testing.T) {
+	secrets := maskedwriter.NewSecrets(nil)
+
+	var buf bytes.Buffer
+
+	logger := slog.New(NewRedactingHandler(NewHandler(FormatText, &buf, slog.LevelInfo), secrets))
+
+	// secrets registered after the logger was created are redacted too
+	secrets.Add([]string{"old-cert"})
+	logger.Info("current config", "cert", "old-cert")
+
+	if strings.Contains(buf.String(), "old-cert") {
+		t.Errorf("secret leaked into log output:\n%s", buf.String())
+	}
+}
+
+func TestRedactingHandlerLateSecretsBound(t *testing.T) {
+	secrets := maskedwriter.NewSecrets(nil)
+
+	var buf bytes.Buffer
+
+	logger := slog.New(NewRedactingHandler(NewHandler(FormatJSON, &buf, slog.LevelInfo), secrets))
+
+	// attributes bound before a secret is registered are redacted too
+	logger = logger.With("cert", "old-cert").WithGroup("g").With("error", errors.New("bad old-cert"))
+
+	secrets.Add([]string{"old-cert"})
+	logger.Info("current config", "key", "value")
+
+	out := buf.String()
+
+	if strings.Contains(out, "old-cert") {
+		t.Errorf("secret leaked into log output:\n%s", out)
+	}
+
+	want := `"cert":"*** redacted ***","g":{"error":"bad *** redacted ***","key":"value"}`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected %s in log output:\n%s", want, out)
+	}
+}
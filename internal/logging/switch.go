@@ -0,0 +1,129 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// SwitchWriter is a writer whose destination can be swapped at runtime, e.g.
+// to send output to a dashboard while it is shown.
+type SwitchWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSwitchWriter returns a writer initially writing to w
+func NewSwitchWriter(w io.Writer) *SwitchWriter {
+	return &SwitchWriter{w: w}
+}
+
+// Swap sets the destination of the writer and returns the previous one
+func (s *SwitchWriter) Swap(w io.Writer) io.Writer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.w
+	s.w = w
+
+	return prev
+}
+
+// Unwrap returns the current destination of the writer
+func (s *SwitchWriter) Unwrap() io.Writer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w
+}
+
+func (s *SwitchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// Hook holds a handler that can be attached and detached at runtime. Its
+// Handler can be part of a logger built before anything is attached.
+type Hook struct {
+	mu     sync.RWMutex
+	target slog.Handler
+}
+
+// Set attaches target to the hook, replacing the previous handler. A nil
+// target detaches it.
+func (h *Hook) Set(target slog.Handler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.target = target
+}
+
+func (h *Hook) get() slog.Handler {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.target
+}
+
+// Handler returns a handler forwarding records to the attached handler, if
+// any
+func (h *Hook) Handler() slog.Handler {
+	return &hookHandler{hook: h}
+}
+
+// hookHandler replays the attributes and groups it was derived with on the
+// handler attached when a record is handled.
+type hookHandler struct {
+	hook *Hook
+	with []func(slog.Handler) slog.Handler
+}
+
+func (h *hookHandler) target() slog.Handler {
+	target := h.hook.get()
+	if target == nil {
+		return nil
+	}
+
+	for _, with := range h.with {
+		target = with(target)
+	}
+
+	return target
+}
+
+func (h *hookHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	target := h.hook.get()
+
+	return target != nil && target.Enabled(ctx, level)
+}
+
+//nolint:gocritic // slog.Handler interface requires passing Record by value
+func (h *hookHandler) Handle(ctx context.Context, r slog.Record) error {
+	target := h.target()
+	if target == nil {
+		return nil
+	}
+
+	return target.Handle(ctx, r)
+}
+
+func (h *hookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(target slog.Handler) slog.Handler { return target.WithAttrs(attrs) })
+}
+
+func (h *hookHandler) WithGroup(name string) slog.Handler {
+	return h.derive(func(target slog.Handler) slog.Handler { return target.WithGroup(name) })
+}
+
+func (h *hookHandler) derive(with func(slog.Handler) slog.Handler) slog.Handler {
+	return &hookHandler{
+		hook: h.hook,
+		with: append(h.with[:len(h.with):len(h.with)], with),
+	}
+}
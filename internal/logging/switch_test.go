@@ -0,0 +1,53 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSwitchWriter(t *testing.T) {
+	var first, second bytes.Buffer
+
+	w := NewSwitchWriter(&first)
+	_, _ = w.Write([]byte("a"))
+
+	if prev := w.Swap(&second); prev != &first {
+		t.Errorf("Swap returned %v, want the first writer", prev)
+	}
+
+	_, _ = w.Write([]byte("b"))
+
+	if first.String() != "a" || second.String() != "b" {
+		t.Errorf("first = %q, second = %q", first.String(), second.String())
+	}
+}
+
+func TestHook(t *testing.T) {
+	hook := &Hook{}
+
+	// the logger is derived before anything is attached
+	logger := slog.New(hook.Handler()).With("node", "node1").WithGroup("g")
+
+	logger.Info("dropped")
+
+	var buf bytes.Buffer
+
+	hook.Set(NewHandler(FormatText, &buf, slog.LevelInfo))
+
+	logger.Debug("too verbose")
+	logger.Info("attached", "key", "value")
+
+	hook.Set(nil)
+
+	logger.Info("detached")
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 || !strings.Contains(out, "msg=attached node=node1 g.key=value") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -7,6 +7,9 @@
 // As soon as a match is impossible, safe bytes are flushed to the underlying
 // writer. When a complete secret is found, it is replaced with a redaction
 // marker. Call Close after the last Write to emit any remaining buffered bytes.
+//
+// The secrets are kept in a Secrets set, which can be shared between several
+// Writers and also redacts plain strings (e.g. log attributes).
 package maskedwriter
 
 import (
@@ -26,33 +29,32 @@ const redacted = "*** redacted ***"
 type Writer struct {
 	mu      sync.Mutex
 	inner   io.Writer
-	secrets [][]byte
-	set     map[string]struct{}
+	secrets *Secrets
 	pending []byte // bytes not yet written; might be part of a secret
 }
 
 // New returns a Writer that replaces any occurrence of the sensitive
 // strings with "*** redacted ***" before writing to the underlying writer.
 func New(writer io.Writer, sensitive []string) *Writer {
-	w := &Writer{inner: writer, set: make(map[string]struct{})}
-	w.AddSecrets(sensitive)
+	return NewShared(writer, NewSecrets(sensitive))
+}
 
-	return w
+// NewShared returns a Writer that redacts the secrets of the given set,
+// including secrets added to the set later on.
+func NewShared(writer io.Writer, secrets *Secrets) *Writer {
+	return &Writer{inner: writer, secrets: secrets}
 }
 
-// AddSecrets registers additional sensitive strings to be redacted.
-func (w *Writer) AddSecrets(sensitive []string) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+// Unwrap returns the underlying writer
+func (w *Writer) Unwrap() io.Writer {
+	return w.inner
+}
 
-	for _, s := range sensitive {
-		if len(s) > 0 {
-			if _, exists := w.set[s]; !exists {
-				w.secrets = append(w.secrets, []byte(s))
-				w.set[s] = struct{}{}
-			}
-		}
-	}
+// AddSecrets registers additional sensitive strings to be redacted. The
+// secrets are added to the underlying set, so they affect all Writers
+// sharing it.
+func (w *Writer) AddSecrets(sensitive []string) {
+	w.secrets.Add(sensitive)
 }
 
 // Write appends p to the internal buffer and drains as many bytes as
@@ -93,14 +95,16 @@ func (w *Writer) Close() error {
 //  3. Otherwise the first byte cannot be part of a secret starting
 //     here — emit it and retry from step 1.
 func (w *Writer) drainPending(flush bool) error {
+	secrets := w.secrets.snapshot()
+
 	for len(w.pending) > 0 {
 		// Rule 1: pause when future input could still complete a match.
-		if !flush && w.couldGrowIntoSecret(w.pending) {
+		if !flush && couldGrowIntoSecret(secrets, w.pending) {
 			return nil
 		}
 
 		// Rule 2: redact the longest secret that starts at pending[0].
-		if n := w.longestMatchAtStart(w.pending); n > 0 {
+		if n := longestMatchAtStart(secrets, w.pending); n > 0 {
 			if _, err := io.WriteString(w.inner, redacted); err != nil {
 				return err
 			}
@@ -123,8 +127,8 @@ func (w *Writer) drainPending(flush bool) error {
 
 // couldGrowIntoSecret reports whether buf is a proper prefix of at least
 // one registered secret, meaning additional input could complete a match.
-func (w *Writer) couldGrowIntoSecret(buf []byte) bool {
-	for _, s := range w.secrets {
+func couldGrowIntoSecret(secrets [][]byte, buf []byte) bool {
+	for _, s := range secrets {
 		if len(s) > len(buf) && bytes.HasPrefix(s, buf) {
 			return true
 		}
@@ -135,10 +139,10 @@ func (w *Writer) couldGrowIntoSecret(buf []byte) bool {
 
 // longestMatchAtStart returns the length of the longest registered secret
 // that matches at the very beginning of buf, or 0 if none matches.
-func (w *Writer) longestMatchAtStart(buf []byte) int {
+func longestMatchAtStart(secrets [][]byte, buf []byte) int {
 	best := 0
 
-	for _, s := range w.secrets {
+	for _, s := range secrets {
 		if len(s) > best && bytes.HasPrefix(buf, s) {
 			best = len(s)
 		}
@@ -0,0 +1,97 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package maskedwriter
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Secrets is a set of sensitive strings that can be shared between several
+// Writers and other redactors (e.g. log handlers), so that secrets registered
+// later are redacted everywhere.
+type Secrets struct {
+	mu       sync.RWMutex
+	list     [][]byte
+	set      map[string]struct{}
+	replacer *strings.Replacer // lazily built by Redact, reset by Add
+}
+
+// NewSecrets returns a set containing the given sensitive strings.
+func NewSecrets(sensitive []string) *Secrets {
+	s := &Secrets{set: make(map[string]struct{})}
+	s.Add(sensitive)
+
+	return s
+}
+
+// Add registers additional sensitive strings. Empty strings are ignored.
+func (s *Secrets) Add(sensitive []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, secret := range sensitive {
+		if len(secret) > 0 {
+			if _, exists := s.set[secret]; !exists {
+				s.list = append(s.list, []byte(secret))
+				s.set[secret] = struct{}{}
+				s.replacer = nil
+			}
+		}
+	}
+}
+
+// Redact replaces every occurrence of a registered secret in str with
+// "*** redacted ***". When secrets overlap, the longest one wins.
+func (s *Secrets) Redact(str string) string {
+	if str == "" {
+		return str
+	}
+
+	s.mu.RLock()
+	r := s.replacer
+	s.mu.RUnlock()
+
+	if r == nil {
+		r = s.buildReplacer()
+	}
+
+	return r.Replace(str)
+}
+
+// buildReplacer builds and caches a replacer for the current secrets. The
+// replacer tries its patterns in argument order, so longer secrets go first.
+func (s *Secrets) buildReplacer() *strings.Replacer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replacer != nil {
+		return s.replacer
+	}
+
+	secrets := slices.Clone(s.list)
+	slices.SortStableFunc(secrets, func(a, b []byte) int {
+		return cmp.Compare(len(b), len(a))
+	})
+
+	oldnew := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		oldnew = append(oldnew, string(secret), redacted)
+	}
+
+	s.replacer = strings.NewReplacer(oldnew...)
+
+	return s.replacer
+}
+
+// snapshot returns the registered secrets. Add only ever appends, so the
+// returned slice stays valid while more secrets are registered.
+func (s *Secrets) snapshot() [][]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.list
+}
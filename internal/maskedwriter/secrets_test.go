@@ -0,0 +1,108 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package maskedwriter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSecretsRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		secrets  []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "no secrets",
+			secrets:  nil,
+			input:    "hello world",
+			expected: "hello world",
+		},
+		{
+			name:     "single match",
+			secrets:  []string{"secret"},
+			input:    "my secret value",
+			expected: "my *** redacted *** value",
+		},
+		{
+			name:     "multiple occurrences",
+			secrets:  []string{"foo", "bar"},
+			input:    "foo and bar and foo",
+			expected: "*** redacted *** and *** redacted *** and *** redacted ***",
+		},
+		{
+			name:     "longest secret wins",
+			secrets:  []string{"abc", "abcdef"},
+			input:    "xabcdefx",
+			expected: "x*** redacted ***x",
+		},
+		{
+			name:     "empty secrets ignored",
+			secrets:  []string{""},
+			input:    "hello",
+			expected: "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSecrets(tt.secrets).Redact(tt.input)
+			if got != tt.expected {
+				t.Errorf("Redact(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSecretsAddAfterRedact(t *testing.T) {
+	s := NewSecrets([]string{"first"})
+
+	if got := s.Redact("first second"); got != "*** redacted *** second" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+
+	s.Add([]string{"second"})
+
+	if got := s.Redact("first second"); got != "*** redacted *** *** redacted ***" {
+		t.Errorf("secret added later not redacted: %q", got)
+	}
+}
+
+func TestSharedSecrets(t *testing.T) {
+	s := NewSecrets(nil)
+
+	var a, b bytes.Buffer
+
+	wa := NewShared(&a, s)
+	wb := NewShared(&b, s)
+
+	// adding through one writer affects all writers sharing the set
+	wa.AddSecrets([]string{"token"})
+
+	if _, err := wb.Write([]byte("token=token\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wa.Write([]byte("token\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wa.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.String() != "*** redacted ***\n" {
+		t.Errorf("writer a = %q", a.String())
+	}
+
+	if b.String() != "*** redacted ***=*** redacted ***\n" {
+		t.Errorf("writer b = %q", b.String())
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/postfinance/topf/internal/control"
 	"github.com/postfinance/topf/internal/topf"
 	"github.com/postfinance/topf/pkg/config"
 )
@@ -97,6 +98,15 @@ type NodeFunc func(ctx context.Context, node *topf.Node, logger *slog.Logger) er
 // joined errors are returned. The provided context is not cancelled on failure,
 // so in-flight operations run to completion.
 func RunConcurrent(ctx context.Context, nodes []*topf.Node, n int, fn NodeFunc, logger *slog.Logger) error {
+	return errors.Join(runPool(ctx, nodes, n, Waves{}, fn, logger)...)
+}
+
+// runPool runs fn over nodes like RunConcurrent, but only stops pulling new
+// nodes once more than w.MaxFailures operations failed or the operator
+// aborted the rollout, and holds back nodes whose failure domain is at the
+// limits of w.Topology. It returns the errors of all failed operations, with
+// control.ErrAborted only once.
+func runPool(ctx context.Context, nodes []*topf.Node, n int, w Waves, fn NodeFunc, logger *slog.Logger) []error {
 	if len(nodes) == 0 {
 		return nil
 	}
@@ -109,36 +119,36 @@ func RunConcurrent(ctx context.Context, nodes []*topf.Node, n int, fn NodeFunc,
 		n = len(nodes)
 	}
 
-	queue := make(chan *topf.Node, len(nodes))
-	for _, node := range nodes {
-		queue <- node
-	}
-
-	close(queue)
+	queue := newScheduler(nodes, w.Topology)
 
 	var (
-		wg   sync.WaitGroup
-		stop atomic.Bool
+		wg       sync.WaitGroup
+		failures atomic.Int64
 	)
 
 	errs := make(chan error, len(nodes))
 
+	// Previous operations may have failed; if too many did, or the rollout
+	// was aborted, stop pulling new nodes from the queue.
+	stop := func() bool { return failures.Load() > int64(w.MaxFailures) || w.Control.Aborted() }
+
 	for range n {
 		wg.Go(func() {
-			for node := range queue {
-				// A previous operation may have failed; if so, stop pulling new
-				// nodes from the queue.
-				if stop.Load() {
+			for {
+				node, ok := queue.next(stop)
+				if !ok {
 					return
 				}
 
 				if err := fn(ctx, node, logger.With(node.Attrs())); err != nil {
 					errs <- err
 
-					stop.Store(true)
-
-					return
+					if !errors.Is(err, control.ErrAborted) {
+						failures.Add(1)
+					}
 				}
+
+				queue.done(node)
 			}
 		})
 	}
@@ -146,10 +156,23 @@ func RunConcurrent(ctx context.Context, nodes []*topf.Node, n int, fn NodeFunc,
 	wg.Wait()
 	close(errs)
 
-	var runErrs []error
+	var (
+		runErrs []error
+		aborted bool
+	)
+
 	for err := range errs {
+		if errors.Is(err, control.ErrAborted) {
+			aborted = true
+			continue
+		}
+
 		runErrs = append(runErrs, err)
 	}
 
-	return errors.Join(runErrs...)
+	if aborted {
+		runErrs = append(runErrs, control.ErrAborted)
+	}
+
+	return runErrs
 }
@@ -0,0 +1,194 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package nodepool
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/internal/yamlutils"
+)
+
+// Topology limits how many failure domains (e.g. racks or zones) have nodes
+// in flight at once, and how many nodes of the same domain. The domain of a
+// node is the value of a key of its data.
+type Topology struct {
+	// Key is the data key holding the failure domain of a node, with dots
+	// separating the keys of nested maps (e.g. "rack" or "location.zone").
+	// Empty disables the limits.
+	Key string
+	// MaxDomains is the number of failure domains with nodes in flight at
+	// once. 0 means unlimited, or 1 if MaxPerDomain isn't set either.
+	MaxDomains int
+	// MaxPerDomain is the number of nodes of the same failure domain in
+	// flight at once. 0 means unlimited.
+	MaxPerDomain int
+}
+
+// Enabled reports whether the topology limits anything
+func (t Topology) Enabled() bool {
+	return t.Key != ""
+}
+
+// maxDomains returns the effective number of domains allowed in flight
+func (t Topology) maxDomains() int {
+	if t.MaxDomains == 0 && t.MaxPerDomain == 0 {
+		return 1
+	}
+
+	return t.MaxDomains
+}
+
+// Domain returns the failure domain of a node, and false if its data doesn't
+// contain the key. All nodes without the key share the empty domain.
+func (t Topology) Domain(node *topf.Node) (string, bool) {
+	if !t.Enabled() || node.Node.Data == nil {
+		return "", false
+	}
+
+	var path []any
+	for segment := range strings.SplitSeq(t.Key, ".") {
+		path = append(path, segment)
+	}
+
+	value, ok := yamlutils.ResolvePath(node.Node.Data, path)
+	if !ok || value == nil {
+		return "", false
+	}
+
+	return fmt.Sprint(value), true
+}
+
+// Order returns the nodes in the order they should be rolled out. When the
+// number of domains in flight is limited, the nodes of a domain are kept
+// together, so that a domain is finished before the next one is started.
+// Otherwise the domains are interleaved, so that the per-domain limit holds
+// back as few nodes as possible. Nodes keep their relative order within a
+// domain, and domains are sorted by name.
+func (t Topology) Order(nodes []*topf.Node) []*topf.Node {
+	if !t.Enabled() {
+		return nodes
+	}
+
+	groups := make(map[string][]*topf.Node)
+
+	for _, node := range nodes {
+		domain, _ := t.Domain(node)
+		groups[domain] = append(groups[domain], node)
+	}
+
+	domains := make([]string, 0, len(groups))
+	for domain := range groups {
+		domains = append(domains, domain)
+	}
+
+	slices.SortFunc(domains, cmp.Compare)
+
+	ordered := make([]*topf.Node, 0, len(nodes))
+
+	if t.maxDomains() > 0 {
+		for _, domain := range domains {
+			ordered = append(ordered, groups[domain]...)
+		}
+
+		return ordered
+	}
+
+	for i := 0; len(ordered) < len(nodes); i++ {
+		for _, domain := range domains {
+			if i < len(groups[domain]) {
+				ordered = append(ordered, groups[domain][i])
+			}
+		}
+	}
+
+	return ordered
+}
+
+// scheduler hands out nodes to the workers of a pool in order, skipping
+// nodes whose failure domain is at its limit until a node of it is done.
+type scheduler struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	topology Topology
+	pending  []*topf.Node
+	domains  map[*topf.Node]string
+	inFlight map[string]int
+}
+
+func newScheduler(nodes []*topf.Node, topology Topology) *scheduler {
+	s := &scheduler{
+		topology: topology,
+		pending:  slices.Clone(nodes),
+		domains:  make(map[*topf.Node]string, len(nodes)),
+		inFlight: make(map[string]int),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	for _, node := range nodes {
+		s.domains[node], _ = topology.Domain(node)
+	}
+
+	return s
+}
+
+// next blocks until a pending node may be started and returns it, or returns
+// false once no nodes are pending or stop reports true.
+func (s *scheduler) next(stop func() bool) (*topf.Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if len(s.pending) == 0 || stop() {
+			return nil, false
+		}
+
+		for i, node := range s.pending {
+			domain := s.domains[node]
+
+			if s.allowed(domain) {
+				s.pending = slices.Delete(s.pending, i, i+1)
+				s.inFlight[domain]++
+
+				return node, true
+			}
+		}
+
+		// nodes only become allowed when an in-flight node is done
+		s.cond.Wait()
+	}
+}
+
+// allowed reports whether another node of domain may be started
+func (s *scheduler) allowed(domain string) bool {
+	if !s.topology.Enabled() {
+		return true
+	}
+
+	if count, ok := s.inFlight[domain]; ok {
+		return s.topology.MaxPerDomain == 0 || count < s.topology.MaxPerDomain
+	}
+
+	maxDomains := s.topology.maxDomains()
+
+	return maxDomains == 0 || len(s.inFlight) < maxDomains
+}
+
+// done marks a node returned by next as finished
+func (s *scheduler) done(node *topf.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain := s.domains[node]
+
+	if s.inFlight[domain]--; s.inFlight[domain] <= 0 {
+		delete(s.inFlight, domain)
+	}
+
+	s.cond.Broadcast()
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// rackNodes returns nodes spread over racks, node i in rack racks[i]
+func rackNodes(racks ...string) []*topf.Node {
+	out := make([]*topf.Node, len(racks))
+	for i, rack := range racks {
+		node := &config.Node{Host: fmt.Sprintf("node-%d", i)}
+		if rack != "" {
+			node.Data = map[string]any{"location": map[string]any{"rack": rack}}
+		}
+
+		out[i] = &topf.Node{Node: node}
+	}
+
+	return out
+}
+
+func hosts(nodes []*topf.Node) []string {
+	out := make([]string, len(nodes))
+	for i, node := range nodes {
+		out[i] = node.Node.Host
+	}
+
+	return out
+}
+
+func TestTopologyDomain(t *testing.T) {
+	nodes := rackNodes("r1", "")
+	topology := Topology{Key: "location.rack"}
+
+	if domain, ok := topology.Domain(nodes[0]); !ok || domain != "r1" {
+		t.Errorf("Domain(node-0) = %q, %v, want r1, true", domain, ok)
+	}
+
+	if domain, ok := topology.Domain(nodes[1]); ok || domain != "" {
+		t.Errorf("Domain(node-1) = %q, %v, want empty, false", domain, ok)
+	}
+
+	if _, ok := (Topology{Key: "location"}).Domain(nodes[0]); !ok {
+		t.Error("Domain with a map value not found")
+	}
+}
+
+func TestTopologyOrder(t *testing.T) {
+	nodes := rackNodes("r2", "r1", "r2", "r1", "r3")
+
+	tests := []struct {
+		name     string
+		topology Topology
+		want     []string
+	}{
+		{name: "disabled", topology: Topology{}, want: []string{"node-0", "node-1", "node-2", "node-3", "node-4"}},
+		{name: "grouped", topology: Topology{Key: "location.rack"}, want: []string{"node-1", "node-3", "node-0", "node-2", "node-4"}},
+		{name: "interleaved", topology: Topology{Key: "location.rack", MaxPerDomain: 1}, want: []string{"node-1", "node-0", "node-4", "node-3", "node-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hosts(tt.topology.Order(nodes)); !slices.Equal(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// domainRecorder records the peak number of domains and nodes per domain in
+// flight
+type domainRecorder struct {
+	topology     Topology
+	mu           sync.Mutex
+	inFlight     map[string]int
+	peakDomains  int
+	peakInDomain int
+}
+
+func (r *domainRecorder) run(_ context.Context, node *topf.Node, _ *slog.Logger) error {
+	domain, _ := r.topology.Domain(node)
+
+	r.mu.Lock()
+	r.inFlight[domain]++
+	r.peakDomains = max(r.peakDomains, len(r.inFlight))
+	r.peakInDomain = max(r.peakInDomain, r.inFlight[domain])
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	if r.inFlight[domain]--; r.inFlight[domain] == 0 {
+		delete(r.inFlight, domain)
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+func TestRunWavesTopology(t *testing.T) {
+	nodes := rackNodes("r1", "r2", "r3", "r1", "r2", "r3", "r1", "r2", "r3")
+
+	tests := []struct {
+		name             string
+		topology         Topology
+		wantDomains      int
+		wantNodesPerRack int
+	}{
+		{name: "one domain at a time", topology: Topology{Key: "location.rack"}, wantDomains: 1, wantNodesPerRack: 3},
+		{name: "one node per domain", topology: Topology{Key: "location.rack", MaxPerDomain: 1}, wantDomains: 3, wantNodesPerRack: 1},
+		{name: "both limits", topology: Topology{Key: "location.rack", MaxDomains: 2, MaxPerDomain: 2}, wantDomains: 2, wantNodesPerRack: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &domainRecorder{topology: tt.topology, inFlight: make(map[string]int)}
+
+			err := RunWaves(context.Background(), nodes, Waves{Topology: tt.topology}, len(nodes), rec.run, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rec.peakDomains != tt.wantDomains || rec.peakInDomain != tt.wantNodesPerRack {
+				t.Errorf("peak domains = %d, nodes per domain = %d, want %d, %d",
+					rec.peakDomains, rec.peakInDomain, tt.wantDomains, tt.wantNodesPerRack)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package nodepool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/postfinance/topf/internal/control"
+	"github.com/postfinance/topf/internal/topf"
+)
+
+// waveRest is the wave size taking all remaining nodes
+const waveRest = "rest"
+
+// WaveSize is the number of nodes of a rollout wave. It is either an
+// absolute count, a percentage of the nodes of the rollout, or all remaining
+// nodes.
+type WaveSize struct {
+	Value   int
+	Percent bool
+	Rest    bool
+}
+
+// ParseWaves parses a comma-separated list of wave sizes, each a positive
+// integer (e.g. "1"), a percentage of the nodes (e.g. "10%") or "rest".
+// "rest" must be the last wave.
+func ParseWaves(value string) ([]WaveSize, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	return ParseWaveList(strings.Split(value, ","))
+}
+
+// ParseWaveList parses a list of wave sizes, see ParseWaves.
+func ParseWaveList(values []string) ([]WaveSize, error) {
+	sizes := make([]WaveSize, 0, len(values))
+
+	for i, value := range values {
+		value = strings.TrimSpace(value)
+
+		if value == waveRest {
+			if i != len(values)-1 {
+				return nil, fmt.Errorf("invalid waves: %q must be the last wave", waveRest)
+			}
+
+			sizes = append(sizes, WaveSize{Rest: true})
+
+			continue
+		}
+
+		size, err := parseWaveSize(value)
+		if err != nil {
+			return nil, err
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+func parseWaveSize(value string) (WaveSize, error) {
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(pct))
+		if err != nil || n <= 0 || n > 100 {
+			return WaveSize{}, fmt.Errorf("invalid wave size %q: percentage must be an integer between 1 and 100", value)
+		}
+
+		return WaveSize{Value: n, Percent: true}, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return WaveSize{}, fmt.Errorf("invalid wave size %q: must be a positive integer, a percentage (e.g. \"10%%\") or %q", value, waveRest)
+	}
+
+	return WaveSize{Value: n}, nil
+}
+
+// String returns the wave size as it is parsed
+func (s WaveSize) String() string {
+	switch {
+	case s.Rest:
+		return waveRest
+	case s.Percent:
+		return strconv.Itoa(s.Value) + "%"
+	default:
+		return strconv.Itoa(s.Value)
+	}
+}
+
+// Resolve returns the number of nodes of the wave for the given total node
+// count of the rollout. Percentages are rounded up, and the result is always
+// at least 1.
+func (s WaveSize) Resolve(total int) int {
+	switch {
+	case s.Rest:
+		return max(total, 1)
+	case s.Percent:
+		return max(int(math.Ceil(float64(total)*float64(s.Value)/100.0)), 1)
+	default:
+		return s.Value
+	}
+}
+
+// Waves splits a rollout into successive batches of nodes. Each wave must
+// complete before the next one starts.
+type Waves struct {
+	// Sizes are the sizes of the waves. When they don't cover all nodes, the
+	// last size is repeated. Empty means a single wave with all nodes.
+	Sizes []WaveSize
+	// Pause is waited between two waves
+	Pause time.Duration
+	// MaxFailures is the number of failed nodes tolerated within a wave. The
+	// rollout halts after a wave with more failures.
+	MaxFailures int
+	// Topology limits the failure domains in flight within a wave. The nodes
+	// are ordered by domain before they are split into waves.
+	Topology Topology
+	// Control pauses the rollout between waves and lets the operator skip
+	// waves or abort the rollout, if set. Pausing between nodes is up to fn.
+	Control *control.Controller
+}
+
+// Split returns the batches of nodes of the waves, preserving the input order
+func (w Waves) Split(nodes []*topf.Node) [][]*topf.Node {
+	if len(w.Sizes) == 0 {
+		if len(nodes) == 0 {
+			return nil
+		}
+
+		return [][]*topf.Node{nodes}
+	}
+
+	var (
+		batches [][]*topf.Node
+		total   = len(nodes)
+	)
+
+	for i := 0; len(nodes) > 0; i++ {
+		size := w.Sizes[min(i, len(w.Sizes)-1)]
+		n := min(size.Resolve(total), len(nodes))
+
+		batches = append(batches, nodes[:n])
+		nodes = nodes[n:]
+	}
+
+	return batches
+}
+
+// RunWaves runs fn over nodes wave by wave, with at most n operations in
+// flight within a wave, and no more failure domains and nodes per domain
+// than w.Topology allows. Without wave sizes, all nodes are a single wave.
+//
+// Within a wave, failed nodes don't stop the other nodes until more than
+// w.MaxFailures failed. The rollout halts after a wave with more failures;
+// otherwise it continues with the next wave after w.Pause and w.Control let
+// it. The errors of all failed nodes are returned.
+func RunWaves(ctx context.Context, nodes []*topf.Node, w Waves, n int, fn NodeFunc, logger *slog.Logger) error {
+	if w.Topology.Enabled() {
+		nodes = w.Topology.Order(nodes)
+		logTopology(nodes, w.Topology, logger)
+	}
+
+	if len(w.Sizes) == 0 {
+		// failures are only tolerated within waves
+		single := w
+		single.MaxFailures = 0
+
+		return errors.Join(runPool(ctx, nodes, n, single, fn, logger)...)
+	}
+
+	batches := w.Split(nodes)
+
+	var errs []error
+
+	for i, batch := range batches {
+		logger := logger.With("wave", fmt.Sprintf("%d/%d", i+1, len(batches)))
+
+		if i > 0 && w.Pause > 0 {
+			logger.Info("pausing before next wave", "pause", w.Pause)
+
+			select {
+			case <-ctx.Done():
+				return errors.Join(append(errs, ctx.Err())...)
+			case <-time.After(w.Pause):
+			}
+		}
+
+		hosts := make([]string, len(batch))
+		for j, node := range batch {
+			hosts[j] = node.Node.Host
+		}
+
+		// a skipped wave still runs, so that every node is reported as skipped
+		switch err := w.Control.BeforeWave(ctx, i+1, len(batches), hosts); {
+		case errors.Is(err, control.ErrSkipped):
+			logger.Warn("skipping wave", "nodes", len(batch))
+		case err != nil:
+			return errors.Join(append(errs, err)...)
+		default:
+			logger.Info("starting wave", "nodes", len(batch), "concurrency", min(n, len(batch)))
+		}
+
+		waveErrs := runPool(ctx, batch, n, w, fn, logger)
+		errs = append(errs, waveErrs...)
+
+		// the abort may have come in after the last node of the wave started
+		if w.Control.Aborted() {
+			if !slices.ContainsFunc(waveErrs, func(err error) bool { return errors.Is(err, control.ErrAborted) }) {
+				errs = append(errs, control.ErrAborted)
+			}
+
+			return errors.Join(errs...)
+		}
+
+		if len(waveErrs) > w.MaxFailures {
+			return fmt.Errorf("halting rollout: %d nodes failed in wave %d, more than the %d tolerated: %w",
+				len(waveErrs), i+1, w.MaxFailures, errors.Join(errs...))
+		}
+
+		if len(waveErrs) > 0 {
+			logger.Warn("wave completed with tolerated failures", "failed", len(waveErrs), "max_failures", w.MaxFailures)
+			continue
+		}
+
+		logger.Info("wave completed")
+	}
+
+	return errors.Join(errs...)
+}
+
+// logTopology logs the failure domains of a rollout, and warns about nodes
+// without one, as they all share a single domain.
+func logTopology(nodes []*topf.Node, topology Topology, logger *slog.Logger) {
+	domains := make(map[string]struct{})
+
+	for _, node := range nodes {
+		domain, ok := topology.Domain(node)
+		if !ok {
+			logger.Warn("node has no failure domain, it shares one with all other nodes without it", "node", node.Node.Host, "key", topology.Key)
+		}
+
+		domains[domain] = struct{}{}
+	}
+
+	logger.Info("limiting failure domains in flight", "key", topology.Key, "domains", len(domains),
+		"max_domains", topology.maxDomains(), "max_per_domain", topology.MaxPerDomain)
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package nodepool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/control"
+	"github.com/postfinance/topf/internal/topf"
+)
+
+func TestParseWaves(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "1, 10%,25%,rest", want: "1,10%,25%,rest"},
+		{input: "2", want: "2"},
+		{input: "rest,1", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "150%", wantErr: true},
+		{input: "1,,2", wantErr: true},
+		{input: "all", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			sizes, err := ParseWaves(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWaves(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			var got []string
+			for _, s := range sizes {
+				got = append(got, s.String())
+			}
+
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("ParseWaves(%q) = %v, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWavesSplit(t *testing.T) {
+	mustParse := func(value string) []WaveSize {
+		sizes, err := ParseWaves(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return sizes
+	}
+
+	tests := []struct {
+		waves string
+		nodes int
+		want  []int
+	}{
+		{waves: "", nodes: 5, want: []int{5}},
+		{waves: "", nodes: 0, want: nil},
+		{waves: "1,10%,25%,rest", nodes: 40, want: []int{1, 4, 10, 25}},
+		{waves: "1,50%", nodes: 10, want: []int{1, 5, 4}},
+		{waves: "2", nodes: 5, want: []int{2, 2, 1}},
+		{waves: "1,rest", nodes: 1, want: []int{1}},
+		{waves: "10%,rest", nodes: 3, want: []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.waves, func(t *testing.T) {
+			var got []int
+			for _, batch := range (Waves{Sizes: mustParse(tt.waves)}).Split(nodes(tt.nodes)) {
+				got = append(got, len(batch))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Split(%d nodes) = %v, want %v", tt.nodes, got, tt.want)
+			}
+		})
+	}
+}
+
+// waveRecorder records the nodes processed and fails the given hosts
+type waveRecorder struct {
+	mu        sync.Mutex
+	processed []string
+	fail      []string
+}
+
+func (r *waveRecorder) run(_ context.Context, node *topf.Node, _ *slog.Logger) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processed = append(r.processed, node.Node.Host)
+
+	if slices.Contains(r.fail, node.Node.Host) {
+		return errors.New("failed " + node.Node.Host)
+	}
+
+	return nil
+}
+
+func TestRunWaves(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	sizes, _ := ParseWaves("1,2,rest")
+
+	t.Run("all waves", func(t *testing.T) {
+		rec := &waveRecorder{}
+
+		if err := RunWaves(context.Background(), nodes(6), Waves{Sizes: sizes}, 1, rec.run, logger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := []string{"node-0", "node-1", "node-2", "node-3", "node-4", "node-5"}; !slices.Equal(rec.processed, want) {
+			t.Errorf("processed = %v, want %v", rec.processed, want)
+		}
+	})
+
+	t.Run("canary failure halts", func(t *testing.T) {
+		rec := &waveRecorder{fail: []string{"node-0"}}
+
+		err := RunWaves(context.Background(), nodes(6), Waves{Sizes: sizes}, 1, rec.run, logger)
+		if err == nil || !strings.Contains(err.Error(), "1 nodes failed in wave 1") {
+			t.Fatalf("err = %v, want a halt in wave 1", err)
+		}
+
+		if len(rec.processed) != 1 {
+			t.Errorf("processed = %v, want only the canary", rec.processed)
+		}
+	})
+
+	t.Run("tolerated failures", func(t *testing.T) {
+		rec := &waveRecorder{fail: []string{"node-1"}}
+
+		err := RunWaves(context.Background(), nodes(6), Waves{Sizes: sizes, MaxFailures: 1}, 1, rec.run, logger)
+		if err == nil || strings.Contains(err.Error(), "halting") || !strings.Contains(err.Error(), "failed node-1") {
+			t.Fatalf("err = %v, want the tolerated failure only", err)
+		}
+
+		if len(rec.processed) != 6 {
+			t.Errorf("processed = %v, want all nodes", rec.processed)
+		}
+	})
+
+	t.Run("too many failures in a later wave", func(t *testing.T) {
+		rec := &waveRecorder{fail: []string{"node-1", "node-2"}}
+
+		err := RunWaves(context.Background(), nodes(6), Waves{Sizes: sizes, MaxFailures: 1}, 1, rec.run, logger)
+		if err == nil || !strings.Contains(err.Error(), "2 nodes failed in wave 2") {
+			t.Fatalf("err = %v, want a halt in wave 2", err)
+		}
+
+		if len(rec.processed) != 3 {
+			t.Errorf("processed = %v, want the first two waves", rec.processed)
+		}
+	})
+
+	t.Run("pause", func(t *testing.T) {
+		rec := &waveRecorder{}
+		start := time.Now()
+
+		if err := RunWaves(context.Background(), nodes(4), Waves{Sizes: sizes, Pause: 20 * time.Millisecond}, 1, rec.run, logger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// two pauses between three waves
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("elapsed = %s, want at least 40ms", elapsed)
+		}
+	})
+
+	t.Run("cancelled during pause", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rec := &waveRecorder{}
+
+		err := RunWaves(ctx, nodes(3), Waves{Sizes: sizes, Pause: time.Hour}, 1, rec.run, logger)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+
+		if len(rec.processed) != 1 {
+			t.Errorf("processed = %v, want the first wave only", rec.processed)
+		}
+	})
+
+	t.Run("aborted by operator", func(t *testing.T) {
+		rec := &waveRecorder{}
+		controller := control.New(control.PauseAfterWave, logger)
+		result := make(chan error, 1)
+
+		go func() {
+			result <- RunWaves(context.Background(), nodes(6), Waves{Sizes: sizes, Control: controller}, 1, rec.run, logger)
+		}()
+
+		for !strings.HasPrefix(controller.Status(), "paused") {
+			time.Sleep(time.Millisecond)
+		}
+
+		_ = controller.Send(control.CommandAbort)
+
+		if err := <-result; !errors.Is(err, control.ErrAborted) {
+			t.Fatalf("err = %v, want control.ErrAborted", err)
+		}
+
+		if len(rec.processed) != 1 {
+			t.Errorf("processed = %v, want the first wave only", rec.processed)
+		}
+	})
+}
@@ -0,0 +1,381 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package notify posts the events of rolling operations to the webhooks
+// configured in topf.yaml, as generic JSON or as Slack and Mattermost
+// compatible messages
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// Results of a finished command
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+const (
+	// queueSize is the number of events buffered for delivery; events are
+	// dropped when the webhooks can't keep up
+	queueSize = 1024
+	// defaultRetryDelay is the delay before the first retry, doubled for
+	// every further one
+	defaultRetryDelay = time.Second
+)
+
+// defaultTemplates are the messages of the events without a configured one
+//
+//nolint:gochecknoglobals // read-only defaults
+var defaultTemplates = map[string]string{
+	config.EventStart: `topf {{ .Command }} started on cluster {{ .Cluster }}`,
+	config.EventNode: `topf {{ .Command }} on cluster {{ .Cluster }}: node {{ .Node.Host }} ({{ .Node.Role }}) {{ .Node.Status }}` +
+		`{{ with .Node.Error }}: {{ . }}{{ end }}`,
+	config.EventFinish: `topf {{ .Command }} on cluster {{ .Cluster }} {{ if eq .Result "success" }}succeeded{{ else }}failed{{ end }} after {{ .Duration }}: ` +
+		`{{ .Summary.Done }} done, {{ .Summary.Unchanged }} unchanged, {{ .Summary.Skipped }} skipped, {{ .Summary.Failed }} failed, {{ .Summary.Pending }} pending` +
+		`{{ with .Error }} ({{ . }}){{ end }}`,
+}
+
+// Event is posted to the webhooks. The JSON format posts it as is, the Slack
+// format only its Text.
+type Event struct {
+	// Event is "start", "node" or "finish"
+	Event   string    `json:"event"`
+	Command string    `json:"command"`
+	Cluster string    `json:"cluster"`
+	Time    time.Time `json:"time"`
+	// Node is the node of node events
+	Node *Node `json:"node,omitempty"`
+	// Result is "success" or "failure", for finish events
+	Result string `json:"result,omitempty"`
+	// Error is the error the command failed with, for finish events
+	Error string `json:"error,omitempty"`
+	// Duration is how long the command ran, for finish events
+	Duration        time.Duration `json:"-"`
+	DurationSeconds float64       `json:"durationSeconds,omitempty"`
+	// Summary counts the nodes per outcome, for finish events
+	Summary *Summary `json:"summary,omitempty"`
+	// Text is the rendered message of the event
+	Text string `json:"text"`
+}
+
+// Node is the outcome of a node
+type Node struct {
+	Host string `json:"host"`
+	Role string `json:"role"`
+	// Status is the final phase of the node: done, unchanged, skipped or failed
+	Status          string        `json:"status"`
+	Error           string        `json:"error,omitempty"`
+	Duration        time.Duration `json:"-"`
+	DurationSeconds float64       `json:"durationSeconds"`
+}
+
+// Summary counts the nodes of a command per outcome
+type Summary struct {
+	Total     int `json:"total"`
+	Done      int `json:"done"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+	Pending   int `json:"pending"`
+}
+
+// Notifier delivers events to webhooks in the background, in order. A nil
+// *Notifier sends nothing.
+type Notifier struct {
+	targets []*target
+	command string
+	cluster string
+	logger  *slog.Logger
+	client  *http.Client
+	// retryDelay is the delay before the first retry
+	retryDelay time.Duration
+
+	started time.Time
+	queue   chan *Event
+	done    chan struct{}
+	once    sync.Once
+}
+
+// target is a configured webhook with its parsed templates
+type target struct {
+	cfg       config.Notification
+	templates map[string]*template.Template
+}
+
+// New returns a notifier for the events of command, or nil if no webhooks
+// are configured. Events are delivered until Finish is called.
+func New(cfgs []config.Notification, cluster, command string, logger *slog.Logger) (*Notifier, error) {
+	if len(cfgs) == 0 {
+		return nil, nil //nolint:nilnil // a nil notifier disables notifications
+	}
+
+	n := &Notifier{
+		command:    command,
+		cluster:    cluster,
+		logger:     logger,
+		client:     &http.Client{},
+		retryDelay: defaultRetryDelay,
+		queue:      make(chan *Event, queueSize),
+		done:       make(chan struct{}),
+	}
+
+	for _, cfg := range cfgs {
+		t := &target{cfg: cfg, templates: make(map[string]*template.Template)}
+
+		for event, text := range defaultTemplates {
+			if custom, ok := cfg.Templates[event]; ok {
+				text = custom
+			}
+
+			tmpl, err := template.New(event).Funcs(sprig.TxtFuncMap()).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("notification template %s: %w", event, err)
+			}
+
+			t.templates[event] = tmpl
+		}
+
+		n.targets = append(n.targets, t)
+	}
+
+	go n.deliver()
+
+	return n, nil
+}
+
+// Start sends the start event
+func (n *Notifier) Start() {
+	if n == nil {
+		return
+	}
+
+	n.started = time.Now()
+	n.send(&Event{Event: config.EventStart})
+}
+
+// Node sends the node event of a node that reached a final phase. It
+// matches the signature of progress.Tracker.Observe.
+func (n *Notifier) Node(node progress.Node) {
+	if n == nil {
+		return
+	}
+
+	n.send(&Event{Event: config.EventNode, Node: describeNode(node)})
+}
+
+// Finish sends the finish event with the outcome of the command, cmdErr, and
+// a summary of nodes, then waits until all events are delivered or given up.
+func (n *Notifier) Finish(cmdErr error, nodes []progress.Node) {
+	if n == nil {
+		return
+	}
+
+	event := &Event{
+		Event:    config.EventFinish,
+		Result:   ResultSuccess,
+		Duration: time.Since(n.started).Round(time.Second),
+		Summary:  summarize(nodes),
+	}
+	event.DurationSeconds = event.Duration.Seconds()
+
+	if cmdErr != nil {
+		event.Result = ResultFailure
+		event.Error = cmdErr.Error()
+	}
+
+	n.send(event)
+
+	n.once.Do(func() { close(n.queue) })
+	<-n.done
+}
+
+// send queues event for delivery, without blocking the command
+func (n *Notifier) send(event *Event) {
+	event.Command = n.command
+	event.Cluster = n.cluster
+	event.Time = time.Now()
+
+	select {
+	case n.queue <- event:
+	default:
+		n.logger.Warn("notification queue is full, dropping event", "event", event.Event)
+	}
+}
+
+func (n *Notifier) deliver() {
+	defer close(n.done)
+
+	for event := range n.queue {
+		for _, t := range n.targets {
+			if !t.wants(event) {
+				continue
+			}
+
+			logger := n.logger.With("event", event.Event, "webhook", host(t.cfg.URL))
+
+			if err := n.post(t, event); err != nil {
+				logger.Warn("failed to send notification", "error", err)
+				continue
+			}
+
+			logger.Debug("notification sent")
+		}
+	}
+}
+
+// wants reports whether the target is configured for event
+func (t *target) wants(event *Event) bool {
+	if slices.Contains(t.cfg.Events, event.Event) {
+		return true
+	}
+
+	return event.Event == config.EventNode && event.Node.Status == string(progress.PhaseFailed) &&
+		slices.Contains(t.cfg.Events, config.EventNodeFailed)
+}
+
+// post renders event for the target and posts it, retrying failed attempts
+// with an exponential backoff
+func (n *Notifier) post(t *target, event *Event) error {
+	var text strings.Builder
+	if err := t.templates[event.Event].Execute(&text, event); err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+
+	rendered := *event
+	rendered.Text = text.String()
+
+	var (
+		body []byte
+		err  error
+	)
+
+	if t.cfg.Format == config.NotificationSlack {
+		body, err = json.Marshal(map[string]string{"text": rendered.Text})
+	} else {
+		body, err = json.Marshal(&rendered)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+
+	delay := n.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		retry, err := n.attempt(t, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= t.cfg.NotificationRetries() {
+			return err
+		}
+
+		n.logger.Debug("notification failed, retrying", "webhook", host(t.cfg.URL), "error", err, "delay", delay)
+
+		time.Sleep(delay)
+
+		delay *= 2
+	}
+}
+
+// attempt posts body once and reports whether a failure may be retried
+func (n *Notifier) attempt(t *target, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.NotificationTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.New("invalid webhook URL")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// the error would include the URL, which often is a secret itself
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	// client errors won't go away by retrying, except for rate limits
+	retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+
+	return retry, fmt.Errorf("webhook responded with %s", resp.Status)
+}
+
+func describeNode(node progress.Node) *Node {
+	duration := node.Elapsed(node.Finished).Round(time.Second)
+
+	return &Node{
+		Host:            node.Host,
+		Role:            node.Role,
+		Status:          string(node.Phase),
+		Error:           node.Error,
+		Duration:        duration,
+		DurationSeconds: duration.Seconds(),
+	}
+}
+
+func summarize(nodes []progress.Node) *Summary {
+	summary := &Summary{Total: len(nodes)}
+
+	for _, node := range nodes {
+		switch node.Phase {
+		case progress.PhaseDone:
+			summary.Done++
+		case progress.PhaseUnchanged:
+			summary.Unchanged++
+		case progress.PhaseSkipped:
+			summary.Skipped++
+		case progress.PhaseFailed:
+			summary.Failed++
+		default:
+			summary.Pending++
+		}
+	}
+
+	return summary
+}
+
+// host returns the host of a webhook URL, to identify it in logs without
+// revealing the token often part of its path
+func host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
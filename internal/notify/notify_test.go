@@ -0,0 +1,223 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/progress"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+// webhook records the bodies posted to it and answers with the next status
+// of statuses, then 200
+type webhook struct {
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.bodies = append(w.bodies, body)
+	w.headers = append(w.headers, r.Header)
+
+	if len(w.statuses) > 0 {
+		rw.WriteHeader(w.statuses[0])
+		w.statuses = w.statuses[1:]
+	}
+}
+
+func newWebhook(t *testing.T, statuses ...int) (*webhook, string) {
+	t.Helper()
+
+	w := &webhook{statuses: statuses}
+	server := httptest.NewServer(w)
+	t.Cleanup(server.Close)
+
+	return w, server.URL
+}
+
+func newTestNotifier(t *testing.T, cfgs ...config.Notification) *Notifier {
+	t.Helper()
+
+	n, err := New(cfgs, "test", "upgrade", slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.retryDelay = time.Millisecond
+
+	return n
+}
+
+func retries(n int) *int {
+	return &n
+}
+
+func TestNilNotifier(t *testing.T) {
+	n, err := New(nil, "test", "apply", slog.New(slog.DiscardHandler))
+	if err != nil || n != nil {
+		t.Fatalf("New() without webhooks = %v, %v", n, err)
+	}
+
+	// must not panic
+	n.Start()
+	n.Node(progress.Node{Host: "node1"})
+	n.Finish(nil, nil)
+}
+
+func TestNotifier(t *testing.T) {
+	generic, genericURL := newWebhook(t)
+	chat, chatURL := newWebhook(t)
+
+	n := newTestNotifier(t,
+		config.Notification{
+			URL:     genericURL,
+			Format:  config.NotificationJSON,
+			Events:  []string{config.EventStart, config.EventNode, config.EventFinish},
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		},
+		config.Notification{
+			URL:       chatURL,
+			Format:    config.NotificationSlack,
+			Events:    []string{config.EventNodeFailed, config.EventFinish},
+			Templates: map[string]string{config.EventFinish: `{{ .Command }} {{ .Result }}: {{ .Summary.Failed }}/{{ .Summary.Total }} failed`},
+		},
+	)
+
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	nodes := []progress.Node{
+		{Host: "node1", Role: "worker", Phase: progress.PhaseDone, Started: started, Finished: started.Add(time.Minute)},
+		{Host: "node2", Role: "worker", Phase: progress.PhaseFailed, Error: "boom"},
+		{Host: "node3", Role: "worker", Phase: progress.PhasePending},
+	}
+
+	n.Start()
+	n.Node(nodes[0])
+	n.Node(nodes[1])
+	n.Finish(errors.New("node2: boom"), nodes)
+
+	if len(generic.bodies) != 4 {
+		t.Fatalf("generic webhook got %d events, want 4", len(generic.bodies))
+	}
+
+	var events []Event
+
+	for _, body := range generic.bodies {
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		events = append(events, event)
+	}
+
+	for i, want := range []string{config.EventStart, config.EventNode, config.EventNode, config.EventFinish} {
+		if events[i].Event != want || events[i].Command != "upgrade" || events[i].Cluster != "test" {
+			t.Errorf("event %d = %s of %s on %s, want %s", i, events[i].Event, events[i].Command, events[i].Cluster, want)
+		}
+	}
+
+	if node := events[1].Node; node == nil || node.Host != "node1" || node.Status != "done" || node.DurationSeconds != 60 {
+		t.Errorf("unexpected node event: %s", generic.bodies[1])
+	}
+
+	if want := "topf upgrade on cluster test: node node2 (worker) failed: boom"; events[2].Text != want {
+		t.Errorf("node text = %q, want %q", events[2].Text, want)
+	}
+
+	finish := events[3]
+	if finish.Result != ResultFailure || finish.Error != "node2: boom" || finish.Summary == nil ||
+		*finish.Summary != (Summary{Total: 3, Done: 1, Failed: 1, Pending: 1}) {
+		t.Errorf("unexpected finish event: %s", generic.bodies[3])
+	}
+
+	if got := generic.headers[0].Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization header = %q", got)
+	}
+
+	// only the failed node and the end, as chat messages
+	var texts []string
+
+	for _, body := range chat.bodies {
+		var message map[string]string
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Fatal(err)
+		}
+
+		texts = append(texts, message["text"])
+	}
+
+	if len(texts) != 2 || texts[0] != events[2].Text || texts[1] != "upgrade failure: 1/3 failed" {
+		t.Errorf("chat messages = %q", texts)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantAttempts int
+	}{
+		{name: "server errors are retried", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}, retries: 3, wantAttempts: 3},
+		{name: "retries are limited", statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, retries: 1, wantAttempts: 2},
+		{name: "client errors are not retried", statuses: []int{http.StatusNotFound}, retries: 3, wantAttempts: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w, url := newWebhook(t, tt.statuses...)
+
+			n := newTestNotifier(t, config.Notification{
+				URL:     url,
+				Format:  config.NotificationSlack,
+				Events:  []string{config.EventFinish},
+				Retries: retries(tt.retries),
+			})
+
+			n.Finish(nil, nil)
+
+			if len(w.bodies) != tt.wantAttempts {
+				t.Errorf("got %d attempts, want %d", len(w.bodies), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-release }))
+
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	n := newTestNotifier(t, config.Notification{
+		URL:     server.URL,
+		Format:  config.NotificationJSON,
+		Events:  []string{config.EventFinish},
+		Timeout: 20 * time.Millisecond,
+		Retries: retries(1),
+	})
+
+	start := time.Now()
+
+	n.Finish(nil, nil)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Finish() took %s despite the timeout", elapsed)
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package progress
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minLogLines is the height of the log pane below which node rows are
+// collapsed to make room for logs
+const minLogLines = 5
+
+// ANSI escape sequences used to color phases
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorDim    = "\x1b[2m"
+)
+
+// Dashboard renders the progress of a tracker and the lines of a log buffer
+// as a terminal screen, with one row per node above a scrollable log pane.
+type Dashboard struct {
+	// Title is shown in the header line, usually the command name
+	Title string
+	// Color enables ANSI colors for the node phases
+	Color bool
+
+	tracker *Tracker
+	logs    *LogBuffer
+	started time.Time
+
+	mu sync.Mutex
+	// scroll is the number of log lines hidden below the pane, 0 follows
+	// new lines
+	scroll int
+}
+
+// NewDashboard returns a dashboard of the given tracker and log buffer,
+// started now
+func NewDashboard(title string, tracker *Tracker, logs *LogBuffer) *Dashboard {
+	return &Dashboard{
+		Title:   title,
+		tracker: tracker,
+		logs:    logs,
+		started: time.Now(),
+	}
+}
+
+// Scroll moves the log pane up (towards older lines) by the given number of
+// lines, or down if negative
+func (d *Dashboard) Scroll(lines int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.scroll = min(max(d.scroll+lines, 0), len(d.logs.Lines()))
+}
+
+// Follow scrolls the log pane to the newest line and keeps following new lines
+func (d *Dashboard) Follow() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.scroll = 0
+}
+
+// Render returns the lines of a screen of the given size, as of now
+func (d *Dashboard) Render(width, height int, now time.Time) []string {
+	nodes := d.tracker.Nodes()
+	maxRows := max(height-minLogLines-3, 1)
+	screen := d.renderNodes(nodes, width, maxRows, now)
+
+	d.mu.Lock()
+	scroll := d.scroll
+	d.mu.Unlock()
+
+	logs := d.logs.Lines()
+	paneHeight := max(height-len(screen)-1, 0)
+	end := len(logs) - min(scroll, max(len(logs)-paneHeight, 0))
+	start := max(end-paneHeight, 0)
+
+	separator := "── logs (↑/↓ PgUp/PgDn scroll, End follow, Ctrl-C abort) "
+	if scroll > 0 {
+		separator = fmt.Sprintf("── logs (%d newer lines below, End to follow) ", len(logs)-end)
+	}
+
+	screen = append(screen, truncate(separator+strings.Repeat("─", width), width))
+
+	for _, line := range logs[start:end] {
+		screen = append(screen, truncate(line, width))
+	}
+
+	return screen
+}
+
+// Summary returns the header and node rows of the final screen, to be kept
+// on the terminal after the dashboard is closed
+func (d *Dashboard) Summary(width int, now time.Time) []string {
+	nodes := d.tracker.Nodes()
+
+	return d.renderNodes(nodes, width, len(nodes)+1, now)
+}
+
+// renderNodes returns the header line and at most maxRows node rows (plus
+// the column header). When nodes don't fit, nodes in progress and failed
+// nodes are shown first.
+func (d *Dashboard) renderNodes(nodes []Node, width, maxRows int, now time.Time) []string {
+	var finished, failed int
+
+	for _, n := range nodes {
+		if n.Phase.Final() {
+			finished++
+		}
+
+		if n.Phase == PhaseFailed {
+			failed++
+		}
+	}
+
+	header := fmt.Sprintf("topf %s: %d/%d nodes finished, %d failed, elapsed %s",
+		d.Title, finished, len(nodes), failed, now.Sub(d.started).Round(time.Second))
+
+	nodes, hidden := selectRows(nodes, maxRows)
+
+	hostWidth, roleWidth := len("HOST"), len("ROLE")
+	for _, n := range nodes {
+		hostWidth = max(hostWidth, len(n.Host))
+		roleWidth = max(roleWidth, len(n.Role))
+	}
+
+	phaseWidth := len(PhaseHealthGates)
+	row := func(host, role, phase, elapsed, message string) string {
+		line := fmt.Sprintf("%-*s  %-*s  %-*s  %8s  %s", hostWidth, host, roleWidth, role, phaseWidth, phase, elapsed, message)
+
+		return strings.TrimRight(line, " ")
+	}
+
+	lines := []string{
+		truncate(header, width),
+		truncate(row("HOST", "ROLE", "PHASE", "ELAPSED", "MESSAGE"), width),
+	}
+
+	for _, n := range nodes {
+		message := n.Message
+		if n.Error != "" {
+			message = n.Error
+		}
+
+		elapsed := ""
+		if !n.Started.IsZero() {
+			elapsed = n.Elapsed(now).Round(time.Second).String()
+		}
+
+		line := truncate(row(n.Host, n.Role, string(n.Phase), elapsed, message), width)
+		lines = append(lines, d.colorize(line, n.Phase))
+	}
+
+	if hidden > 0 {
+		lines = append(lines, truncate(fmt.Sprintf("... and %d more nodes", hidden), width))
+	}
+
+	return lines
+}
+
+// colorize wraps a node row in the color of its phase, if colors are enabled
+func (d *Dashboard) colorize(line string, phase Phase) string {
+	if !d.Color {
+		return line
+	}
+
+	switch phase {
+	case PhaseDone, PhaseUnchanged:
+		return colorGreen + line + colorReset
+	case PhaseFailed:
+		return colorRed + line + colorReset
+	case PhasePending, PhaseSkipped:
+		return colorDim + line + colorReset
+	default:
+		return colorYellow + line + colorReset
+	}
+}
+
+// selectRows returns at most maxRows nodes, keeping a row for the number of
+// hidden nodes when they don't all fit
+func selectRows(nodes []Node, maxRows int) ([]Node, int) {
+	if len(nodes) <= maxRows {
+		return nodes, 0
+	}
+
+	nodes = slices.Clone(nodes)
+	slices.SortStableFunc(nodes, func(a, b Node) int { return cmp.Compare(rowRank(a.Phase), rowRank(b.Phase)) })
+
+	return nodes[:maxRows-1], len(nodes) - (maxRows - 1)
+}
+
+// rowRank orders node rows by relevance when they don't all fit the screen
+func rowRank(phase Phase) int {
+	switch {
+	case phase == PhaseFailed:
+		return 1
+	case phase == PhasePending:
+		return 2
+	case phase.Final():
+		return 3
+	default:
+		return 0
+	}
+}
+
+// truncate cuts line to at most width runes
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+
+	if width <= 0 {
+		return ""
+	}
+
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+func TestDashboardRender(t *testing.T) {
+	tr := newTestTracker()
+	cp, w1 := newNode("cp1", config.RoleControlPlane), newNode("w1", config.RoleWorker)
+	tr.AddNodes([]*topf.Node{cp, w1})
+	tr.Set(cp, PhaseStabilizing)
+	tr.SetMessage("cp1", "waiting for node to become ready")
+
+	logs := NewLogBuffer(100)
+	for i := range 20 {
+		fmt.Fprintf(logs, "log line %d\n", i)
+	}
+
+	d := NewDashboard("upgrade", tr, logs)
+	d.started = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := d.started.Add(90 * time.Second)
+
+	screen := d.Render(80, 12, now)
+	if len(screen) != 12 {
+		t.Fatalf("got %d lines, want 12:\n%s", len(screen), strings.Join(screen, "\n"))
+	}
+
+	if want := "topf upgrade: 0/2 nodes finished, 0 failed, elapsed 1m30s"; screen[0] != want {
+		t.Errorf("header = %q, want %q", screen[0], want)
+	}
+
+	if want := "cp1   control-plane  stabilizing      1m29s  waiting for node to become ready"; screen[2] != want {
+		t.Errorf("row of cp1 = %q, want %q", screen[2], want)
+	}
+
+	if want := "w1    worker         pending"; screen[3] != want {
+		t.Errorf("row of w1 = %q, want %q", screen[3], want)
+	}
+
+	// the log pane follows the newest lines
+	if screen[5] != "log line 13" || screen[11] != "log line 19" {
+		t.Errorf("log pane = %q", screen[5:])
+	}
+
+	for i, line := range screen {
+		if n := len([]rune(line)); n > 80 {
+			t.Errorf("line %d has %d runes, want at most 80", i, n)
+		}
+	}
+
+	d.Scroll(5)
+
+	screen = d.Render(80, 12, now)
+	if screen[5] != "log line 8" || screen[11] != "log line 14" {
+		t.Errorf("scrolled log pane = %q", screen[5:])
+	}
+
+	if !strings.Contains(screen[4], "5 newer lines below") {
+		t.Errorf("separator = %q", screen[4])
+	}
+
+	// scrolling stops at the oldest line
+	d.Scroll(100)
+
+	if screen = d.Render(80, 12, now); screen[5] != "log line 0" {
+		t.Errorf("log pane scrolled to the top starts with %q", screen[5])
+	}
+
+	d.Follow()
+
+	if screen = d.Render(80, 12, now); screen[11] != "log line 19" {
+		t.Errorf("log pane following ends with %q", screen[11])
+	}
+}
+
+func TestDashboardCollapsesRows(t *testing.T) {
+	tr := newTestTracker()
+
+	var nodes []*topf.Node
+	for i := range 10 {
+		nodes = append(nodes, newNode(fmt.Sprintf("w%d", i), config.RoleWorker))
+	}
+
+	tr.AddNodes(nodes)
+	tr.Finish(nodes[0], nil)
+	tr.Set(nodes[7], PhaseDraining)
+
+	d := NewDashboard("apply", tr, NewLogBuffer(10))
+	screen := d.Render(80, 12, time.Now())
+
+	// 4 rows fit: the node in progress first, then pending nodes and the
+	// number of hidden nodes
+	if !strings.HasPrefix(screen[2], "w7 ") || !strings.HasPrefix(screen[3], "w1 ") || !strings.HasPrefix(screen[4], "w2 ") {
+		t.Errorf("rows = %q", screen[2:5])
+	}
+
+	if screen[5] != "... and 7 more nodes" {
+		t.Errorf("hidden rows = %q", screen[5])
+	}
+
+	if got := len(d.Summary(80, time.Now())); got != 12 {
+		t.Errorf("summary has %d lines, want 12", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{line: "short", width: 10, want: "short"},
+		{line: "exactly", width: 7, want: "exactly"},
+		{line: "too long", width: 5, want: "too …"},
+		{line: "──────", width: 3, want: "──…"},
+		{line: "any", width: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.line, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}
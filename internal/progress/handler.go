@@ -0,0 +1,82 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package progress
+
+import (
+	"context"
+	"log/slog"
+)
+
+// nodeAttr is the log attribute identifying a node, see topf.Node.Attrs
+const nodeAttr = "node"
+
+// handler records the message of every log record carrying a node attribute
+// as the last message of that node.
+type handler struct {
+	tracker *Tracker
+	level   slog.Leveler
+	node    string
+	grouped bool
+}
+
+// Handler returns a log handler that records the message of every record of
+// at least the given level as the last message of the node it is about.
+func (t *Tracker) Handler(level slog.Leveler) slog.Handler {
+	return &handler{tracker: t, level: level}
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+//nolint:gocritic // slog.Handler interface requires passing Record by value
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	node := h.node
+
+	if !h.grouped {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == nodeAttr {
+				node = a.Value.String()
+				return false
+			}
+
+			return true
+		})
+	}
+
+	if node != "" {
+		h.tracker.SetMessage(node, r.Message)
+	}
+
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.grouped {
+		return h
+	}
+
+	h2 := *h
+
+	for _, a := range attrs {
+		if a.Key == nodeAttr {
+			h2.node = a.Value.String()
+		}
+	}
+
+	return &h2
+}
+
+// WithGroup returns a handler ignoring node attributes, as those of a group
+// are qualified by the group name.
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.grouped = true
+
+	return &h2
+}
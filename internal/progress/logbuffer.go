@@ -0,0 +1,84 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package progress
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ansiColor matches the ANSI color sequences of colored diffs
+var ansiColor = regexp.MustCompile(`\x1b\[[0-9;]*m`) //nolint:gochecknoglobals // compiled once
+
+// LogBuffer is a writer keeping the last lines written to it, for display in
+// the log pane of the dashboard. It is safe for concurrent use.
+type LogBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	partial  []byte
+	maxLines int
+	updated  chan struct{}
+}
+
+// NewLogBuffer returns a buffer keeping at most maxLines lines
+func NewLogBuffer(maxLines int) *LogBuffer {
+	return &LogBuffer{
+		maxLines: maxLines,
+		updated:  make(chan struct{}, 1),
+	}
+}
+
+// Write appends complete lines to the buffer. An incomplete trailing line is
+// kept until the rest of it is written.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.partial = append(b.partial, p...)
+
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		b.lines = append(b.lines, sanitizeLine(string(b.partial[:i])))
+		b.partial = b.partial[i+1:]
+	}
+
+	if over := len(b.lines) - b.maxLines; over > 0 {
+		b.lines = append([]string(nil), b.lines[over:]...)
+	}
+
+	select {
+	case b.updated <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+
+// Lines returns a copy of the buffered lines, oldest first
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]string(nil), b.lines...)
+}
+
+// Updated returns a channel receiving a value after lines were written.
+// Several writes may be coalesced into a single value.
+func (b *LogBuffer) Updated() <-chan struct{} {
+	return b.updated
+}
+
+// sanitizeLine drops carriage returns and colors and expands tabs, so that
+// the line width is the number of its runes
+func sanitizeLine(line string) string {
+	line = ansiColor.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+
+	return strings.ReplaceAll(line, "\t", "    ")
+}
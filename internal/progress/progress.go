@@ -0,0 +1,277 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package progress tracks the live progress of rolling operations, with the
+// current phase and last log message of every node, and renders it as an
+// interactive terminal dashboard.
+package progress
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+)
+
+// Phase is the step a node is currently at during a rolling operation
+type Phase string
+
+const (
+	// PhasePending means the node was not processed yet
+	PhasePending Phase = "pending"
+	// PhasePreflight means the pre-flight checks of the node are running
+	PhasePreflight Phase = "pre-flight"
+	// PhaseApplying means the machine config is being applied
+	PhaseApplying Phase = "applying"
+	// PhasePulling means the installer image is being pulled
+	PhasePulling Phase = "pulling"
+	// PhaseInstalling means the new Talos version is being installed
+	PhaseInstalling Phase = "installing"
+	// PhaseDraining means the Kubernetes node is being cordoned and drained
+	PhaseDraining Phase = "draining"
+	// PhaseResetting means the node is being reset
+	PhaseResetting Phase = "resetting"
+	// PhaseRebooting means the node is rebooting
+	PhaseRebooting Phase = "rebooting"
+	// PhaseStabilizing means topf waits for the node to become healthy
+	PhaseStabilizing Phase = "stabilizing"
+	// PhaseUncordoning means the Kubernetes node is being uncordoned
+	PhaseUncordoning Phase = "uncordoning"
+	// PhaseHealthGates means topf waits for the configured health gates
+	PhaseHealthGates Phase = "health-gates"
+	// PhaseDone means the operation finished successfully on the node
+	PhaseDone Phase = "done"
+	// PhaseUnchanged means the node already was in the desired state
+	PhaseUnchanged Phase = "unchanged"
+	// PhaseSkipped means the node was deliberately skipped
+	PhaseSkipped Phase = "skipped"
+	// PhaseFailed means the operation failed on the node
+	PhaseFailed Phase = "failed"
+)
+
+// Final returns whether no further progress is expected in this phase
+func (p Phase) Final() bool {
+	switch p {
+	case PhaseDone, PhaseUnchanged, PhaseSkipped, PhaseFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Node is the progress of a single node
+type Node struct {
+	Host  string
+	Role  string
+	Phase Phase
+	// Started is when the node left the pending phase
+	Started time.Time
+	// Finished is when the node reached a final phase
+	Finished time.Time
+	// Message is the last log message recorded for the node
+	Message string
+	Error   string
+}
+
+// Elapsed returns how long the node has been processed, as of now
+func (n *Node) Elapsed(now time.Time) time.Duration {
+	switch {
+	case n.Started.IsZero():
+		return 0
+	case !n.Finished.IsZero():
+		return n.Finished.Sub(n.Started)
+	default:
+		return now.Sub(n.Started)
+	}
+}
+
+// Tracker records the progress of the nodes of a rolling operation. A nil
+// *Tracker is valid and ignores all updates, so commands can record
+// unconditionally.
+type Tracker struct {
+	mu      sync.Mutex
+	nodes   []*Node
+	updated chan struct{}
+	now     func() time.Time
+	// observers are called for every node reaching a final phase
+	observers []func(Node)
+	// phaseObservers are called for every phase change
+	phaseObservers []func(Node)
+}
+
+// New returns an empty tracker
+func New() *Tracker {
+	return &Tracker{
+		updated: make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// AddNodes registers the nodes the operation acts on, in pending phase
+func (t *Tracker) AddNodes(nodes []*topf.Node) {
+	for _, node := range nodes {
+		t.update(node.Node.Host, string(node.Node.Role), func(*Node) {})
+	}
+}
+
+// Observe calls fn with the progress of every node reaching a final phase,
+// once per node. fn is called by the goroutine recording the phase, so it
+// must not block for long.
+func (t *Tracker) Observe(fn func(Node)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.observers = append(t.observers, fn)
+}
+
+// ObservePhases calls fn with the progress of a node whenever it moves to
+// another phase. Like with Observe, fn must not block for long.
+func (t *Tracker) ObservePhases(fn func(Node)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.phaseObservers = append(t.phaseObservers, fn)
+}
+
+// Set moves the node to the given phase
+func (t *Tracker) Set(node *topf.Node, phase Phase) {
+	t.set(node, phase, "")
+}
+
+// Finish moves the node to the done phase, or to the failed phase if err is
+// not nil. Changes detected in dry-run mode are not a failure.
+func (t *Tracker) Finish(node *topf.Node, err error) {
+	if err == nil || errors.Is(err, topf.ErrDryRunChangesDetected) {
+		t.Set(node, PhaseDone)
+		return
+	}
+
+	t.set(node, PhaseFailed, err.Error())
+}
+
+// set moves the node to phase, recording errMsg unless empty, and notifies
+// the phase observers of the change and the observers once the node reaches
+// a final phase
+func (t *Tracker) set(node *topf.Node, phase Phase, errMsg string) {
+	var (
+		snapshot                  Node
+		observers, phaseObservers []func(Node)
+	)
+
+	t.update(node.Node.Host, string(node.Node.Role), func(n *Node) {
+		now := t.now()
+
+		if n.Started.IsZero() && phase != PhasePending {
+			n.Started = now
+		}
+
+		if n.Phase != phase {
+			phaseObservers = t.phaseObservers
+		}
+
+		n.Phase = phase
+
+		if errMsg != "" {
+			n.Error = errMsg
+		}
+
+		if phase.Final() && n.Finished.IsZero() {
+			n.Finished = now
+			observers = t.observers
+		}
+
+		snapshot = *n
+	})
+
+	for _, fn := range phaseObservers {
+		fn(snapshot)
+	}
+
+	for _, fn := range observers {
+		fn(snapshot)
+	}
+}
+
+// SetMessage records the last log message of the node with the given host.
+// Messages of unknown hosts are ignored.
+func (t *Tracker) SetMessage(host, message string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, n := range t.nodes {
+		if n.Host == host {
+			n.Message = message
+			t.notify()
+
+			return
+		}
+	}
+}
+
+// Nodes returns a snapshot of the progress of all nodes, in the order they
+// were added
+func (t *Tracker) Nodes() []Node {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nodes := make([]Node, 0, len(t.nodes))
+	for _, n := range t.nodes {
+		nodes = append(nodes, *n)
+	}
+
+	return nodes
+}
+
+// Updated returns a channel receiving a value after the progress changed.
+// Several changes may be coalesced into a single value.
+func (t *Tracker) Updated() <-chan struct{} {
+	return t.updated
+}
+
+func (t *Tracker) update(host, role string, fn func(n *Node)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer t.notify()
+
+	for _, n := range t.nodes {
+		if n.Host == host {
+			fn(n)
+			return
+		}
+	}
+
+	n := &Node{Host: host, Role: role, Phase: PhasePending}
+	t.nodes = append(t.nodes, n)
+
+	fn(n)
+}
+
+// notify signals an update without blocking; must be called with mu held
+func (t *Tracker) notify() {
+	select {
+	case t.updated <- struct{}{}:
+	default:
+	}
+}
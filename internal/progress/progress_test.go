@@ -0,0 +1,198 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package progress
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+)
+
+func newNode(host string, role config.NodeRole) *topf.Node {
+	return &topf.Node{Node: &config.Node{Host: host, Role: role}}
+}
+
+// newTestTracker returns a tracker whose clock advances by a second per read
+func newTestTracker() *Tracker {
+	tr := New()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	return tr
+}
+
+func TestNilTracker(_ *testing.T) {
+	var tr *Tracker
+
+	// must not panic
+	node := newNode("node1", config.RoleWorker)
+	tr.AddNodes([]*topf.Node{node})
+	tr.Set(node, PhaseApplying)
+	tr.Finish(node, errors.New("boom"))
+	tr.SetMessage("node1", "message")
+	_ = tr.Nodes()
+}
+
+func TestTracker(t *testing.T) {
+	tr := newTestTracker()
+	cp, w1, w2 := newNode("cp1", config.RoleControlPlane), newNode("w1", config.RoleWorker), newNode("w2", config.RoleWorker)
+
+	tr.AddNodes([]*topf.Node{cp, w1, w2})
+	tr.Set(cp, PhasePulling)
+	tr.Set(cp, PhaseRebooting)
+	tr.Finish(cp, nil)
+	tr.Set(w1, PhaseApplying)
+	tr.Finish(w1, fmt.Errorf("apply: %w", errors.New("boom")))
+
+	nodes := tr.Nodes()
+	if len(nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(nodes))
+	}
+
+	for i, want := range []struct {
+		host  string
+		phase Phase
+		err   string
+	}{
+		{host: "cp1", phase: PhaseDone},
+		{host: "w1", phase: PhaseFailed, err: "apply: boom"},
+		{host: "w2", phase: PhasePending},
+	} {
+		if nodes[i].Host != want.host || nodes[i].Phase != want.phase || nodes[i].Error != want.err {
+			t.Errorf("node %d = %s %s %q, want %s %s %q", i, nodes[i].Host, nodes[i].Phase, nodes[i].Error, want.host, want.phase, want.err)
+		}
+	}
+
+	// started when pulling, finished two phase changes later
+	if got := nodes[0].Elapsed(time.Now()); got != 2*time.Second {
+		t.Errorf("elapsed of cp1 = %s, want 2s", got)
+	}
+
+	if got := nodes[2].Elapsed(time.Now()); got != 0 {
+		t.Errorf("elapsed of pending w2 = %s, want 0", got)
+	}
+
+	if nodes[0].Role != string(config.RoleControlPlane) {
+		t.Errorf("role of cp1 = %q", nodes[0].Role)
+	}
+}
+
+func TestTrackerDryRunChanges(t *testing.T) {
+	tr := New()
+	node := newNode("node1", config.RoleWorker)
+
+	tr.Finish(node, fmt.Errorf("node1: %w", topf.ErrDryRunChangesDetected))
+
+	if got := tr.Nodes()[0].Phase; got != PhaseDone {
+		t.Errorf("phase = %s, want %s", got, PhaseDone)
+	}
+}
+
+func TestTrackerUpdated(t *testing.T) {
+	tr := New()
+	node := newNode("node1", config.RoleWorker)
+
+	tr.Set(node, PhaseApplying)
+	tr.Set(node, PhaseStabilizing)
+
+	select {
+	case <-tr.Updated():
+	default:
+		t.Fatal("no update signaled")
+	}
+
+	// both changes are coalesced
+	select {
+	case <-tr.Updated():
+		t.Fatal("unexpected second update")
+	default:
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tr := New()
+	tr.AddNodes([]*topf.Node{newNode("node1", config.RoleWorker), newNode("node2", config.RoleWorker)})
+
+	logger := slog.New(tr.Handler(slog.LevelInfo)).With("command", "upgrade")
+
+	logger.With("node", "node1").Info("pulling image")
+	logger.Info("reboot initiated", "node", "node2")
+	logger.Debug("too verbose", "node", "node2")
+	logger.Info("not about a node")
+	logger.Info("unknown node", "node", "node3")
+	logger.WithGroup("etcd").Info("grouped", "node", "node1")
+
+	nodes := tr.Nodes()
+
+	if got := nodes[0].Message; got != "pulling image" {
+		t.Errorf("message of node1 = %q, want %q", got, "pulling image")
+	}
+
+	if got := nodes[1].Message; got != "reboot initiated" {
+		t.Errorf("message of node2 = %q, want %q", got, "reboot initiated")
+	}
+}
+
+func TestLogBuffer(t *testing.T) {
+	b := NewLogBuffer(3)
+
+	fmt.Fprint(b, "one\ntwo\n\x1b[32m+three\x1b[0m\n")
+	fmt.Fprint(b, "fo")
+	fmt.Fprint(b, "ur\r\n\tfive\nsix")
+
+	want := []string{"+three", "four", "    five"}
+	if got := b.Lines(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestTrackerObserve(t *testing.T) {
+	tr := New()
+	node := newNode("node1", config.RoleWorker)
+
+	var observed []Node
+
+	tr.Observe(func(n Node) { observed = append(observed, n) })
+
+	tr.Set(node, PhaseApplying)
+	tr.Finish(node, errors.New("boom"))
+	// a node reaching a final phase again is not observed twice
+	tr.Finish(node, errors.New("boom again"))
+
+	if len(observed) != 1 {
+		t.Fatalf("observed %d times, want once", len(observed))
+	}
+
+	if got := observed[0]; got.Host != "node1" || got.Phase != PhaseFailed || got.Error != "boom" {
+		t.Errorf("observed %s %s %q", got.Host, got.Phase, got.Error)
+	}
+}
+
+func TestTrackerObservePhases(t *testing.T) {
+	tr := New()
+	node := newNode("node1", config.RoleWorker)
+
+	var phases []Phase
+
+	tr.ObservePhases(func(n Node) { phases = append(phases, n.Phase) })
+
+	tr.AddNodes([]*topf.Node{node})
+	tr.Set(node, PhasePulling)
+	tr.Set(node, PhasePulling)
+	tr.Set(node, PhaseInstalling)
+	tr.Finish(node, nil)
+
+	if got := fmt.Sprint(phases); got != "[pulling installing done]" {
+		t.Errorf("observed phases %s, want only the changes", got)
+	}
+}
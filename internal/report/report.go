@@ -0,0 +1,263 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package report collects a machine-readable execution report of a topf
+// command, with one entry per node and an overall summary.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"go.yaml.in/yaml/v4"
+)
+
+// Format is the encoding of a written report
+type Format string
+
+const (
+	// FormatJSON encodes the report as JSON
+	FormatJSON Format = "json"
+	// FormatYAML encodes the report as YAML
+	FormatYAML Format = "yaml"
+)
+
+// FormatFromPath derives the report format from the file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("unsupported report file extension %q, valid extensions: .json, .yaml, .yml", filepath.Ext(path))
+	}
+}
+
+// Status is the outcome of a command for a single node
+type Status string
+
+const (
+	// StatusPending means the node was not processed (e.g. the command aborted earlier)
+	StatusPending Status = "pending"
+	// StatusUnchanged means the node already was in the desired state
+	StatusUnchanged Status = "unchanged"
+	// StatusChangesDetected means changes were detected in dry-run mode
+	StatusChangesDetected Status = "changes-detected"
+	// StatusSucceeded means the operation was performed successfully
+	StatusSucceeded Status = "succeeded"
+	// StatusSkipped means the node was deliberately skipped
+	StatusSkipped Status = "skipped"
+	// StatusFailed means the operation failed on the node
+	StatusFailed Status = "failed"
+)
+
+// Preflight is the result of the pre-flight checks of a node
+type Preflight string
+
+const (
+	// PreflightPassed means the node passed all pre-flight checks
+	PreflightPassed Preflight = "passed"
+	// PreflightFailed means the node failed at least one pre-flight check
+	PreflightFailed Preflight = "failed"
+)
+
+// Change is a single change detected on a node, such as a modified machine
+// config document or a new Talos version.
+type Change struct {
+	Target  string `json:"target"            yaml:"target"`
+	Status  string `json:"status,omitempty"  yaml:"status,omitempty"`
+	From    string `json:"from,omitempty"    yaml:"from,omitempty"`
+	To      string `json:"to,omitempty"      yaml:"to,omitempty"`
+	Added   int    `json:"added,omitempty"   yaml:"added,omitempty"`
+	Removed int    `json:"removed,omitempty" yaml:"removed,omitempty"`
+}
+
+// Node is the report entry of a single node
+type Node struct {
+	Host                 string    `json:"host"                           yaml:"host"`
+	Role                 string    `json:"role"                           yaml:"role"`
+	Preflight            Preflight `json:"preflight,omitempty"            yaml:"preflight,omitempty"`
+	PreflightError       string    `json:"preflightError,omitempty"       yaml:"preflightError,omitempty"`
+	Status               Status    `json:"status"                         yaml:"status"`
+	Changes              []Change  `json:"changes,omitempty"              yaml:"changes,omitempty"`
+	Mode                 string    `json:"mode,omitempty"                 yaml:"mode,omitempty"`
+	StabilizationSeconds float64   `json:"stabilizationSeconds,omitempty" yaml:"stabilizationSeconds,omitempty"`
+	Error                string    `json:"error,omitempty"                yaml:"error,omitempty"`
+}
+
+// Fail marks the node as failed with the given error
+func (n *Node) Fail(err error) {
+	n.Status = StatusFailed
+	n.Error = err.Error()
+}
+
+// FailPreflight marks the node as having failed the pre-flight checks
+func (n *Node) FailPreflight(err error) {
+	n.Preflight = PreflightFailed
+	n.PreflightError = err.Error()
+}
+
+// SetStabilization records how long the node took to stabilize
+func (n *Node) SetStabilization(d time.Duration) {
+	n.StabilizationSeconds = d.Round(time.Millisecond).Seconds()
+}
+
+// Summary counts the nodes per status
+type Summary struct {
+	Total           int `json:"total"           yaml:"total"`
+	Pending         int `json:"pending"         yaml:"pending"`
+	Unchanged       int `json:"unchanged"       yaml:"unchanged"`
+	ChangesDetected int `json:"changesDetected" yaml:"changesDetected"`
+	Succeeded       int `json:"succeeded"       yaml:"succeeded"`
+	Skipped         int `json:"skipped"         yaml:"skipped"`
+	Failed          int `json:"failed"          yaml:"failed"`
+}
+
+// Result is the overall outcome of a command
+type Result string
+
+const (
+	// ResultSuccess means the command finished without errors
+	ResultSuccess Result = "success"
+	// ResultChangesDetected means the command ran in dry-run mode and detected changes
+	ResultChangesDetected Result = "changes-detected"
+	// ResultFailure means the command returned an error
+	ResultFailure Result = "failure"
+)
+
+// Report is the execution report of a command. A nil *Report is valid and
+// ignores all updates, so commands can record unconditionally.
+type Report struct {
+	mu sync.Mutex
+
+	Command         string    `json:"command"         yaml:"command"`
+	DryRun          bool      `json:"dryRun"          yaml:"dryRun"`
+	StartedAt       time.Time `json:"startedAt"       yaml:"startedAt"`
+	FinishedAt      time.Time `json:"finishedAt"      yaml:"finishedAt"`
+	DurationSeconds float64   `json:"durationSeconds" yaml:"durationSeconds"`
+	Result          Result    `json:"result"          yaml:"result"`
+	Error           string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Summary         Summary   `json:"summary"         yaml:"summary"`
+	Nodes           []*Node   `json:"nodes"           yaml:"nodes"`
+}
+
+// New starts a new report for the given command
+func New(command string, dryRun bool) *Report {
+	return &Report{
+		Command:   command,
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+		Nodes:     []*Node{},
+	}
+}
+
+// AddNodes registers the nodes the command operates on, in pending state
+func (r *Report) AddNodes(nodes []*topf.Node) {
+	if r == nil {
+		return
+	}
+
+	for _, node := range nodes {
+		r.Update(node, func(*Node) {})
+	}
+}
+
+// Update calls fn with the report entry of the node, creating it if needed.
+// Updates are serialized, so it is safe to call from concurrent workers.
+func (r *Report) Update(node *topf.Node, fn func(n *Node)) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range r.Nodes {
+		if n.Host == node.Node.Host {
+			fn(n)
+			return
+		}
+	}
+
+	n := &Node{Host: node.Node.Host, Role: string(node.Node.Role), Status: StatusPending}
+	r.Nodes = append(r.Nodes, n)
+
+	fn(n)
+}
+
+// Finish records the end of the command with its returned error and
+// computes the summary.
+func (r *Report) Finish(err error) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FinishedAt = time.Now()
+	r.DurationSeconds = r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond).Seconds()
+
+	switch {
+	case err == nil:
+		r.Result = ResultSuccess
+	case errors.Is(err, topf.ErrDryRunChangesDetected):
+		r.Result = ResultChangesDetected
+	default:
+		r.Result = ResultFailure
+		r.Error = err.Error()
+	}
+
+	r.Summary = Summary{Total: len(r.Nodes)}
+
+	for _, n := range r.Nodes {
+		switch n.Status {
+		case StatusPending:
+			r.Summary.Pending++
+		case StatusUnchanged:
+			r.Summary.Unchanged++
+		case StatusChangesDetected:
+			r.Summary.ChangesDetected++
+		case StatusSucceeded:
+			r.Summary.Succeeded++
+		case StatusSkipped:
+			r.Summary.Skipped++
+		case StatusFailed:
+			r.Summary.Failed++
+		}
+	}
+}
+
+// Write encodes the report in the given format
+func (r *Report) Write(w io.Writer, format Format) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+
+		return enc.Encode(r)
+	case FormatYAML:
+		b, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+
+		return err
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/postfinance/topf/internal/topf"
+	"github.com/postfinance/topf/pkg/config"
+	"go.yaml.in/yaml/v4"
+)
+
+func newNode(host string, role config.NodeRole) *topf.Node {
+	return &topf.Node{Node: &config.Node{Host: host, Role: role}}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Format
+		wantErr bool
+	}{
+		{path: "report.json", want: FormatJSON},
+		{path: "out/report.yaml", want: FormatYAML},
+		{path: "report.YML", want: FormatYAML},
+		{path: "report.txt", wantErr: true},
+		{path: "report", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := FormatFromPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatFromPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("FormatFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilReport(_ *testing.T) {
+	var r *Report
+
+	// must not panic
+	r.AddNodes([]*topf.Node{newNode("node1", config.RoleWorker)})
+	r.Update(newNode("node1", config.RoleWorker), func(n *Node) { n.Status = StatusFailed })
+	r.Finish(nil)
+}
+
+func TestFinish(t *testing.T) {
+	cp := newNode("cp1", config.RoleControlPlane)
+	w1 := newNode("w1", config.RoleWorker)
+	w2 := newNode("w2", config.RoleWorker)
+	w3 := newNode("w3", config.RoleWorker)
+
+	r := New("apply", false)
+	r.AddNodes([]*topf.Node{cp, w1, w2, w3})
+
+	r.Update(cp, func(n *Node) {
+		n.Preflight = PreflightPassed
+		n.Status = StatusSucceeded
+		n.SetStabilization(1500 * time.Millisecond)
+	})
+	r.Update(w1, func(n *Node) { n.Status = StatusUnchanged })
+	r.Update(w2, func(n *Node) {
+		n.FailPreflight(errors.New("not ready"))
+		n.Fail(errors.New("not ready"))
+	})
+
+	r.Finish(errors.New("aborting"))
+
+	want := Summary{Total: 4, Pending: 1, Unchanged: 1, Succeeded: 1, Failed: 1}
+	if r.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", r.Summary, want)
+	}
+
+	if r.Result != ResultFailure || r.Error != "aborting" {
+		t.Errorf("Result = %q, Error = %q", r.Result, r.Error)
+	}
+
+	if len(r.Nodes) != 4 || r.Nodes[0].Host != "cp1" || r.Nodes[0].Role != string(config.RoleControlPlane) {
+		t.Errorf("unexpected nodes: %+v", r.Nodes)
+	}
+
+	if r.Nodes[0].StabilizationSeconds != 1.5 {
+		t.Errorf("StabilizationSeconds = %v, want 1.5", r.Nodes[0].StabilizationSeconds)
+	}
+}
+
+func TestFinishResult(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Result
+	}{
+		{err: nil, want: ResultSuccess},
+		{err: fmt.Errorf("wrapped: %w", topf.ErrDryRunChangesDetected), want: ResultChangesDetected},
+		{err: errors.New("boom"), want: ResultFailure},
+	}
+
+	for _, tt := range tests {
+		r := New("upgrade", true)
+		r.Finish(tt.err)
+
+		if r.Result != tt.want {
+			t.Errorf("Finish(%v) result = %q, want %q", tt.err, r.Result, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := New("apply", true)
+	r.Update(newNode("node1", config.RoleWorker), func(n *Node) {
+		n.Status = StatusChangesDetected
+		n.Changes = []Change{{Target: "v1alpha1", Status: "modified", Added: 1, Removed: 1}}
+		n.Mode = "NO_REBOOT"
+	})
+	r.Finish(topf.ErrDryRunChangesDetected)
+
+	var jsonBuf bytes.Buffer
+	if err := r.Write(&jsonBuf, FormatJSON); err != nil {
+		t.Fatalf("Write(json) error = %v", err)
+	}
+
+	var fromJSON map[string]any
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if fromJSON["result"] != string(ResultChangesDetected) {
+		t.Errorf("result = %v", fromJSON["result"])
+	}
+
+	var yamlBuf bytes.Buffer
+	if err := r.Write(&yamlBuf, FormatYAML); err != nil {
+		t.Fatalf("Write(yaml) error = %v", err)
+	}
+
+	var fromYAML map[string]any
+	if err := yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("invalid YAML: %v", err)
+	}
+
+	if !strings.Contains(yamlBuf.String(), "status: changes-detected") {
+		t.Errorf("expected node status in YAML output:\n%s", yamlBuf.String())
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pushTimeout limits how long pushing the metrics may take
+const pushTimeout = 10 * time.Second
+
+// metric is a gauge in the Prometheus text format
+type metric struct {
+	name   string
+	help   string
+	series []series
+}
+
+type series struct {
+	labels [][2]string
+	value  float64
+}
+
+// add adds a series with the given label name and value pairs
+func (m *metric) add(value float64, labels ...string) {
+	s := series{value: value}
+
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.labels = append(s.labels, [2]string{labels[i], labels[i+1]})
+	}
+
+	m.series = append(m.series, s)
+}
+
+// metrics returns the metrics of the command in the Prometheus text format.
+// All metrics are gauges describing the last run, as topf doesn't run long
+// enough to be scraped; must be called with mu held.
+func (r *Recorder) metrics(now time.Time, result string, last []NodePhase) []byte {
+	base := []string{"command", r.opts.Command, "cluster", r.opts.Cluster}
+	with := func(labels ...string) []string {
+		return append(slices.Clone(base), labels...)
+	}
+
+	duration := &metric{name: "topf_command_duration_seconds", help: "Duration of the last run of the command."}
+	duration.add(now.Sub(r.started).Seconds(), with("result", result)...)
+
+	lastRun := &metric{name: "topf_command_last_run_timestamp_seconds", help: "Unix time the last run of the command finished."}
+	lastRun.add(float64(now.Unix()), with("result", result)...)
+
+	counts := map[string]int{"done": 0, "unchanged": 0, "skipped": 0, "failed": 0, "pending": 0}
+
+	for _, n := range last {
+		switch {
+		case n.Final, n.Phase == "pending":
+			counts[n.Phase]++
+		default:
+			counts["interrupted"]++
+		}
+	}
+
+	nodeDurations := &metric{name: "topf_node_duration_seconds", help: "Time a node was processed during the last run, by its outcome."}
+	phaseDurations := &metric{name: "topf_node_phase_duration_seconds", help: "Time a node spent in a phase during the last run."}
+
+	for host, n := range r.nodes {
+		status := n.status
+		if status == "" {
+			status = "interrupted"
+		}
+
+		end := n.finished
+		if end.IsZero() {
+			end = now
+		}
+
+		nodeDurations.add(end.Sub(n.started).Seconds(), with("node", host, "role", n.role, "status", status)...)
+
+		for phase, d := range n.phases {
+			phaseDurations.add(d.Seconds(), with("node", host, "role", n.role, "phase", phase)...)
+		}
+	}
+
+	nodes := &metric{name: "topf_nodes", help: "Number of nodes of the last run, by their outcome, or pending if not started."}
+	for status, count := range counts {
+		nodes.add(float64(count), with("status", status)...)
+	}
+
+	retries := &metric{name: "topf_retries", help: "Number of retries of the retried operations during the last run."}
+	for operation, count := range r.retries {
+		retries.add(float64(count), with("operation", operation)...)
+	}
+
+	var buf bytes.Buffer
+
+	for _, m := range []*metric{duration, lastRun, nodes, nodeDurations, phaseDurations, retries} {
+		m.write(&buf)
+	}
+
+	return buf.Bytes()
+}
+
+// write writes the metric in the Prometheus text format, with its series
+// sorted by labels
+func (m *metric) write(buf *bytes.Buffer) {
+	if len(m.series) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+
+	lines := make([]string, 0, len(m.series))
+
+	for _, s := range m.series {
+		labels := make([]string, 0, len(s.labels))
+		for _, l := range s.labels {
+			labels = append(labels, l[0]+`="`+escapeLabel(l[1])+`"`)
+		}
+
+		lines = append(lines, m.name+"{"+strings.Join(labels, ",")+"} "+strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+
+	slices.Sort(lines)
+
+	for _, line := range lines {
+		buf.WriteString(line + "\n")
+	}
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeMetricsFile replaces path with the metrics. The file is written next
+// to it first, so that the textfile collector never reads a partial file.
+func writeMetricsFile(path string, metrics []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(metrics); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// readable by the node exporter, like a file created with the usual umask
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil { //nolint:gosec // metrics aren't sensitive
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// pushMetrics replaces the metrics of the group at url, as done by the
+// Prometheus Pushgateway for PUT requests
+func pushMetrics(url string, metrics []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("pushing metrics: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+// Package telemetry records where the time of rolling operations goes: it
+// exports OpenTelemetry traces with spans per command, node and phase, and
+// writes the durations, node outcomes and retries as Prometheus metrics.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Results of a command
+const (
+	ResultSuccess         = "success"
+	ResultChangesDetected = "changes-detected"
+	ResultFailure         = "failure"
+)
+
+// shutdownTimeout limits how long pending spans are exported at the end
+const shutdownTimeout = 10 * time.Second
+
+// Options configures a Recorder
+type Options struct {
+	Command string
+	Cluster string
+	Version string
+	// OTLPEndpoint is the URL of the OTLP/HTTP collector traces are exported
+	// to, e.g. http://localhost:4318. Tracing is disabled if empty.
+	OTLPEndpoint string
+	// MetricsFile is the file metrics are written to, for the textfile
+	// collector of the node exporter
+	MetricsFile string
+	// MetricsPushURL is the URL metrics are pushed to, e.g. the grouping
+	// URL of a Prometheus Pushgateway
+	MetricsPushURL string
+	// IsDryRunErr reports whether the error of the command only signals
+	// changes detected in dry-run mode
+	IsDryRunErr func(error) bool
+	Logger      *slog.Logger
+}
+
+// NodePhase is a phase a node of a rolling operation moved to
+type NodePhase struct {
+	Host  string
+	Role  string
+	Phase string
+	// Final is set for the phase a node ends in, e.g. done or failed
+	Final bool
+	// Error is the error of a failed node
+	Error string
+}
+
+// Recorder records the telemetry of a single command. A nil *Recorder
+// records nothing, so callers can record unconditionally.
+type Recorder struct {
+	opts     Options
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+
+	started time.Time
+	ctx     context.Context //nolint:containedctx // the parent of the node spans
+	span    trace.Span
+
+	mu      sync.Mutex
+	nodes   map[string]*node
+	retries map[string]int
+}
+
+// node is the telemetry of a node
+type node struct {
+	role     string
+	status   string
+	started  time.Time
+	finished time.Time
+	span     trace.Span
+	ctx      context.Context //nolint:containedctx // the parent of the phase spans
+
+	phase      string
+	phaseStart time.Time
+	phaseSpan  trace.Span
+	phases     map[string]time.Duration
+}
+
+// New returns a recorder for opts, or nil if neither tracing nor metrics
+// are enabled
+func New(ctx context.Context, opts Options) (*Recorder, error) {
+	if opts.OTLPEndpoint == "" && opts.MetricsFile == "" && opts.MetricsPushURL == "" {
+		return nil, nil //nolint:nilnil // a nil recorder disables telemetry
+	}
+
+	r := &Recorder{
+		opts:    opts,
+		tracer:  noop.NewTracerProvider().Tracer(""),
+		nodes:   make(map[string]*node),
+		retries: make(map[string]int),
+	}
+
+	if opts.OTLPEndpoint != "" {
+		// further settings, such as headers or certificates, are taken from
+		// the standard OTEL_EXPORTER_OTLP_* environment variables
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.OTLPEndpoint))
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP trace exporter: %w", err)
+		}
+
+		r.provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(resource.NewSchemaless(
+				semconv.ServiceName("topf"),
+				semconv.ServiceVersion(opts.Version),
+			)),
+		)
+		r.tracer = r.provider.Tracer("github.com/postfinance/topf")
+	}
+
+	return r, nil
+}
+
+// Start starts the span of the command and returns a context carrying it
+// and the recorder
+func (r *Recorder) Start(ctx context.Context) context.Context {
+	if r == nil {
+		return ctx
+	}
+
+	r.started = time.Now()
+	ctx, r.span = r.tracer.Start(ctx, "topf "+r.opts.Command, trace.WithAttributes(
+		attribute.String("topf.command", r.opts.Command),
+		attribute.String("topf.cluster", r.opts.Cluster),
+	))
+	r.ctx = ctx
+
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Phase records a node moving to another phase. Every phase becomes a span
+// of the node, the span of the node ends with its final phase.
+func (r *Recorder) Phase(update NodePhase) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.nodes[update.Host]
+	if !ok {
+		n = &node{role: update.Role, phases: make(map[string]time.Duration)}
+		r.nodes[update.Host] = n
+	}
+
+	if !n.finished.IsZero() {
+		return
+	}
+
+	if n.span == nil {
+		n.started = now
+		n.ctx, n.span = r.tracer.Start(r.ctx, "node "+update.Host, trace.WithTimestamp(now), trace.WithAttributes(
+			attribute.String("topf.node.host", update.Host),
+			attribute.String("topf.node.role", update.Role),
+		))
+	}
+
+	n.endPhase(now)
+
+	if !update.Final {
+		n.phase, n.phaseStart = update.Phase, now
+		_, n.phaseSpan = r.tracer.Start(n.ctx, update.Phase, trace.WithTimestamp(now))
+
+		return
+	}
+
+	n.status, n.finished = update.Phase, now
+	n.span.SetAttributes(attribute.String("topf.node.status", update.Phase))
+
+	if update.Error != "" {
+		n.span.SetStatus(codes.Error, update.Error)
+	}
+
+	n.span.End(trace.WithTimestamp(now))
+}
+
+// endPhase ends the current phase of the node, if any
+func (n *node) endPhase(now time.Time) {
+	if n.phaseSpan == nil {
+		return
+	}
+
+	n.phases[n.phase] += now.Sub(n.phaseStart)
+	n.phaseSpan.End(trace.WithTimestamp(now))
+	n.phaseSpan = nil
+}
+
+// Retry records a retry of a retried operation
+func (r *Recorder) Retry(operation string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retries[operation]++
+}
+
+// Finish ends the spans with the outcome of the command, cmdErr, exports
+// them and writes the metrics, counting the outcomes of nodes, the last
+// phases of all nodes of the command. Failures are logged, as they must not
+// fail the command.
+func (r *Recorder) Finish(cmdErr error, nodes []NodePhase) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	result := r.result(cmdErr)
+
+	r.mu.Lock()
+
+	// nodes interrupted by the end of the command
+	for _, n := range r.nodes {
+		if n.span != nil && n.finished.IsZero() {
+			n.endPhase(now)
+			n.span.End(trace.WithTimestamp(now))
+		}
+	}
+
+	metrics := r.metrics(now, result, nodes)
+
+	r.mu.Unlock()
+
+	r.span.SetAttributes(attribute.String("topf.result", result))
+
+	if result == ResultFailure {
+		r.span.SetStatus(codes.Error, cmdErr.Error())
+	}
+
+	r.span.End(trace.WithTimestamp(now))
+
+	if r.provider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := r.provider.Shutdown(ctx); err != nil {
+			r.opts.Logger.Warn("failed to export traces", "error", err)
+		}
+	}
+
+	if r.opts.MetricsFile != "" {
+		if err := writeMetricsFile(r.opts.MetricsFile, metrics); err != nil {
+			r.opts.Logger.Warn("failed to write metrics", "error", err)
+		}
+	}
+
+	if r.opts.MetricsPushURL != "" {
+		if err := pushMetrics(r.opts.MetricsPushURL, metrics); err != nil {
+			r.opts.Logger.Warn("failed to push metrics", "error", err)
+		}
+	}
+}
+
+func (r *Recorder) result(err error) string {
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case r.opts.IsDryRunErr != nil && r.opts.IsDryRunErr(err):
+		return ResultChangesDetected
+	default:
+		return ResultFailure
+	}
+}
+
+type recorderKey struct{}
+
+// FromContext returns the recorder started with the context, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Retried wraps the function of a retry loop, such as retry.Constant, so
+// that every attempt but the first is recorded as a retry of operation by
+// the recorder of the context
+func Retried(operation string, fn func(context.Context) error) func(context.Context) error {
+	attempted := false
+
+	return func(ctx context.Context) error {
+		if attempted {
+			FromContext(ctx).Retry(operation)
+		}
+
+		attempted = true
+
+		return fn(ctx)
+	}
+}
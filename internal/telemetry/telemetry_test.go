@@ -0,0 +1,176 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNilRecorder(t *testing.T) {
+	r, err := New(context.Background(), Options{Command: "apply"})
+	if err != nil || r != nil {
+		t.Fatalf("New() without outputs = %v, %v", r, err)
+	}
+
+	// must not panic
+	ctx := r.Start(context.Background())
+	r.Phase(NodePhase{Host: "node1", Phase: "applying"})
+	r.Finish(nil, nil)
+
+	if err := Retried("stabilize", func(context.Context) error { return nil })(ctx); err != nil {
+		t.Errorf("Retried() = %v", err)
+	}
+}
+
+// run records an upgrade of two nodes, one failing after a retry
+func run(t *testing.T, r *Recorder) {
+	t.Helper()
+
+	ctx := r.Start(context.Background())
+
+	attempts := 0
+	retried := Retried("stabilize", func(context.Context) error {
+		attempts++
+		return nil
+	})
+
+	for range 3 {
+		_ = retried(ctx)
+	}
+
+	if attempts != 3 {
+		t.Fatalf("retried function ran %d times, want 3", attempts)
+	}
+
+	r.Phase(NodePhase{Host: "node1", Role: "worker", Phase: "pulling"})
+	r.Phase(NodePhase{Host: "node1", Role: "worker", Phase: "installing"})
+	r.Phase(NodePhase{Host: "node1", Role: "worker", Phase: "done", Final: true})
+	r.Phase(NodePhase{Host: "node2", Role: "worker", Phase: "draining"})
+	r.Phase(NodePhase{Host: "node2", Role: "worker", Phase: "failed", Final: true, Error: "drain timed out"})
+
+	r.Finish(errors.New("node2: drain timed out"), []NodePhase{
+		{Host: "node1", Phase: "done", Final: true},
+		{Host: "node2", Phase: "failed", Final: true},
+		{Host: "node3", Phase: "pending"},
+	})
+}
+
+func TestMetricsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topf.prom")
+
+	r, err := New(context.Background(), Options{Command: "upgrade", Cluster: "test", MetricsFile: path, Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, r)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := string(content)
+
+	for _, want := range []string{
+		"# TYPE topf_command_duration_seconds gauge\n",
+		`topf_command_duration_seconds{command="upgrade",cluster="test",result="failure"} `,
+		`topf_nodes{command="upgrade",cluster="test",status="done"} 1` + "\n",
+		`topf_nodes{command="upgrade",cluster="test",status="failed"} 1` + "\n",
+		`topf_nodes{command="upgrade",cluster="test",status="pending"} 1` + "\n",
+		`topf_node_duration_seconds{command="upgrade",cluster="test",node="node2",role="worker",status="failed"} `,
+		`topf_node_phase_duration_seconds{command="upgrade",cluster="test",node="node1",role="worker",phase="installing"} `,
+		`topf_retries{command="upgrade",cluster="test",operation="stabilize"} 2` + "\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics lack %q:\n%s", want, metrics)
+		}
+	}
+
+	// final phases are not phases the node spent time in
+	if strings.Contains(metrics, `phase="done"`) {
+		t.Errorf("metrics contain the final phase:\n%s", metrics)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var method, body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(content)
+	}))
+	defer server.Close()
+
+	r, err := New(context.Background(), Options{Command: "reset", Cluster: "test", MetricsPushURL: server.URL + "/metrics/job/topf", Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Start(context.Background())
+	r.Finish(nil, nil)
+
+	if method != http.MethodPut || !strings.Contains(body, `topf_command_duration_seconds{command="reset",cluster="test",result="success"}`) {
+		t.Errorf("pushed %s:\n%s", method, body)
+	}
+}
+
+func TestTraces(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+
+	r, err := New(context.Background(), Options{Command: "upgrade", Cluster: "test", MetricsFile: filepath.Join(t.TempDir(), "topf.prom"), Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	r.tracer = r.provider.Tracer("test")
+
+	run(t, r)
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans.Ended() {
+		byName[span.Name()] = span
+	}
+
+	command, node1, node2 := byName["topf upgrade"], byName["node node1"], byName["node node2"]
+	if command == nil || node1 == nil || node2 == nil {
+		t.Fatalf("missing spans, got %v", byName)
+	}
+
+	if command.Status().Code != codes.Error || node2.Status().Code != codes.Error || node2.Status().Description != "drain timed out" {
+		t.Errorf("unexpected status: command %v, node2 %v", command.Status(), node2.Status())
+	}
+
+	if node1.Parent().SpanID() != command.SpanContext().SpanID() {
+		t.Error("node span is not a child of the command span")
+	}
+
+	for _, phase := range []string{"pulling", "installing", "draining"} {
+		if byName[phase] == nil {
+			t.Errorf("missing span of phase %s", phase)
+		}
+	}
+
+	if installing := byName["installing"]; installing != nil && installing.Parent().SpanID() != node1.SpanContext().SpanID() {
+		t.Error("phase span is not a child of the node span")
+	}
+
+	if byName["done"] != nil {
+		t.Error("final phase has a span")
+	}
+}
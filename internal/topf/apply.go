@@ -5,13 +5,18 @@ package topf
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
 
+	"github.com/postfinance/topf/internal/configdiff"
 	"github.com/postfinance/topf/internal/interactive"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/client"
 	"github.com/siderolabs/talos/pkg/machinery/config/encoder"
 )
 
@@ -19,79 +24,258 @@ import (
 // and changes were detected. Callers can use this to exit with a non-zero status.
 var ErrDryRunChangesDetected = errors.New("dry-run: changes detected")
 
+// ApplyResult describes the outcome of applying the configuration to a node
+type ApplyResult struct {
+	// Changed is true when Talos reports changes to apply. The Diff is only
+	// computed locally to show them, and may miss changes Talos detects.
+	Changed bool
+	// Applied is true when the configuration was actually applied
+	Applied bool
+	// Mode is the apply mode reported by Talos. Unset when there were no changes.
+	Mode machine.ApplyConfigurationRequest_Mode
+	// Diff contains the changes between the active and the rendered configuration
+	Diff *configdiff.Diff
+}
+
 // Apply applies the configuration bundle to the node.
 // If dryRun is true, only shows what changes would be applied without actually applying them.
-func (n *Node) Apply(ctx context.Context, logger *slog.Logger, dryRun bool, mode machine.ApplyConfigurationRequest_Mode) (bool, error) {
+// Whether there are changes is decided by a dry-run apply on the node; they are shown as a
+// per-document diff against the node's active config in the given format.
+// The result is also returned alongside ErrDryRunChangesDetected.
+// If set, beforeReboot is called before a configuration that reboots the node
+// is applied, and beforeApply right before any changes are applied, once
+// confirmed. Their errors abort the apply.
+func (n *Node) Apply(ctx context.Context, logger *slog.Logger, dryRun bool, mode machine.ApplyConfigurationRequest_Mode, diffFormat configdiff.Format,
+	beforeApply, beforeReboot func(context.Context) error,
+) (*ApplyResult, error) {
 	logger = logger.With(n.Attrs())
 
 	if n.ConfigBundle == nil {
-		return false, errors.New("cannot apply config: config bundle is empty")
+		return nil, errors.New("cannot apply config: config bundle is empty")
+	}
+
+	// diff locally against the active config; nodes in maintenance mode have
+	// none, so all documents show up as added
+	diff, err := configdiff.Compute(n.activeConfig, n.ConfigProvider())
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff machine config: %w", err)
 	}
 
+	result := &ApplyResult{Diff: diff}
+
 	nodeClient, err := n.Client(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer nodeClient.Close()
 
-	configBytes, err := n.ConfigProvider().EncodeBytes(encoder.WithComments(encoder.CommentsDisabled), encoder.WithOmitEmpty(true))
+	configBytes, err := n.encodeConfig()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	logger.Info("dry-run apply")
 
-	// first pass is a dry-run apply
-	response, err := nodeClient.MachineClient.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
-		Data:   configBytes,
-		DryRun: true,
-		Mode:   mode,
-	})
+	// first pass is a dry-run apply, which also tells whether anything changed
+	applyResponse, err := dryRunApply(ctx, nodeClient, configBytes, mode)
 	if err != nil {
-		return false, fmt.Errorf("failed to apply machine config: %w", err)
+		return nil, err
 	}
 
-	applyResponse := response.GetMessages()[0]
+	if noChanges(applyResponse) {
+		if diff.Changed() {
+			logger.Debug("local diff shows changes, but talos reports none")
+		}
 
-	// no better way from API than matching on this until
-	if strings.HasSuffix(applyResponse.GetModeDetails(), "\nNo changes.") {
 		logger.Info("no changes to apply")
-		return false, nil
+
+		return result, nil
 	}
 
+	result.Changed = true
+	result.Mode = applyResponse.GetMode()
+
 	if len(applyResponse.GetWarnings()) > 0 {
 		logger.Warn("dry-run", "warnings", strings.Join(applyResponse.GetWarnings(), ", "))
 	}
 
 	// in dry-run mode, print the changes and signal that changes were detected
 	if dryRun {
-		fmt.Fprintln(n.t.Writer(), "     "+strings.ReplaceAll(applyResponse.GetModeDetails(), "\n", "\n     "))
+		if err := n.printChanges(applyResponse, diff, diffFormat); err != nil {
+			return nil, err
+		}
 
-		return false, ErrDryRunChangesDetected
+		return result, ErrDryRunChangesDetected
+	}
+
+	if beforeReboot != nil && result.Mode == machine.ApplyConfigurationRequest_REBOOT {
+		if err := beforeReboot(ctx); err != nil {
+			return nil, err
+		}
 	}
 
 	// ask for user confirmation
 	if n.t.Confirm() {
-		fmt.Fprintln(n.t.Writer(), "     "+strings.ReplaceAll(applyResponse.GetModeDetails(), "\n", "\n     "))
+		if err := n.printChanges(applyResponse, diff, diffFormat); err != nil {
+			return nil, err
+		}
 
 		if interactive.ConfirmPrompt(fmt.Sprintf("Do you want to apply the above changes to %s (Mode: %s)?", n.Node.Host, applyResponse.GetMode().String())) == 'n' {
 			logger.Info("skipping")
-			return false, nil
+			return result, nil
+		}
+	}
+
+	if beforeApply != nil {
+		if err := beforeApply(ctx); err != nil {
+			return nil, err
 		}
 	}
 
 	// actually apply config
-	response, err = nodeClient.MachineClient.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
+	response, err := nodeClient.MachineClient.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
 		Data: configBytes,
 		Mode: mode,
 	})
 	if err != nil {
-		return false, fmt.Errorf("failed to apply machine config: %w", err)
+		return nil, fmt.Errorf("failed to apply machine config: %w", err)
 	}
 
 	applyResponse = response.GetMessages()[0]
+	result.Applied = true
+	result.Mode = applyResponse.GetMode()
 
 	logger.Info("applied machine config", "mode", applyResponse.GetMode())
 
-	return true, nil
+	return result, nil
+}
+
+// CheckDrift tells whether applying the rendered configuration would change
+// the node, without printing or applying anything. As in Apply, this is decided
+// by a dry-run apply in auto mode, which also tells how Talos would apply the
+// changes (e.g. whether it requires a reboot). The Diff is computed locally
+// against the node's active configuration to show them.
+func (n *Node) CheckDrift(ctx context.Context) (*ApplyResult, error) {
+	if n.ConfigBundle == nil {
+		return nil, errors.New("cannot check config drift: config bundle is empty")
+	}
+
+	diff, err := configdiff.Compute(n.activeConfig, n.ConfigProvider())
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff machine config: %w", err)
+	}
+
+	result := &ApplyResult{Diff: diff}
+
+	nodeClient, err := n.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer nodeClient.Close()
+
+	configBytes, err := n.encodeConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	applyResponse, err := dryRunApply(ctx, nodeClient, configBytes, machine.ApplyConfigurationRequest_AUTO)
+	if err != nil {
+		return nil, err
+	}
+
+	if noChanges(applyResponse) {
+		return result, nil
+	}
+
+	result.Changed = true
+	result.Mode = applyResponse.GetMode()
+
+	return result, nil
+}
+
+// noChanges reports whether the response of a dry-run apply reports no
+// changes. The API offers no better way than matching its details, but only
+// Talos knows what it would change: the local diff works on the active config
+// read before and can't tell which differences Talos ignores.
+func noChanges(resp *machine.ApplyConfiguration) bool {
+	return strings.HasSuffix(resp.GetModeDetails(), "\nNo changes.")
+}
+
+func (n *Node) encodeConfig() ([]byte, error) {
+	return n.ConfigProvider().EncodeBytes(encoder.WithComments(encoder.CommentsDisabled), encoder.WithOmitEmpty(true))
+}
+
+func dryRunApply(ctx context.Context, nodeClient *client.Client, configBytes []byte, mode machine.ApplyConfigurationRequest_Mode) (*machine.ApplyConfiguration, error) {
+	response, err := nodeClient.MachineClient.ApplyConfiguration(ctx, &machine.ApplyConfigurationRequest{
+		Data:   configBytes,
+		DryRun: true,
+		Mode:   mode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply machine config: %w", err)
+	}
+
+	return response.GetMessages()[0], nil
+}
+
+// nodeDiff is the JSON representation of the changes to a single node
+type nodeDiff struct {
+	Node string `json:"node"`
+	Mode string `json:"mode"`
+	// Details are the changes reported by Talos, if the local diff misses them
+	Details string `json:"details,omitempty"`
+	*configdiff.Diff
+}
+
+// printChanges writes the changes that would be applied to the node in the given format.
+// If the local diff misses the changes Talos reported, e.g. because the cached active
+// config is stale, the details reported by Talos are shown instead.
+func (n *Node) printChanges(resp *machine.ApplyConfiguration, diff *configdiff.Diff, format configdiff.Format) error {
+	w := n.t.Writer()
+
+	var details string
+	if !diff.Changed() {
+		details = resp.GetModeDetails()
+	}
+
+	if format == configdiff.FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+
+		return enc.Encode(nodeDiff{Node: n.Node.Host, Mode: resp.GetMode().String(), Details: details, Diff: diff})
+	}
+
+	fmt.Fprintf(w, "%s (mode: %s)\n", n.Node.Host, resp.GetMode().String())
+
+	if details != "" {
+		_, err := fmt.Fprintln(w, "     "+strings.ReplaceAll(details, "\n", "\n     "))
+		return err
+	}
+
+	return diff.WriteUnified(w, colorOutput(w))
+}
+
+// colorOutput reports whether w ends up on a terminal and colors are not
+// disabled via NO_COLOR (https://no-color.org). Wrapping writers are looked
+// through with their Unwrap method.
+func colorOutput(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	for {
+		switch inner := w.(type) {
+		case *os.File:
+			fi, err := inner.Stat()
+			if err != nil {
+				return false
+			}
+
+			return fi.Mode()&os.ModeCharDevice != 0
+		case interface{ Unwrap() io.Writer }:
+			w = inner.Unwrap()
+		default:
+			return false
+		}
+	}
 }
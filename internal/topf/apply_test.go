@@ -0,0 +1,79 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package topf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/postfinance/topf/internal/logging"
+	"github.com/postfinance/topf/internal/maskedwriter"
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+)
+
+func TestNoChanges(t *testing.T) {
+	tests := []struct {
+		details string
+		want    bool
+	}{
+		{details: "Dry run summary:\nApplied configuration without a reboot (skipped in dry-run).\nConfig diff:\n\nNo changes.", want: true},
+		{details: "Dry run summary:\nApplied configuration without a reboot (skipped in dry-run).\nConfig diff:\n\n--- a\n+++ b\n"},
+		{details: ""},
+	}
+
+	for _, tt := range tests {
+		if got := noChanges(&machine.ApplyConfiguration{ModeDetails: tt.details}); got != tt.want {
+			t.Errorf("noChanges(%q) = %v, want %v", tt.details, got, tt.want)
+		}
+	}
+}
+
+func TestColorOutput(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	// /dev/null is a character device, like a terminal
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "diff.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	redirected := logging.NewSwitchWriter(devNull)
+	redirected.Swap(&bytes.Buffer{})
+
+	tests := []struct {
+		name string
+		w    io.Writer
+		want bool
+	}{
+		{name: "terminal", w: devNull, want: true},
+		{name: "masked terminal", w: maskedwriter.New(logging.NewSwitchWriter(devNull), nil), want: true},
+		{name: "file", w: maskedwriter.New(logging.NewSwitchWriter(file), nil)},
+		{name: "redirected", w: maskedwriter.New(redirected, nil)},
+		{name: "buffer", w: &bytes.Buffer{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorOutput(tt.w); got != tt.want {
+				t.Errorf("colorOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Setenv("NO_COLOR", "1")
+
+	if colorOutput(devNull) {
+		t.Error("colorOutput() = true despite NO_COLOR")
+	}
+}
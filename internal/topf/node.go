@@ -5,10 +5,12 @@ package topf
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 
+	"github.com/postfinance/topf/internal/configdiff"
 	"github.com/postfinance/topf/pkg/config"
 	talosconfig "github.com/siderolabs/talos/pkg/machinery/config"
 	"github.com/siderolabs/talos/pkg/machinery/config/bundle"
@@ -37,6 +39,7 @@ type Node struct {
 	runningVersion    string
 	runningSchematic  string
 	resolvedSchematic string
+	activeConfig      talosconfig.Provider
 	ConfigBundle      *bundle.Bundle `yaml:"-"`
 	Error             error          `yaml:",omitempty"`
 }
@@ -65,6 +68,18 @@ func (n *Node) ResolvedSchematic() string {
 	return n.resolvedSchematic
 }
 
+// DesiredVersion returns the Talos version of the installer image, without
+// the "v" prefix, to compare with RunningVersion.
+func (n *Node) DesiredVersion() string {
+	return strings.TrimPrefix(cmp.Or(n.Node.TalosVersion, n.t.Config().TalosVersion, version.Tag), "v")
+}
+
+// DesiredSchematic returns the schematic ID of the installer image, to
+// compare with RunningSchematic.
+func (n *Node) DesiredSchematic() string {
+	return cmp.Or(n.resolvedSchematic, DefaultSchematic)
+}
+
 // InstallerImage returns the fully resolved installer image for this node.
 // The schematic ID is the resolved value (after @-prefixed references have been expanded).
 // Factory, platform, talosVersion, and secureboot resolve per node -> cluster config -> default.
@@ -72,34 +87,56 @@ func (n *Node) InstallerImage() string {
 	cfg := n.t.Config()
 	factory := cmp.Or(n.Node.Factory, cfg.Factory, DefaultFactory)
 	platform := cmp.Or(n.Node.Platform, cfg.Platform, DefaultPlatform)
-	schematic := cmp.Or(n.resolvedSchematic, DefaultSchematic)
-	talosVersion := strings.TrimPrefix(cmp.Or(n.Node.TalosVersion, cfg.TalosVersion, version.Tag), "v")
 
 	installer := platform + "-installer"
 	if n.Node.SecureBoot || cfg.SecureBoot {
 		installer += "-secureboot"
 	}
 
-	return fmt.Sprintf("%s/%s/%s:v%s", factory, installer, schematic, talosVersion)
+	return fmt.Sprintf("%s/%s/%s:v%s", factory, installer, n.DesiredSchematic(), n.DesiredVersion())
+}
+
+// LocalConfigDiff reports whether the rendered machine config differs from the
+// active one of the node. The configs are only compared locally, which may
+// report changes Talos ignores; use CheckDrift to decide about drift like
+// Apply does.
+func (n *Node) LocalConfigDiff() (bool, error) {
+	if n.ConfigBundle == nil {
+		return false, errors.New("cannot compare config: config bundle is empty")
+	}
+
+	diff, err := configdiff.Compute(n.activeConfig, n.ConfigProvider())
+	if err != nil {
+		return false, fmt.Errorf("failed to diff machine config: %w", err)
+	}
+
+	return diff.Changed(), nil
 }
 
 // MarshalYAML implements custom YAML marshalling to properly serialize the Error field
 func (n *Node) MarshalYAML() (any, error) {
 	aux := &struct {
-		Node          *config.Node              `yaml:"node"`
-		MachineStatus runtime.MachineStatusSpec `yaml:"machinestatus"`
-		Schematic     string                    `yaml:"schematic"`
-		TalosVersion  string                    `yaml:"talosversion"`
-		Error         string                    `yaml:"error,omitempty"`
+		Node                *config.Node              `yaml:"node"`
+		MachineStatus       runtime.MachineStatusSpec `yaml:"machinestatus"`
+		Schematic           string                    `yaml:"schematic"`
+		TalosVersion        string                    `yaml:"talosversion"`
+		DesiredSchematic    string                    `yaml:"desiredschematic"`
+		DesiredTalosVersion string                    `yaml:"desiredtalosversion"`
+		LocalConfigDiff     *bool                     `yaml:"localconfigdiff,omitempty"`
+		Error               string                    `yaml:"error,omitempty"`
 	}{
-		Node:          n.Node,
-		MachineStatus: n.MachineStatus,
-		Schematic:     n.runningSchematic,
-		TalosVersion:  n.runningVersion,
+		Node:                n.Node,
+		MachineStatus:       n.MachineStatus,
+		Schematic:           n.runningSchematic,
+		TalosVersion:        n.runningVersion,
+		DesiredSchematic:    n.DesiredSchematic(),
+		DesiredTalosVersion: n.DesiredVersion(),
 	}
 
 	if n.Error != nil {
 		aux.Error = n.Error.Error()
+	} else if changed, err := n.LocalConfigDiff(); err == nil {
+		aux.LocalConfigDiff = &changed
 	}
 
 	return aux, nil
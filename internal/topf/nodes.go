@@ -14,6 +14,7 @@ import (
 	"github.com/cosi-project/runtime/pkg/resource"
 	"github.com/cosi-project/runtime/pkg/safe"
 	"github.com/postfinance/topf/pkg/config"
+	"github.com/siderolabs/talos/pkg/machinery/client"
 	talosconfig "github.com/siderolabs/talos/pkg/machinery/config"
 	"github.com/siderolabs/talos/pkg/machinery/config/bundle"
 	"github.com/siderolabs/talos/pkg/machinery/config/configpatcher"
@@ -45,61 +46,106 @@ func (n *Node) collectNodeInfo(ctx context.Context) error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
+	state, err := readLiveState(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	n.setLiveState(state)
+
+	return nil
+}
+
+// liveState is the state of a node as reported by Talos
+type liveState struct {
+	machineStatus    runtime.MachineStatusSpec
+	activeConfig     talosconfig.Provider
+	runningSchematic string
+	runningVersion   string
+}
+
+// setLiveState records the state on the node. The sensitive values of the
+// active config are added to the redaction pool: during a key rotation the
+// old certs still live on the node; without this they would leak through
+// the masked writer.
+func (n *Node) setLiveState(state liveState) {
+	n.MachineStatus = state.machineStatus
+	n.activeConfig = state.activeConfig
+	n.runningSchematic = state.runningSchematic
+	n.runningVersion = state.runningVersion
+
+	if state.activeConfig != nil {
+		n.t.AddSecretsToMask(collectCurrentConfigSecrets(state.activeConfig))
+	}
+}
+
+func readLiveState(ctx context.Context, nodeClient *client.Client) (liveState, error) {
+	var state liveState
+
 	machineStatus, err := safe.StateGetResource(ctx, nodeClient.COSI, runtime.NewMachineStatus())
 	if err != nil {
-		return fmt.Errorf("unable to get machine status: %w", err)
+		return state, fmt.Errorf("unable to get machine status: %w", err)
 	}
 
-	n.MachineStatus = *machineStatus.TypedSpec()
+	state.machineStatus = *machineStatus.TypedSpec()
 
-	// Fetch the current machine config from the node and add its
-	// sensitive values to the redaction pool. During a key rotation
-	// the old certs still live on the node; without this they would
-	// leak through the masked writer. Only available outside maintenance mode.
-	if n.MachineStatus.Stage != runtime.MachineStageMaintenance {
-		machineConfig, err := safe.StateGet[*configresource.MachineConfig](
-			ctx, nodeClient.COSI,
-			resource.NewMetadata(configresource.NamespaceName, configresource.MachineConfigType, configresource.ActiveID, resource.VersionUndefined),
-		)
+	// Fetch the current machine config from the node, to diff it against
+	// the rendered config. Only available outside maintenance mode.
+	if state.machineStatus.Stage != runtime.MachineStageMaintenance {
+		machineConfig, err := ActiveConfig(ctx, nodeClient)
 		if err != nil {
-			return fmt.Errorf("couldn't get machine config: %w", err)
-		}
-
-		if machineConfig == nil {
-			return errors.New("retrieved a 'nil' machine config")
+			return state, err
 		}
 
-		n.t.AddSecretsToMask(collectCurrentConfigSecrets(machineConfig.Provider()))
+		state.activeConfig = machineConfig
 	}
 
 	extensions, err := safe.StateListAll[*runtime.ExtensionStatus](ctx, nodeClient.COSI)
 	if err != nil {
-		return fmt.Errorf("couldn't list extensions: %w", err)
+		return state, fmt.Errorf("couldn't list extensions: %w", err)
 	}
 
 	// it's possible that the schematic extension is not present
 	// in which case we have to assume the default one
-	n.runningSchematic = DefaultSchematic
+	state.runningSchematic = DefaultSchematic
 
 	for extension := range extensions.All() {
 		if extension.TypedSpec().Metadata.Name == "schematic" {
-			n.runningSchematic = extension.TypedSpec().Metadata.Version
+			state.runningSchematic = extension.TypedSpec().Metadata.Version
 		}
 	}
 
 	// Collect Talos version via COSI client because it is also available on maintenance mode
 	versions, err := safe.StateListAll[*runtime.Version](ctx, nodeClient.COSI)
 	if err != nil {
-		return fmt.Errorf("couldn't list versions: %w", err)
+		return state, fmt.Errorf("couldn't list versions: %w", err)
 	}
 
 	for v := range versions.All() {
 		if v.Metadata().Type() == runtime.VersionType {
-			n.runningVersion = strings.TrimPrefix(v.TypedSpec().Version, "v")
+			state.runningVersion = strings.TrimPrefix(v.TypedSpec().Version, "v")
 		}
 	}
 
-	return nil
+	return state, nil
+}
+
+// ActiveConfig fetches the machine config currently active on the node the
+// client is connected to. Not available in maintenance mode.
+func ActiveConfig(ctx context.Context, nodeClient *client.Client) (talosconfig.Provider, error) {
+	machineConfig, err := safe.StateGet[*configresource.MachineConfig](
+		ctx, nodeClient.COSI,
+		resource.NewMetadata(configresource.NamespaceName, configresource.MachineConfigType, configresource.ActiveID, resource.VersionUndefined),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get machine config: %w", err)
+	}
+
+	if machineConfig == nil {
+		return nil, errors.New("retrieved a 'nil' machine config")
+	}
+
+	return machineConfig.Provider(), nil
 }
 
 func (t *topf) generateNodeConfig(ctx context.Context, node *Node) error {
@@ -13,10 +13,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 	"sync"
 
 	"github.com/postfinance/topf/internal/decryption"
+	"github.com/postfinance/topf/internal/logging"
 	"github.com/postfinance/topf/internal/maskedwriter"
 	"github.com/postfinance/topf/internal/schematic"
 	"github.com/postfinance/topf/pkg/config"
@@ -50,21 +50,42 @@ type Topf interface {
 	// available on control-plane nodes (e.g. Kubeconfig).
 	ControlPlaneClient(context.Context) (*client.Client, error)
 
+	// ControlPlaneNodes returns the control-plane nodes from the unfiltered
+	// node list, for cluster-wide checks. Live node info is not gathered.
+	ControlPlaneNodes() []*Node
+
 	// Writer returns a writer targeting os.Stdout. When the runtime was
 	// created with Redact=true, secrets and certificates are replaced with
 	// "*** redacted ***" before being written.
 	Writer() io.Writer
 
-	// AddSecretsToMask registers additional sensitive strings for redaction.
-	// Has no effect when redaction is disabled.
+	// MaskWriter wraps w so that secrets are redacted like in Writer. The
+	// returned writer must be closed to flush buffered bytes. When redaction
+	// is disabled, w is returned as is.
+	MaskWriter(w io.Writer) io.WriteCloser
+
+	// AddSecretsToMask registers additional sensitive strings for redaction
+	// in Writer, MaskWriter and log output. Has no effect when redaction is
+	// disabled.
 	AddSecretsToMask(sensitive []string)
 
+	// RedirectOutput sends the output of Writer and the log records meant for
+	// stderr to w, and log records to observer as well, until the returned
+	// function is called. The log file is not affected.
+	RedirectOutput(w io.Writer, observer slog.Handler) (restore func())
+
 	// Confirm returns whether confirmation prompts are enabled
 	Confirm() bool
 
 	// TopfVersion returns the topf version string
 	TopfVersion() string
 
+	// ConfigDir returns the directory containing topf.yaml
+	ConfigDir() string
+
+	// Close flushes and closes the log sinks of the runtime
+	Close() error
+
 	// ResolveSchematic resolves a schematic ID string. If the ID starts with @,
 	// it is treated as a path to a schematic file (relative to the directory
 	// containing topf.yaml) and the resolved hash is returned. Non-@-prefixed
@@ -84,6 +105,16 @@ type RuntimeConfig struct {
 	// LogLevel sets the logging verbosity (debug, info, warn, error)
 	LogLevel string
 
+	// LogFormat sets the encoding of log records (text, json)
+	LogFormat string
+
+	// LogFile is an optional file that log records are appended to, in
+	// addition to stderr
+	LogFile string
+
+	// LogFileLevel sets the logging verbosity of LogFile (debug, info, warn, error)
+	LogFileLevel string
+
 	// Redact controls whether sensitive values are masked in output
 	Redact bool
 
@@ -102,26 +133,20 @@ type RuntimeConfig struct {
 func NewTopfRuntime(cfg RuntimeConfig) (Topf, error) {
 	decryptCache := decryption.NewCache()
 
-	topfConfig, secrets, err := config.LoadFromFile(cfg.ConfigPath, decryptCache)
+	// Parse log settings
+	level, err := logging.ParseLevel(cfg.LogLevel)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid log level: %w", err)
 	}
 
-	// Validate patchesDir exists and is a directory
-	if stat, err := os.Stat(topfConfig.PatchesDir); err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("patches directory does not exist: %s", topfConfig.PatchesDir)
-		}
-
-		return nil, fmt.Errorf("failed to access patches directory: %w", err)
-	} else if !stat.IsDir() {
-		return nil, fmt.Errorf("patches path is not a directory: %s", topfConfig.PatchesDir)
+	logFormat, err := logging.ParseFormat(cfg.LogFormat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log format: %w", err)
 	}
 
-	// Parse log level
-	level, err := parseLogLevel(cfg.LogLevel)
+	logFileLevel, err := logging.ParseLevel(cfg.LogFileLevel)
 	if err != nil {
-		return nil, fmt.Errorf("invalid log level: %w", err)
+		return nil, fmt.Errorf("invalid log file level: %w", err)
 	}
 
 	// Compile nodes filter regex; empty means all nodes
@@ -134,31 +159,102 @@ func NewTopfRuntime(cfg RuntimeConfig) (Topf, error) {
 		}
 	}
 
-	// Create logger with TextHandler
-	opts := &slog.HandlerOptions{
-		Level: level,
+	// All redaction (stdout, logs, reports) shares the same secrets, so that
+	// secrets registered later via AddSecretsToMask are redacted everywhere
+	var (
+		redactSecrets *maskedwriter.Secrets
+		mw            *maskedwriter.Writer
+	)
+
+	// stdout, stderr and the hook let RedirectOutput send all output to a
+	// dashboard while it is shown
+	var (
+		stdout = logging.NewSwitchWriter(os.Stdout)
+		stderr = logging.NewSwitchWriter(os.Stderr)
+		hook   = &logging.Hook{}
+	)
+
+	if cfg.Redact {
+		redactSecrets = maskedwriter.NewSecrets(nil)
+		mw = maskedwriter.NewShared(stdout, redactSecrets)
+	}
+
+	handlers := []slog.Handler{logging.NewHandler(logFormat, stderr, level), hook.Handler()}
+
+	var logFile *os.File
+
+	if cfg.LogFile != "" {
+		//nolint:gosec // writing to a user-provided log file is by design
+		logFile, err = os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+
+		handlers = append(handlers, logging.NewHandler(logFormat, logFile, logFileLevel))
+	}
+
+	handler := logging.NewFanoutHandler(handlers...)
+	if cfg.Redact {
+		handler = logging.NewRedactingHandler(handler, redactSecrets)
 	}
-	handler := slog.NewTextHandler(os.Stderr, opts)
+
 	logger := slog.New(handler)
 
-	var mw *maskedwriter.Writer
+	// The config is loaded once the logger exists, such that warnings of the
+	// nodes provider go through it; its secrets are redacted from then on
+	topfConfig, secrets, err := loadConfig(cfg.ConfigPath, decryptCache, logger)
+	if err != nil {
+		if logFile != nil {
+			_ = logFile.Close()
+		}
+
+		return nil, err
+	}
+
 	if cfg.Redact {
-		mw = maskedwriter.New(os.Stdout, secrets)
+		redactSecrets.Add(secrets)
 	}
 
 	return &topf{
 		TopfConfig:   topfConfig,
 		patchesDir:   topfConfig.PatchesDir,
 		logger:       logger,
+		secrets:      redactSecrets,
 		maskedWriter: mw,
+		stdout:       stdout,
+		stderr:       stderr,
+		hook:         hook,
+		logFile:      logFile,
 		confirm:      cfg.Confirm,
 		version:      cfg.TopfVersion,
+		configDir:    filepath.Dir(cfg.ConfigPath),
 		resolver:     schematic.NewResolver(filepath.Dir(cfg.ConfigPath), cfg.TopfVersion, schematic.WithSubmitToFactory(cfg.SubmitToFactory), schematic.WithLogger(logger)),
 		decryptCache: decryptCache,
 		nodesFilter:  nodesFilter,
 	}, nil
 }
 
+// loadConfig loads the config at path and validates its patches directory
+func loadConfig(path string, cache *decryption.Cache, logger *slog.Logger) (*config.TopfConfig, []string, error) {
+	topfConfig, secrets, err := config.LoadFromFile(path, cache, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate patchesDir exists and is a directory
+	if stat, err := os.Stat(topfConfig.PatchesDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("patches directory does not exist: %s", topfConfig.PatchesDir)
+		}
+
+		return nil, nil, fmt.Errorf("failed to access patches directory: %w", err)
+	} else if !stat.IsDir() {
+		return nil, nil, fmt.Errorf("patches path is not a directory: %s", topfConfig.PatchesDir)
+	}
+
+	return topfConfig, secrets, nil
+}
+
 type topf struct {
 	*config.TopfConfig
 	mu sync.Mutex
@@ -166,9 +262,15 @@ type topf struct {
 	patchesDir    string
 	secretsBundle *secrets.Bundle
 	logger        *slog.Logger
+	secrets       *maskedwriter.Secrets
 	maskedWriter  *maskedwriter.Writer
+	stdout        *logging.SwitchWriter
+	stderr        *logging.SwitchWriter
+	hook          *logging.Hook
+	logFile       *os.File
 	confirm       bool
 	version       string
+	configDir     string
 	resolver      *schematic.Resolver
 	decryptCache  *decryption.Cache
 	nodesFilter   *regexp.Regexp
@@ -182,6 +284,10 @@ func (t *topf) TopfVersion() string {
 	return t.version
 }
 
+func (t *topf) ConfigDir() string {
+	return t.configDir
+}
+
 // Logger returns the configured logger for this runtime
 func (t *topf) Logger() *slog.Logger {
 	return t.logger
@@ -192,13 +298,54 @@ func (t *topf) Writer() io.Writer {
 		return t.maskedWriter
 	}
 
-	return os.Stdout
+	return t.stdout
+}
+
+func (t *topf) MaskWriter(w io.Writer) io.WriteCloser {
+	if t.secrets != nil {
+		return maskedwriter.NewShared(w, t.secrets)
+	}
+
+	return nopCloser{w}
+}
+
+type nopCloser struct {
+	io.Writer
 }
 
+func (nopCloser) Close() error { return nil }
+
 func (t *topf) AddSecretsToMask(sensitive []string) {
+	if t.secrets != nil {
+		t.secrets.Add(sensitive)
+	}
+}
+
+func (t *topf) RedirectOutput(w io.Writer, observer slog.Handler) func() {
+	prevStdout := t.stdout.Swap(w)
+	prevStderr := t.stderr.Swap(w)
+
+	t.hook.Set(observer)
+
+	return func() {
+		t.hook.Set(nil)
+		t.stderr.Swap(prevStderr)
+		t.stdout.Swap(prevStdout)
+	}
+}
+
+func (t *topf) Close() error {
+	var errs []error
+
 	if t.maskedWriter != nil {
-		t.maskedWriter.AddSecrets(sensitive)
+		errs = append(errs, t.maskedWriter.Close())
+	}
+
+	if t.logFile != nil {
+		errs = append(errs, t.logFile.Close())
 	}
+
+	return errors.Join(errs...)
 }
 
 func (t *topf) Confirm() bool {
@@ -209,22 +356,26 @@ func (t *topf) ResolveSchematic(ctx context.Context, factory, schematicID string
 	return t.resolver.Resolve(ctx, factory, schematicID, patchCtx)
 }
 
-// ControlPlaneClient returns a Talos API client connected to a control-plane
-// node from the full (unfiltered) node list. The Kubeconfig RPC and other
-// cluster-wide operations are only available on control-plane nodes.
-func (t *topf) ControlPlaneClient(ctx context.Context) (*client.Client, error) {
-	for i := range t.Nodes {
-		nodeCfg := &t.Nodes[i]
+func (t *topf) ControlPlaneNodes() []*Node {
+	var nodes []*Node
 
-		if nodeCfg.Role != config.RoleControlPlane {
-			continue
+	for i := range t.Nodes {
+		if t.Nodes[i].Role == config.RoleControlPlane {
+			nodes = append(nodes, &Node{Node: &t.Nodes[i], t: t})
 		}
+	}
 
-		node := &Node{Node: nodeCfg, t: t}
+	return nodes
+}
 
+// ControlPlaneClient returns a Talos API client connected to a control-plane
+// node from the full (unfiltered) node list. The Kubeconfig RPC and other
+// cluster-wide operations are only available on control-plane nodes.
+func (t *topf) ControlPlaneClient(ctx context.Context) (*client.Client, error) {
+	for _, node := range t.ControlPlaneNodes() {
 		c, err := node.Client(ctx)
 		if err != nil {
-			t.logger.Warn("failed to connect to control-plane node, trying next", "node", nodeCfg.Host, "error", err)
+			t.logger.Warn("failed to connect to control-plane node, trying next", "node", node.Node.Host, "error", err)
 			continue
 		}
 
@@ -233,19 +384,3 @@ func (t *topf) ControlPlaneClient(ctx context.Context) (*client.Client, error) {
 
 	return nil, errors.New("no reachable control-plane node available")
 }
-
-// parseLogLevel converts a string log level to slog.Level
-func parseLogLevel(levelStr string) (slog.Level, error) {
-	switch strings.ToLower(levelStr) {
-	case "", "info":
-		return slog.LevelInfo, nil
-	case "debug":
-		return slog.LevelDebug, nil
-	case "warn", "warning":
-		return slog.LevelWarn, nil
-	case "error":
-		return slog.LevelError, nil
-	default:
-		return slog.LevelInfo, fmt.Errorf("unknown log level %q, valid levels: debug, info, warn, error", levelStr)
-	}
-}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/postfinance/topf/internal/telemetry"
 	"github.com/siderolabs/go-retry/retry"
 	"github.com/siderolabs/talos/pkg/machinery/api/machine"
 	"github.com/siderolabs/talos/pkg/machinery/client"
@@ -91,14 +92,14 @@ func (n *Node) Stabilize(ctx context.Context, logger *slog.Logger, stabilization
 		}
 	}
 
-	return retry.Constant(time.Minute*15, retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug))).RetryWithContext(ctx, waitForMachineReady)
+	return retry.Constant(time.Minute*15, retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug))).RetryWithContext(ctx, telemetry.Retried("stabilize", waitForMachineReady))
 }
 
 // WaitForMaintenance waits for the talos machine to reach maintenance mode
 func (n *Node) WaitForMaintenance(ctx context.Context, logger *slog.Logger) error {
 	return retry.Constant(time.Minute*15,
 		retry.WithErrorLogging(logger.Enabled(ctx, slog.LevelDebug)),
-	).RetryWithContext(ctx, func(ctx context.Context) error {
+	).RetryWithContext(ctx, telemetry.Retried("wait-for-maintenance", func(ctx context.Context) error {
 		nodeClient, err := n.Client(ctx)
 		if err != nil {
 			return retry.ExpectedErrorf("couldn't get client: %w", err)
@@ -115,5 +116,5 @@ func (n *Node) WaitForMaintenance(ctx context.Context, logger *slog.Logger) erro
 		}
 
 		return nil
-	})
+	}))
 }
@@ -0,0 +1,125 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package topf
+
+import (
+	"context"
+	"time"
+
+	"github.com/siderolabs/talos/pkg/machinery/api/machine"
+	"github.com/siderolabs/talos/pkg/machinery/client"
+	"github.com/siderolabs/talos/pkg/machinery/resources/runtime"
+)
+
+// watchRetryInterval is the delay between reconnects of a node watch, and
+// between polls of a node in maintenance mode
+const watchRetryInterval = 5 * time.Second
+
+// Update is a change of the live state of a watched node
+type Update struct {
+	Node *Node
+	// Err is set if the node became unreachable, e.g. while rebooting. The
+	// watch reconnects on its own.
+	Err error
+
+	state liveState
+}
+
+// Apply records the update on its node. It must not be called concurrently
+// with other methods of the node.
+func (u Update) Apply() {
+	u.Node.Error = u.Err
+
+	if u.Err == nil {
+		u.Node.setLiveState(u.state)
+	}
+}
+
+// Watch sends the live state of the node to updates until ctx is canceled:
+// the state when connecting, then every machine status event. The event
+// stream is reopened after errors, picking up a new Talos version, schematic
+// or config after a reboot. Nodes in maintenance mode have no event stream
+// and are polled instead.
+func (n *Node) Watch(ctx context.Context, updates chan<- Update) {
+	for {
+		if err := n.watch(ctx, updates); err != nil && ctx.Err() == nil {
+			sendUpdate(ctx, updates, Update{Node: n, Err: err})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// watch follows the events of the node until the stream breaks. It returns
+// nil for nodes in maintenance mode, which are polled.
+func (n *Node) watch(ctx context.Context, updates chan<- Update) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	nodeClient, err := n.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer nodeClient.Close()
+
+	// start watching before reading the state, such that no event between
+	// both is missed
+	eventChannel := make(chan client.EventResult)
+	watchErr := nodeClient.EventsWatchV2(ctx, eventChannel)
+
+	state, err := readLiveState(ctx, nodeClient)
+	if err != nil {
+		return err
+	}
+
+	sendUpdate(ctx, updates, Update{Node: n, state: state})
+
+	if watchErr != nil {
+		if state.machineStatus.Stage == runtime.MachineStageMaintenance {
+			return nil
+		}
+
+		return watchErr
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e := <-eventChannel:
+			if e.Error != nil {
+				return e.Error
+			}
+
+			msg, ok := e.Event.Payload.(*machine.MachineStatusEvent)
+			if !ok {
+				continue
+			}
+
+			// the event stages are numbered like the resource stages
+			state.machineStatus = runtime.MachineStatusSpec{
+				Stage:  runtime.MachineStage(msg.GetStage()),
+				Status: runtime.MachineStatusStatus{Ready: msg.GetStatus().GetReady()},
+			}
+
+			for _, cond := range msg.GetStatus().GetUnmetConditions() {
+				state.machineStatus.Status.UnmetConditions = append(state.machineStatus.Status.UnmetConditions,
+					runtime.UnmetCondition{Name: cond.GetName(), Reason: cond.GetReason()})
+			}
+
+			sendUpdate(ctx, updates, Update{Node: n, state: state})
+		}
+	}
+}
+
+func sendUpdate(ctx context.Context, updates chan<- Update, update Update) {
+	select {
+	case updates <- update:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// DefaultHealthGateTimeout is the timeout of a health gate that doesn't set one
+const DefaultHealthGateTimeout = 10 * time.Minute
+
+// HealthGates configures the checks that must pass after each node of a
+// rolling operation (apply, upgrade) before moving on to the next node.
+// Gates run after the node stabilized; all gates are disabled by default.
+type HealthGates struct {
+	// Timeout is the default timeout of every gate (default: 10m)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// NodeReady waits for the Kubernetes node to report Ready
+	NodeReady *HealthGate `yaml:"nodeReady,omitempty"`
+	// DaemonSets waits for every DaemonSet pod on the node to run the
+	// current revision and be ready
+	DaemonSets *HealthGate `yaml:"daemonSets,omitempty"`
+	// Etcd waits for all control-plane nodes to report a healthy etcd
+	// member list with one voting member per control-plane node
+	Etcd *HealthGate `yaml:"etcd,omitempty"`
+
+	// Workloads waits for user-defined workloads to be available
+	Workloads []WorkloadHealthGate `yaml:"workloads,omitempty"`
+}
+
+// HealthGate enables a built-in health gate
+type HealthGate struct {
+	Enabled bool `yaml:"enabled"`
+	// Timeout overrides the default timeout of the gate
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Supported workload kinds of a WorkloadHealthGate
+const (
+	WorkloadDeployment  = "Deployment"
+	WorkloadStatefulSet = "StatefulSet"
+	WorkloadDaemonSet   = "DaemonSet"
+)
+
+// WorkloadHealthGate waits for a workload to have enough available replicas
+type WorkloadHealthGate struct {
+	// Kind is one of Deployment, StatefulSet or DaemonSet
+	Kind      string `yaml:"kind"`
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	// MinAvailable is the number of replicas that must be available.
+	// Defaults to the desired number of replicas.
+	MinAvailable *int32 `yaml:"minAvailable,omitempty"`
+	// Timeout overrides the default timeout of the gate
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// String returns a short identifier of the workload, e.g. Deployment/ns/name
+func (w *WorkloadHealthGate) String() string {
+	return fmt.Sprintf("%s/%s/%s", w.Kind, w.Namespace, w.Name)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler and performs additional validation
+func (w *WorkloadHealthGate) UnmarshalYAML(yamlNode *yaml.Node) error {
+	type raw WorkloadHealthGate
+
+	if err := yamlNode.Decode((*raw)(w)); err != nil {
+		return err
+	}
+
+	if !slices.Contains([]string{WorkloadDeployment, WorkloadStatefulSet, WorkloadDaemonSet}, w.Kind) {
+		return fmt.Errorf("workload health gate 'kind' must be one of %s, %s, %s, got %q", WorkloadDeployment, WorkloadStatefulSet, WorkloadDaemonSet, w.Kind)
+	}
+
+	if w.Namespace == "" || w.Name == "" {
+		return errors.New("workload health gate 'namespace' and 'name' can't be empty")
+	}
+
+	if w.MinAvailable != nil && *w.MinAvailable < 0 {
+		return errors.New("workload health gate 'minAvailable' can't be negative")
+	}
+
+	return nil
+}
+
+// GateTimeout returns timeout if set, otherwise the default timeout of the gates
+func (h *HealthGates) GateTimeout(timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+
+	return DefaultHealthGateTimeout
+}
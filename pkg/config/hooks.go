@@ -0,0 +1,109 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// DefaultHookTimeout is the timeout of a hook that doesn't set one
+const DefaultHookTimeout = 5 * time.Minute
+
+// Failure policies of a hook
+const (
+	// HookAbort fails the node, or the command for cluster hooks
+	HookAbort = "abort"
+	// HookSkip skips the node; only valid for pre-node hooks
+	HookSkip = "skip"
+	// HookIgnore logs a warning and carries on
+	HookIgnore = "ignore"
+)
+
+// Hooks configures executables run around the rolling operations (apply,
+// upgrade, reset), e.g. to silence alerts or update a load balancer pool.
+// Hooks are not run in dry-run mode.
+type Hooks struct {
+	// PreCluster hooks run once before the first node is changed
+	PreCluster []Hook `yaml:"preCluster,omitempty"`
+	// PostCluster hooks run once after the last node, even if the command failed
+	PostCluster []Hook `yaml:"postCluster,omitempty"`
+	// PreNode hooks run before each node is changed
+	PreNode []Hook `yaml:"preNode,omitempty"`
+	// PostNode hooks run after each node, even if the node failed
+	PostNode []Hook `yaml:"postNode,omitempty"`
+}
+
+// Hook is an executable run at a hook point
+type Hook struct {
+	// Command is the path of the executable
+	Command string `yaml:"command"`
+	// Timeout is how long the hook may run (default: 5m)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// OnFailure is what a non-zero exit does: "abort", "skip" (pre-node hooks
+	// only) or "ignore". Defaults to "abort" for pre hooks and "ignore" for
+	// post hooks.
+	OnFailure string `yaml:"onFailure,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler and performs additional validation
+func (h *Hooks) UnmarshalYAML(yamlNode *yaml.Node) error {
+	type raw Hooks
+
+	if err := yamlNode.Decode((*raw)(h)); err != nil {
+		return err
+	}
+
+	for _, point := range []struct {
+		name     string
+		hooks    []Hook
+		fallback string
+		policies []string
+	}{
+		{name: "preCluster", hooks: h.PreCluster, fallback: HookAbort, policies: []string{HookAbort, HookIgnore}},
+		{name: "postCluster", hooks: h.PostCluster, fallback: HookIgnore, policies: []string{HookAbort, HookIgnore}},
+		{name: "preNode", hooks: h.PreNode, fallback: HookAbort, policies: []string{HookAbort, HookSkip, HookIgnore}},
+		{name: "postNode", hooks: h.PostNode, fallback: HookIgnore, policies: []string{HookAbort, HookIgnore}},
+	} {
+		for i := range point.hooks {
+			hook := &point.hooks[i]
+
+			if hook.Command == "" {
+				return fmt.Errorf("%s hook %d: 'command' can't be empty", point.name, i+1)
+			}
+
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%s hook %s: 'timeout' can't be negative", point.name, hook.Command)
+			}
+
+			if hook.OnFailure == "" {
+				hook.OnFailure = point.fallback
+			}
+
+			if !slices.Contains(point.policies, hook.OnFailure) {
+				return fmt.Errorf("%s hook %s: 'onFailure' must be one of %s, got %q", point.name, hook.Command, strings.Join(point.policies, ", "), hook.OnFailure)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HookTimeout returns the timeout of the hook, or the default one
+func (h *Hook) HookTimeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+
+	return DefaultHookTimeout
+}
+
+// Empty reports whether no hooks are configured
+func (h *Hooks) Empty() bool {
+	return h == nil || len(h.PreCluster)+len(h.PostCluster)+len(h.PreNode)+len(h.PostNode) == 0
+}
@@ -29,6 +29,10 @@ type Node struct {
 	Platform string `yaml:"platform,omitempty"`
 	// SecureBoot enables the secure boot installer variant for this node
 	SecureBoot bool `yaml:"secureboot,omitempty"`
+
+	// Provided is set for nodes returned by the nodes provider instead of
+	// being listed in topf.yaml
+	Provided bool `yaml:"-"`
 }
 
 // Endpoint returns the IP address if set, otherwise returns the Host.
@@ -0,0 +1,141 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	// DefaultNotificationTimeout is the timeout of a single delivery attempt
+	DefaultNotificationTimeout = 10 * time.Second
+	// DefaultNotificationRetries is the number of retries of a failed delivery
+	DefaultNotificationRetries = 3
+)
+
+// Payload formats of a notification
+const (
+	// NotificationJSON posts the event as a JSON document
+	NotificationJSON = "json"
+	// NotificationSlack posts a {"text": ...} message, as understood by
+	// Slack and Mattermost incoming webhooks
+	NotificationSlack = "slack"
+)
+
+// Events a notification can be sent for
+const (
+	// EventStart is sent when a rolling operation starts
+	EventStart = "start"
+	// EventNode is sent when a node finished, failed or was skipped
+	EventNode = "node"
+	// EventNodeFailed is sent when a node failed
+	EventNodeFailed = "node-failed"
+	// EventFinish is sent at the end of a rolling operation, with a summary
+	EventFinish = "finish"
+)
+
+// Notification posts the events of the rolling operations (apply, upgrade,
+// reset) to a webhook. Notifications are not sent in dry-run mode.
+type Notification struct {
+	// URL is the webhook URL the events are posted to
+	URL string `yaml:"url"`
+	// Format is the payload format, "json" (default) or "slack"
+	Format string `yaml:"format,omitempty"`
+	// Events are the events to send (default: start, node, finish)
+	Events []string `yaml:"events,omitempty"`
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Templates override the default messages per event
+	Templates map[string]string `yaml:"templates,omitempty"`
+	// Timeout is the timeout of a single delivery attempt (default: 10s)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Retries is the number of retries of a failed delivery (default: 3)
+	Retries *int `yaml:"retries,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler and performs additional validation
+func (n *Notification) UnmarshalYAML(yamlNode *yaml.Node) error {
+	type raw Notification
+
+	if err := yamlNode.Decode((*raw)(n)); err != nil {
+		return err
+	}
+
+	if n.URL == "" {
+		return errors.New("notification 'url' can't be empty")
+	}
+
+	if u, err := url.Parse(n.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("notification 'url' must be an http or https URL")
+	}
+
+	if n.Format == "" {
+		n.Format = NotificationJSON
+	}
+
+	if n.Format != NotificationJSON && n.Format != NotificationSlack {
+		return fmt.Errorf("notification 'format' must be one of %s, %s, got %q", NotificationJSON, NotificationSlack, n.Format)
+	}
+
+	events := []string{EventStart, EventNode, EventNodeFailed, EventFinish}
+
+	if len(n.Events) == 0 {
+		n.Events = []string{EventStart, EventNode, EventFinish}
+	}
+
+	for _, event := range n.Events {
+		if !slices.Contains(events, event) {
+			return fmt.Errorf("notification 'events' must be one of %s, got %q", strings.Join(events, ", "), event)
+		}
+	}
+
+	for event, text := range n.Templates {
+		if event != EventStart && event != EventNode && event != EventFinish {
+			return fmt.Errorf("notification 'templates' keys must be one of %s, %s, %s, got %q", EventStart, EventNode, EventFinish, event)
+		}
+
+		if _, err := template.New(event).Funcs(sprig.TxtFuncMap()).Parse(text); err != nil {
+			return fmt.Errorf("notification template %s: %w", event, err)
+		}
+	}
+
+	if n.Timeout < 0 {
+		return errors.New("notification 'timeout' can't be negative")
+	}
+
+	if n.Retries != nil && *n.Retries < 0 {
+		return errors.New("notification 'retries' can't be negative")
+	}
+
+	return nil
+}
+
+// NotificationTimeout returns the timeout of a delivery attempt, or the
+// default one
+func (n *Notification) NotificationTimeout() time.Duration {
+	if n.Timeout > 0 {
+		return n.Timeout
+	}
+
+	return DefaultNotificationTimeout
+}
+
+// NotificationRetries returns the number of retries of a failed delivery,
+// or the default one
+func (n *Notification) NotificationRetries() int {
+	if n.Retries != nil {
+		return *n.Retries
+	}
+
+	return DefaultNotificationRetries
+}
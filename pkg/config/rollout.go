@@ -0,0 +1,85 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"errors"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Rollout configures how the worker nodes of a rolling operation (apply,
+// upgrade) are split into waves, e.g. a canary node first, then growing
+// batches. Control-plane nodes are always processed one at a time.
+type Rollout struct {
+	// Waves are the sizes of the successive waves, each a node count (e.g.
+	// "1"), a percentage of the worker nodes (e.g. "10%") or "rest" for all
+	// remaining nodes. The last size is repeated until all nodes are done.
+	Waves []string `yaml:"waves,omitempty"`
+	// Pause is waited between two waves
+	Pause time.Duration `yaml:"pause,omitempty"`
+	// MaxFailures is the number of failed nodes tolerated within a wave
+	// before the rollout halts (default: 0)
+	MaxFailures int `yaml:"maxFailures,omitempty"`
+	// Topology limits how many failure domains, e.g. racks or zones, have
+	// nodes in flight at once
+	Topology *Topology `yaml:"topology,omitempty"`
+}
+
+// Topology groups the worker nodes of a rollout into failure domains by a
+// key of their data
+type Topology struct {
+	// Key is the data key holding the failure domain of a node, with dots
+	// separating the keys of nested maps (e.g. "rack" or "location.zone")
+	Key string `yaml:"key"`
+	// MaxDomains is the number of failure domains with nodes in flight at
+	// once (default: 1, unlimited if only maxPerDomain is set)
+	MaxDomains int `yaml:"maxDomains,omitempty"`
+	// MaxPerDomain is the number of nodes of the same failure domain in
+	// flight at once (default: unlimited)
+	MaxPerDomain int `yaml:"maxPerDomain,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler and performs additional validation
+func (r *Rollout) UnmarshalYAML(yamlNode *yaml.Node) error {
+	type raw Rollout
+
+	if err := yamlNode.Decode((*raw)(r)); err != nil {
+		return err
+	}
+
+	if r.Pause < 0 {
+		return errors.New("rollout 'pause' can't be negative")
+	}
+
+	if r.MaxFailures < 0 {
+		return errors.New("rollout 'maxFailures' can't be negative")
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler and performs additional validation
+func (t *Topology) UnmarshalYAML(yamlNode *yaml.Node) error {
+	type raw Topology
+
+	if err := yamlNode.Decode((*raw)(t)); err != nil {
+		return err
+	}
+
+	if t.Key == "" {
+		return errors.New("rollout topology 'key' is required")
+	}
+
+	if t.MaxDomains < 0 {
+		return errors.New("rollout topology 'maxDomains' can't be negative")
+	}
+
+	if t.MaxPerDomain < 0 {
+		return errors.New("rollout topology 'maxPerDomain' can't be negative")
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -35,7 +36,9 @@ type TopfConfig struct {
 	// set, will use a local secrets.yaml (see SecretsPath) with optional SOPS encryption.
 	SecretsProvider string `yaml:"secretsProvider,omitempty"`
 
-	// NodesProvider can be optionally set to the path of a binary which will provide additional nodes
+	// NodesProvider can be optionally set to provide additional nodes: a
+	// file://, http(s):// or terraform-state:// URI of a built-in provider, or
+	// the path of a binary
 	NodesProvider string `yaml:"nodesProvider,omitempty"`
 
 	// PatchesDir is the directory containing patches and node-specific configurations.
@@ -50,6 +53,21 @@ type TopfConfig struct {
 	// Defaults to "secrets.yaml" next to the config file.
 	SecretsPath string `yaml:"secretsPath,omitempty"`
 
+	// HealthGates configures the checks run after each node of a rolling
+	// operation before moving on to the next node
+	HealthGates *HealthGates `yaml:"healthGates,omitempty"`
+
+	// Rollout configures the waves worker nodes of a rolling operation are
+	// processed in
+	Rollout *Rollout `yaml:"rollout,omitempty"`
+
+	// Hooks are executables run before and after the rolling operations and
+	// each of their nodes
+	Hooks *Hooks `yaml:"hooks,omitempty"`
+
+	// Notifications post the events of the rolling operations to webhooks
+	Notifications []Notification `yaml:"notifications,omitempty"`
+
 	Nodes []Node `yaml:"nodes"`
 
 	// Data can contain arbitrary data that can be used when templating patches
@@ -60,7 +78,8 @@ type TopfConfig struct {
 // PatchesDir defaults to the directory containing the config file.
 // SecretsPath defaults to "secrets.yaml" next to the config file (not inside PatchesDir).
 // Relative paths for both are resolved against the directory containing the config file.
-func LoadFromFile(path string, cache *decryption.Cache) (config *TopfConfig, secrets []string, err error) {
+// Warnings of the nodes provider are logged to logger.
+func LoadFromFile(path string, cache *decryption.Cache, logger *slog.Logger) (config *TopfConfig, secrets []string, err error) {
 	// Read file with automatic SOPS decryption if needed
 	var content []byte
 
@@ -104,7 +123,10 @@ func LoadFromFile(path string, cache *decryption.Cache) (config *TopfConfig, sec
 
 	// If a nodes provider is given, add those to the list of nodes
 	if config.NodesProvider != "" {
-		provider := providers.NewBinaryNodesProvider(config.NodesProvider)
+		provider, err := providers.NewNodesProvider(config.NodesProvider, configFileDir, logger)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid nodes provider: %w", err)
+		}
 
 		nodesYAML, err := providers.LoadNodesYAML(provider, config.ClusterName)
 		if err != nil {
@@ -116,6 +138,10 @@ func LoadFromFile(path string, cache *decryption.Cache) (config *TopfConfig, sec
 			return nil, nil, fmt.Errorf("failed to parse nodes from provider: %w", err)
 		}
 
+		for i := range nodes {
+			nodes[i].Provided = true
+		}
+
 		config.Nodes = append(config.Nodes, nodes...)
 	}
 
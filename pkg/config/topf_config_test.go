@@ -4,14 +4,18 @@
 package config
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/postfinance/topf/internal/decryption"
 )
 
+var discard = slog.New(slog.DiscardHandler) //nolint:gochecknoglobals // shared test logger
+
 func writeTestConfig(t *testing.T, dir, content string) string {
 	t.Helper()
 
@@ -86,7 +90,7 @@ func TestLoadFromFile(t *testing.T) {
 				}
 				yaml += "nodes:\n  - host: n1\n    role: worker\n"
 
-				cfg, _, err := LoadFromFile(writeTestConfig(t, sub, yaml), decryption.NewCache())
+				cfg, _, err := LoadFromFile(writeTestConfig(t, sub, yaml), decryption.NewCache(), discard)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -108,7 +112,7 @@ configDir: _config
 nodes:
   - host: n1
     role: worker
-`), decryption.NewCache())
+`), decryption.NewCache(), discard)
 		if err == nil {
 			t.Fatal("expected error for deprecated configDir field")
 		}
@@ -116,4 +120,243 @@ nodes:
 			t.Errorf("expected error mentioning configDir and patchesDir, got: %v", err)
 		}
 	})
+
+	t.Run("healthGates", func(t *testing.T) {
+		cfg, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+clusterEndpoint: https://1.2.3.4:6443
+kubernetesVersion: 1.30.0
+healthGates:
+  timeout: 5m
+  nodeReady:
+    enabled: true
+  etcd:
+    enabled: true
+    timeout: 2m
+  workloads:
+    - kind: Deployment
+      namespace: ingress
+      name: ingress-nginx
+      minAvailable: 2
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gates := cfg.HealthGates
+		if gates == nil || gates.NodeReady == nil || !gates.NodeReady.Enabled || gates.DaemonSets != nil {
+			t.Fatalf("unexpected health gates: %+v", gates)
+		}
+
+		if got := gates.GateTimeout(gates.NodeReady.Timeout); got != 5*time.Minute {
+			t.Errorf("nodeReady timeout: got %s, want 5m", got)
+		}
+
+		if got := gates.GateTimeout(gates.Etcd.Timeout); got != 2*time.Minute {
+			t.Errorf("etcd timeout: got %s, want 2m", got)
+		}
+
+		if len(gates.Workloads) != 1 || gates.Workloads[0].String() != "Deployment/ingress/ingress-nginx" || *gates.Workloads[0].MinAvailable != 2 {
+			t.Errorf("unexpected workloads: %+v", gates.Workloads)
+		}
+
+		_, _, err = LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+healthGates:
+  workloads:
+    - kind: Job
+      namespace: default
+      name: foo
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err == nil || !strings.Contains(err.Error(), "kind") {
+			t.Errorf("expected error for unsupported workload kind, got: %v", err)
+		}
+	})
+
+	t.Run("rollout", func(t *testing.T) {
+		cfg, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+rollout:
+  waves: [1, 10%, rest]
+  pause: 2m
+  maxFailures: 1
+  topology:
+    key: rack
+    maxPerDomain: 1
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rollout := cfg.Rollout
+		if rollout == nil || strings.Join(rollout.Waves, ",") != "1,10%,rest" || rollout.Pause != 2*time.Minute || rollout.MaxFailures != 1 {
+			t.Fatalf("unexpected rollout: %+v", rollout)
+		}
+
+		if topology := rollout.Topology; topology == nil || topology.Key != "rack" || topology.MaxDomains != 0 || topology.MaxPerDomain != 1 {
+			t.Fatalf("unexpected rollout topology: %+v", topology)
+		}
+
+		_, _, err = LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+rollout:
+  maxFailures: -1
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err == nil || !strings.Contains(err.Error(), "maxFailures") {
+			t.Errorf("expected error for negative maxFailures, got: %v", err)
+		}
+
+		_, _, err = LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+rollout:
+  topology:
+    maxDomains: 2
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err == nil || !strings.Contains(err.Error(), "'key' is required") {
+			t.Errorf("expected error for missing topology key, got: %v", err)
+		}
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		cfg, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+hooks:
+  preNode:
+    - command: /usr/local/bin/drain-lb
+      timeout: 30s
+      onFailure: skip
+  postNode:
+    - command: /usr/local/bin/undrain-lb
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hooks := cfg.Hooks
+		if hooks.Empty() || len(hooks.PreNode) != 1 || len(hooks.PostNode) != 1 {
+			t.Fatalf("unexpected hooks: %+v", hooks)
+		}
+
+		if pre := hooks.PreNode[0]; pre.OnFailure != HookSkip || pre.HookTimeout() != 30*time.Second {
+			t.Errorf("unexpected pre-node hook: %+v", pre)
+		}
+
+		if post := hooks.PostNode[0]; post.OnFailure != HookIgnore || post.HookTimeout() != DefaultHookTimeout {
+			t.Errorf("unexpected post-node hook: %+v", post)
+		}
+
+		for _, tt := range []struct {
+			name, hooks, want string
+		}{
+			{name: "missing command", hooks: "preCluster:\n    - timeout: 1m", want: "'command' can't be empty"},
+			{name: "skip outside pre-node", hooks: "postNode:\n    - command: /bin/true\n      onFailure: skip", want: "'onFailure' must be one of abort, ignore"},
+		} {
+			_, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), "clusterName: test\nhooks:\n  "+tt.hooks+`
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: expected error containing %q, got: %v", tt.name, tt.want, err)
+			}
+		}
+	})
+	t.Run("notifications", func(t *testing.T) {
+		cfg, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), `clusterName: test
+notifications:
+  - url: https://chat.example.com/hooks/abc
+    format: slack
+    events: [node-failed, finish]
+    templates:
+      finish: "{{ .Command }} {{ .Result }}"
+  - url: http://localhost:8080/events
+    retries: 0
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(cfg.Notifications) != 2 {
+			t.Fatalf("unexpected notifications: %+v", cfg.Notifications)
+		}
+
+		if chat := cfg.Notifications[0]; chat.Format != NotificationSlack || chat.NotificationRetries() != DefaultNotificationRetries || chat.NotificationTimeout() != DefaultNotificationTimeout {
+			t.Errorf("unexpected chat notification: %+v", chat)
+		}
+
+		if generic := cfg.Notifications[1]; generic.Format != NotificationJSON || strings.Join(generic.Events, ",") != "start,node,finish" || generic.NotificationRetries() != 0 {
+			t.Errorf("unexpected generic notification: %+v", generic)
+		}
+
+		for _, tt := range []struct {
+			name, notification, want string
+		}{
+			{name: "missing scheme", notification: "url: chat.example.com", want: "http or https URL"},
+			{name: "unknown event", notification: "url: https://example.com\n    events: [progress]", want: "'events' must be one of"},
+			{name: "invalid template", notification: "url: https://example.com\n    templates:\n      node: \"{{ .Node\"", want: "notification template node"},
+		} {
+			_, _, err := LoadFromFile(writeTestConfig(t, t.TempDir(), "clusterName: test\nnotifications:\n  - "+tt.notification+`
+nodes:
+  - host: n1
+    role: worker
+`), decryption.NewCache(), discard)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: expected error containing %q, got: %v", tt.name, tt.want, err)
+			}
+		}
+	})
+
+	t.Run("nodesProvider", func(t *testing.T) {
+		dir := t.TempDir()
+
+		if err := os.MkdirAll(filepath.Join(dir, "nodes"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "nodes", "workers.yaml"), []byte("- host: w1\n  role: worker\n  ip: 192.0.2.1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, _, err := LoadFromFile(writeTestConfig(t, dir, `clusterName: test
+nodesProvider: file://nodes/*.yaml
+nodes:
+  - host: cp1
+    role: control-plane
+`), decryption.NewCache(), discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(cfg.Nodes) != 2 || cfg.Nodes[0].Provided || !cfg.Nodes[1].Provided || cfg.Nodes[1].Endpoint() != "192.0.2.1" {
+			t.Errorf("unexpected nodes: %+v", cfg.Nodes)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "nodes", "invalid.yaml"), []byte("- host: w2\n  role: storage\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := LoadFromFile(writeTestConfig(t, dir, "clusterName: test\nnodesProvider: file://nodes/*.yaml\n"), decryption.NewCache(), discard); err == nil {
+			t.Error("expected an error for a provided node with an invalid role")
+		}
+
+		if _, _, err := LoadFromFile(writeTestConfig(t, dir, "clusterName: test\nnodesProvider: terraform-state://terraform.tfstate\n"), decryption.NewCache(), discard); err == nil ||
+			!strings.Contains(err.Error(), "invalid nodes provider") {
+			t.Errorf("expected an error for a terraform-state provider without mapping, got: %v", err)
+		}
+	})
 }
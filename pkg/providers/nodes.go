@@ -5,14 +5,49 @@ package providers
 
 import (
 	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Schemes of the built-in nodes providers
+const (
+	SchemeFile           = "file://"
+	SchemeHTTP           = "http://"
+	SchemeHTTPS          = "https://"
+	SchemeTerraformState = "terraform-state://"
 )
 
+// clusterPlaceholder is replaced with the cluster name in the locations of
+// the built-in nodes providers
+const clusterPlaceholder = "{cluster}"
+
 // NodesProvider is an interface for getting nodes from an external source
 type NodesProvider interface {
 	// GetNodes returns the YAML representation of nodes for the given cluster
 	GetNodes(clusterName string) ([]byte, error)
 }
 
+// NewNodesProvider returns the NodesProvider for spec: a built-in provider if
+// spec starts with one of the supported schemes, otherwise the binary at
+// spec. Relative paths of the built-in providers are resolved against baseDir.
+// Warnings of the providers are logged to logger.
+func NewNodesProvider(spec, baseDir string, logger *slog.Logger) (NodesProvider, error) {
+	switch {
+	case strings.HasPrefix(spec, SchemeFile):
+		return NewFileNodesProvider(resolve(strings.TrimPrefix(spec, SchemeFile), baseDir)), nil
+	case strings.HasPrefix(spec, SchemeHTTP), strings.HasPrefix(spec, SchemeHTTPS):
+		return NewHTTPNodesProvider(spec, logger)
+	case strings.HasPrefix(spec, SchemeTerraformState):
+		statePath, query, _ := strings.Cut(strings.TrimPrefix(spec, SchemeTerraformState), "?")
+		return NewTerraformStateNodesProvider(resolve(statePath, baseDir), query)
+	default:
+		return NewBinaryNodesProvider(spec), nil
+	}
+}
+
 // LoadNodesYAML gets the YAML bytes from a provider
 func LoadNodesYAML(provider NodesProvider, clusterName string) ([]byte, error) {
 	nodesYAML, err := provider.GetNodes(clusterName)
@@ -22,3 +57,23 @@ func LoadNodesYAML(provider NodesProvider, clusterName string) ([]byte, error) {
 
 	return nodesYAML, nil
 }
+
+// resolve returns path relative to baseDir, unless it is absolute
+func resolve(path, baseDir string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(baseDir, path)
+}
+
+// nodeList parses content as a YAML or JSON list of nodes. The nodes are
+// only validated once all of them are loaded.
+func nodeList(content []byte) ([]any, error) {
+	var nodes []any
+	if err := yaml.Unmarshal(content, &nodes); err != nil {
+		return nil, fmt.Errorf("expected a list of nodes: %w", err)
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// NewFileNodesProvider returns a NodesProvider that reads the nodes from the
+// YAML or JSON files matching the glob pattern. The lists of all files are
+// concatenated in lexical order of the file names.
+func NewFileNodesProvider(pattern string) NodesProvider {
+	return &fileNodes{
+		pattern: pattern,
+	}
+}
+
+type fileNodes struct {
+	pattern string
+}
+
+func (n *fileNodes) GetNodes(clusterName string) ([]byte, error) {
+	pattern := strings.ReplaceAll(n.pattern, clusterPlaceholder, clusterName)
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodes file pattern %q: %w", pattern, err)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no nodes file matches %q", pattern)
+	}
+
+	var nodes []any
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path) //nolint:gosec // reading the configured files is the purpose
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nodes file: %w", err)
+		}
+
+		list, err := nodeList(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		nodes = append(nodes, list...)
+	}
+
+	return yaml.Marshal(nodes)
+}
@@ -0,0 +1,244 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// NodesProviderTokenEnv is the environment variable holding the bearer
+	// token sent to HTTP nodes providers
+	NodesProviderTokenEnv = "TOPF_NODES_PROVIDER_TOKEN"
+	// NodesProviderMaxCacheAgeEnv is the environment variable holding how old
+	// the cached nodes of HTTP nodes providers may be to be used while the
+	// server can't be reached
+	NodesProviderMaxCacheAgeEnv = "TOPF_NODES_PROVIDER_MAX_CACHE_AGE"
+	// httpNodesTimeout limits how long fetching the nodes may take
+	httpNodesTimeout = 30 * time.Second
+)
+
+// NewHTTPNodesProvider returns a NodesProvider that gets the nodes as YAML or
+// JSON list from rawURL. The last response is cached and revalidated with
+// conditional requests. If NodesProviderMaxCacheAgeEnv is set, it is also
+// used while the server can't be reached, as long as it isn't older.
+func NewHTTPNodesProvider(rawURL string, logger *slog.Logger) (NodesProvider, error) {
+	var maxCacheAge time.Duration
+
+	if value := os.Getenv(NodesProviderMaxCacheAgeEnv); value != "" {
+		var err error
+
+		maxCacheAge, err = time.ParseDuration(value)
+		if err != nil || maxCacheAge < 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive duration, e.g. 24h", NodesProviderMaxCacheAgeEnv, value)
+		}
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err == nil {
+		cacheDir = filepath.Join(cacheDir, "topf", "nodes")
+	}
+
+	return &httpNodes{
+		url:         rawURL,
+		token:       os.Getenv(NodesProviderTokenEnv),
+		client:      &http.Client{Timeout: httpNodesTimeout},
+		cacheDir:    cacheDir,
+		maxCacheAge: maxCacheAge,
+		logger:      logger,
+	}, nil
+}
+
+type httpNodes struct {
+	url    string
+	token  string
+	client *http.Client
+	// cacheDir is where responses are cached, caching is disabled if empty
+	cacheDir string
+	// maxCacheAge is how old the cached nodes may be to be used while the
+	// server can't be reached, zero never uses them
+	maxCacheAge time.Duration
+	logger      *slog.Logger
+}
+
+func (n *httpNodes) GetNodes(clusterName string) ([]byte, error) {
+	rawURL := strings.ReplaceAll(n.url, clusterPlaceholder, url.PathEscape(clusterName))
+	cache := n.cache(rawURL)
+
+	nodes, err := n.fetch(rawURL, cache)
+	if err == nil {
+		return nodes, nil
+	}
+
+	// an unreachable inventory shouldn't block operations on the cluster, as
+	// long as the cached nodes are recent enough to be trusted
+	var unreachable *url.Error
+	if n.maxCacheAge == 0 || !errors.As(err, &unreachable) {
+		return nil, err
+	}
+
+	cached, cacheErr := cache.read()
+	if cacheErr != nil {
+		return nil, err
+	}
+
+	if age := time.Since(cached.modified); age > n.maxCacheAge {
+		return nil, fmt.Errorf("%w (cached nodes are %s old, more than %s=%s)",
+			err, age.Round(time.Second), NodesProviderMaxCacheAgeEnv, n.maxCacheAge)
+	}
+
+	n.logger.Warn("nodes provider unreachable, using cached nodes", "error", unreachable.Err, "cached", cached.modified)
+
+	return cached.body, nil
+}
+
+// fetch gets the nodes from rawURL, or from the cache if they didn't change
+func (n *httpNodes) fetch(rawURL string, cache *nodesCache) ([]byte, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodes provider URL: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/yaml, application/json")
+
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	cached, cacheErr := cache.read()
+	if cacheErr == nil && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// the URL may carry credentials, only keep its host
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = req.URL.Host
+		}
+
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cacheErr == nil {
+		// the server confirmed the cached nodes, which makes them recent
+		if err := cache.touch(); err != nil {
+			n.logger.Warn("failed to cache nodes", "error", err)
+		}
+
+		return cached.body, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nodes provider %s responded with %s", req.URL.Host, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes from %s: %w", req.URL.Host, err)
+	}
+
+	if _, err := nodeList(body); err != nil {
+		return nil, fmt.Errorf("nodes provider %s: %w", req.URL.Host, err)
+	}
+
+	if err := cache.write(body, resp.Header.Get("ETag")); err != nil {
+		n.logger.Warn("failed to cache nodes", "error", err)
+	}
+
+	return body, nil
+}
+
+// nodesCache is the cached response of a nodes provider URL. A nil
+// *nodesCache caches nothing.
+type nodesCache struct {
+	path string
+}
+
+type cachedNodes struct {
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+// cache returns the cache of rawURL, keyed by its hash as it may carry
+// credentials
+func (n *httpNodes) cache(rawURL string) *nodesCache {
+	if n.cacheDir == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+
+	return &nodesCache{path: filepath.Join(n.cacheDir, hex.EncodeToString(sum[:]))}
+}
+
+func (c *nodesCache) read() (*cachedNodes, error) {
+	if c == nil {
+		return nil, os.ErrNotExist
+	}
+
+	info, err := os.Stat(c.path + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := os.ReadFile(c.path + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	etag, err := os.ReadFile(c.path + ".etag")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return &cachedNodes{body: body, etag: string(etag), modified: info.ModTime()}, nil
+}
+
+func (c *nodesCache) write(body []byte, etag string) error {
+	if c == nil {
+		return nil
+	}
+
+	// the nodes may reveal the layout of the infrastructure
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(c.path+".yaml", body, 0o600); err != nil {
+		return err
+	}
+
+	if etag == "" {
+		if err := os.Remove(c.path + ".etag"); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(c.path+".etag", []byte(etag), 0o600)
+}
+
+// touch marks the cached nodes as confirmed now
+func (c *nodesCache) touch() error {
+	now := time.Now()
+
+	return os.Chtimes(c.path+".yaml", now, now)
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// NewTerraformStateNodesProvider returns a NodesProvider that reads the nodes
+// from the resource instances in the Terraform state at statePath. The
+// mapping is a URL query: "resource" selects the resources by address, with
+// glob patterns and repeatable, while "host", "ip" and "role" are templates
+// rendered with the attributes of every instance, e.g.
+// resource=hcloud_server.*&host={{.name}}&ip={{.ipv4_address}}&role={{.labels.role}}
+func NewTerraformStateNodesProvider(statePath, mapping string) (NodesProvider, error) {
+	query, err := url.ParseQuery(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid terraform-state mapping: %w", err)
+	}
+
+	n := &terraformStateNodes{
+		path:      statePath,
+		resources: query["resource"],
+		fields:    make(map[string]*template.Template),
+	}
+
+	if len(n.resources) == 0 {
+		return nil, errors.New("terraform-state mapping requires a resource")
+	}
+
+	for _, pattern := range n.resources {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid terraform-state resource %q: %w", pattern, err)
+		}
+	}
+
+	for _, field := range []string{"host", "ip", "role"} {
+		text := query.Get(field)
+		if text == "" {
+			if field == "ip" {
+				continue
+			}
+
+			return nil, fmt.Errorf("terraform-state mapping requires %s", field)
+		}
+
+		tmpl, err := template.New(field).Funcs(sprig.TxtFuncMap()).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid terraform-state mapping of %s: %w", field, err)
+		}
+
+		n.fields[field] = tmpl
+	}
+
+	return n, nil
+}
+
+type terraformStateNodes struct {
+	path      string
+	resources []string
+	fields    map[string]*template.Template
+}
+
+// terraformState is the part of the Terraform state format (version 4) the
+// nodes are read from
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+func (n *terraformStateNodes) GetNodes(clusterName string) ([]byte, error) {
+	statePath := strings.ReplaceAll(n.path, clusterPlaceholder, clusterName)
+
+	content, err := os.ReadFile(statePath) //nolint:gosec // reading the configured state is the purpose
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terraform state: %w", err)
+	}
+
+	var state terraformState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform state: %w", err)
+	}
+
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported terraform state version %d", state.Version)
+	}
+
+	var nodes []map[string]string
+
+	for _, resource := range state.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+
+		if !n.selects(address) {
+			continue
+		}
+
+		for i, instance := range resource.Instances {
+			node := make(map[string]string, len(n.fields))
+
+			for field, tmpl := range n.fields {
+				var value strings.Builder
+				if err := tmpl.Execute(&value, instance.Attributes); err != nil {
+					return nil, fmt.Errorf("mapping %s of %s[%d]: %w", field, address, i, err)
+				}
+
+				if value.Len() > 0 {
+					node[field] = value.String()
+				}
+			}
+
+			nodes = append(nodes, node)
+		}
+	}
+
+	return yaml.Marshal(nodes)
+}
+
+// selects reports whether the resource at address is mapped to nodes
+func (n *terraformStateNodes) selects(address string) bool {
+	for _, pattern := range n.resources {
+		if ok, _ := path.Match(pattern, address); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,331 @@
+// Copyright 2026 PostFinance AG
+// SPDX-License-Identifier: MIT
+
+package providers
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var discard = slog.New(slog.DiscardHandler) //nolint:gochecknoglobals // shared test logger
+
+// newHTTPNodes returns an HTTP nodes provider for rawURL with its own cache
+func newHTTPNodes(t *testing.T, rawURL string) *httpNodes {
+	t.Helper()
+
+	provider, err := NewHTTPNodesProvider(rawURL, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpProvider, _ := provider.(*httpNodes)
+	httpProvider.cacheDir = t.TempDir()
+
+	return httpProvider
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// getNodes returns the nodes of provider for the cluster "test"
+func getNodes(t *testing.T, provider NodesProvider) []map[string]string {
+	t.Helper()
+
+	content, err := provider.GetNodes("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []map[string]string
+	if err := yaml.Unmarshal(content, &nodes); err != nil {
+		t.Fatalf("invalid nodes %q: %v", content, err)
+	}
+
+	return nodes
+}
+
+func TestNewNodesProvider(t *testing.T) {
+	for _, tt := range []struct {
+		spec string
+		want NodesProvider
+	}{
+		{spec: "file://nodes/*.yaml", want: &fileNodes{pattern: "/etc/topf/nodes/*.yaml"}},
+		{spec: "file:///srv/nodes.json", want: &fileNodes{pattern: "/srv/nodes.json"}},
+		{spec: "https://inventory.example.com/clusters/{cluster}/nodes", want: &httpNodes{}},
+		{spec: "terraform-state://terraform.tfstate?resource=*&host={{.name}}&role=worker", want: &terraformStateNodes{}},
+		{spec: "./nodes-provider", want: &binaryNodes{binaryPath: "./nodes-provider"}},
+	} {
+		t.Run(tt.spec, func(t *testing.T) {
+			provider, err := NewNodesProvider(tt.spec, "/etc/topf", discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch want := tt.want.(type) {
+			case *fileNodes:
+				if got, ok := provider.(*fileNodes); !ok || *got != *want {
+					t.Errorf("got %#v, want %#v", provider, want)
+				}
+			case *binaryNodes:
+				if got, ok := provider.(*binaryNodes); !ok || *got != *want {
+					t.Errorf("got %#v, want %#v", provider, want)
+				}
+			case *httpNodes:
+				if got, ok := provider.(*httpNodes); !ok || got.url != tt.spec {
+					t.Errorf("got %#v, want an HTTP provider", provider)
+				}
+			case *terraformStateNodes:
+				if got, ok := provider.(*terraformStateNodes); !ok || got.path != "/etc/topf/terraform.tfstate" {
+					t.Errorf("got %#v, want a terraform-state provider", provider)
+				}
+			}
+		})
+	}
+}
+
+func TestFileNodes(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "test-a.yaml"), "- host: cp1\n  role: control-plane\n")
+	writeFile(t, filepath.Join(dir, "test-b.json"), `[{"host": "w1", "role": "worker", "ip": "192.0.2.1"}]`)
+	writeFile(t, filepath.Join(dir, "other.yaml"), "- host: other\n  role: worker\n")
+
+	nodes := getNodes(t, NewFileNodesProvider(filepath.Join(dir, "{cluster}-*")))
+
+	if len(nodes) != 2 || nodes[0]["host"] != "cp1" || nodes[1]["host"] != "w1" || nodes[1]["ip"] != "192.0.2.1" {
+		t.Errorf("unexpected nodes: %v", nodes)
+	}
+
+	if _, err := NewFileNodesProvider(filepath.Join(dir, "missing-*")).GetNodes("test"); err == nil {
+		t.Error("expected an error if no file matches")
+	}
+
+	writeFile(t, filepath.Join(dir, "invalid.yaml"), "host: n1\n")
+
+	if _, err := NewFileNodesProvider(filepath.Join(dir, "invalid.yaml")).GetNodes("test"); err == nil {
+		t.Error("expected an error for a file without a list")
+	}
+}
+
+func TestHTTPNodes(t *testing.T) {
+	var requests []*http.Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"host": "w1", "role": "worker"}]`))
+	}))
+
+	t.Setenv(NodesProviderTokenEnv, "secret")
+
+	t.Setenv(NodesProviderMaxCacheAgeEnv, "1h")
+
+	provider := newHTTPNodes(t, server.URL+"/clusters/{cluster}/nodes")
+
+	if provider.maxCacheAge != time.Hour {
+		t.Errorf("maxCacheAge = %s, want 1h", provider.maxCacheAge)
+	}
+
+	for range 2 {
+		if nodes := getNodes(t, provider); len(nodes) != 1 || nodes[0]["host"] != "w1" {
+			t.Errorf("unexpected nodes: %v", nodes)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+
+	if got := requests[0].URL.Path; got != "/clusters/test/nodes" {
+		t.Errorf("requested %s", got)
+	}
+
+	if got := requests[0].Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization header = %q", got)
+	}
+
+	if got := requests[1].Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("second request isn't conditional: If-None-Match = %q", got)
+	}
+
+	// the cached nodes are used while the server is unreachable
+	server.Close()
+
+	if nodes := getNodes(t, provider); len(nodes) != 1 || nodes[0]["host"] != "w1" {
+		t.Errorf("unexpected cached nodes: %v", nodes)
+	}
+
+	// but not once they are older than allowed
+	cached := provider.cache(strings.ReplaceAll(provider.url, clusterPlaceholder, "test")).path + ".yaml"
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cached, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.GetNodes("test"); err == nil || !strings.Contains(err.Error(), "cached nodes are 2h0m") {
+		t.Errorf("expected an error for expired cached nodes, got %v", err)
+	}
+
+	// nor if the fallback isn't enabled
+	provider.maxCacheAge = 0
+
+	if err := os.Chtimes(cached, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.GetNodes("test"); err == nil {
+		t.Error("expected an error without cache fallback")
+	}
+
+	provider.maxCacheAge = time.Hour
+	provider.cacheDir = t.TempDir()
+
+	_, err := provider.GetNodes("test")
+	if err == nil {
+		t.Fatal("expected an error without cached nodes")
+	}
+
+	if strings.Contains(err.Error(), "/clusters/") {
+		t.Errorf("error reveals the URL: %v", err)
+	}
+}
+
+func TestHTTPNodesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "error status", status: http.StatusForbidden},
+		{name: "no list", status: http.StatusOK, body: `{"host": "w1"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			provider := newHTTPNodes(t, server.URL)
+
+			if _, err := provider.GetNodes("test"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewHTTPNodesProviderMaxCacheAge(t *testing.T) {
+	for _, value := range []string{"1 day", "-1h"} {
+		t.Setenv(NodesProviderMaxCacheAgeEnv, value)
+
+		if _, err := NewHTTPNodesProvider("https://inventory.example.com", discard); err == nil {
+			t.Errorf("expected an error for %s=%q", NodesProviderMaxCacheAgeEnv, value)
+		}
+	}
+}
+
+const testState = `{
+  "version": 4,
+  "resources": [
+    {
+      "module": "module.test",
+      "mode": "managed",
+      "type": "hcloud_server",
+      "name": "control_plane",
+      "instances": [
+        {"attributes": {"name": "cp1", "ipv4_address": "192.0.2.1", "labels": {"role": "control-plane"}}}
+      ]
+    },
+    {
+      "module": "module.test",
+      "mode": "managed",
+      "type": "hcloud_server",
+      "name": "worker",
+      "instances": [
+        {"attributes": {"name": "w1", "ipv4_address": "", "labels": {"role": "worker"}}},
+        {"attributes": {"name": "w2", "ipv4_address": "192.0.2.12", "labels": {"role": "worker"}}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "hcloud_network",
+      "name": "net",
+      "instances": [{"attributes": {"name": "net"}}]
+    }
+  ]
+}`
+
+func TestTerraformStateNodes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "test.tfstate"), testState)
+
+	provider, err := NewNodesProvider("terraform-state://{cluster}.tfstate?resource=module.test.hcloud_server.*"+
+		"&host={{.name}}.example.com&ip={{.ipv4_address}}&role={{.labels.role}}", dir, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := getNodes(t, provider)
+
+	want := []map[string]string{
+		{"host": "cp1.example.com", "ip": "192.0.2.1", "role": "control-plane"},
+		{"host": "w1.example.com", "role": "worker"},
+		{"host": "w2.example.com", "ip": "192.0.2.12", "role": "worker"},
+	}
+
+	if len(nodes) != len(want) {
+		t.Fatalf("got nodes %v, want %v", nodes, want)
+	}
+
+	for i := range want {
+		if len(nodes[i]) != len(want[i]) || nodes[i]["host"] != want[i]["host"] || nodes[i]["ip"] != want[i]["ip"] || nodes[i]["role"] != want[i]["role"] {
+			t.Errorf("node %d = %v, want %v", i, nodes[i], want[i])
+		}
+	}
+
+	// mapping a missing attribute is an error rather than an empty value
+	provider, err = NewNodesProvider("terraform-state://test.tfstate?resource=*&host={{.name}}&role={{.labels.role}}", dir, discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.GetNodes("test"); err == nil || !strings.Contains(err.Error(), "hcloud_network.net") {
+		t.Errorf("expected an error mapping the network, got %v", err)
+	}
+}
+
+func TestTerraformStateMapping(t *testing.T) {
+	for _, mapping := range []string{
+		"host={{.name}}&role=worker",
+		"resource=*&role=worker",
+		"resource=*&host={{.name}}",
+		"resource=*&host={{.name&role=worker",
+		"resource=[&host={{.name}}&role=worker",
+	} {
+		if _, err := NewTerraformStateNodesProvider("terraform.tfstate", mapping); err == nil {
+			t.Errorf("expected an error for mapping %q", mapping)
+		}
+	}
+}